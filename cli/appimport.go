@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+func runImportApp(env *env, args []string) error {
+	fs := newFlagSet(env, "import-app")
+	source := fs.String("from", "", "app that wrote the export: "+strings.Join(logic.AppSources, ", "))
+	unit := fs.String("unit", "", "weight unit for exports that do not say (Strong); default kg")
+	dryRun := fs.Bool("dry-run", false, "preview the import without saving")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *source == "" {
+		fs.Usage()
+		return errors.New("expected -from and exactly one export file")
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	imp, err := env.tracker.ReadAppExport(env.ctx, file, *source, *unit)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewScanner(env.stdin)
+	for _, u := range imp.Unknown {
+		ex, remember, err := askMapping(env, in, u)
+		if err != nil {
+			return err
+		}
+		if !remember {
+			continue
+		}
+		if err := env.tracker.MapExercise(env.ctx, imp.Source, u.Name, ex); err != nil {
+			return err
+		}
+	}
+
+	res, err := env.tracker.ImportApp(env.ctx, imp, *dryRun)
+	if err != nil {
+		return err
+	}
+	printImportResult(env.stdout, res, *dryRun)
+	return nil
+}
+
+// askMapping prompts until the user picks a suggestion, types an exercise
+// name, ignores the name for good (0) or skips it for this import (empty
+// line). Running out of input skips everything that is left.
+func askMapping(env *env, in *bufio.Scanner, u logic.UnknownExercise) (exercise string, remember bool, err error) {
+	for {
+		fmt.Fprintf(env.stdout, "\nUnknown exercise %q (%d sets)\n", u.Name, u.Sets)
+		for i, s := range u.Suggestions {
+			fmt.Fprintf(env.stdout, "  %d) %s\n", i+1, s)
+		}
+		fmt.Fprintln(env.stdout, "  0) always ignore this exercise")
+		fmt.Fprint(env.stdout, "Choose a number, type an exercise name, or press enter to skip: ")
+		if !in.Scan() {
+			fmt.Fprintln(env.stdout)
+			return "", false, in.Err()
+		}
+		answer := strings.TrimSpace(in.Text())
+		if answer == "" {
+			return "", false, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n == 0 {
+				return "", true, nil
+			}
+			if n >= 1 && n <= len(u.Suggestions) {
+				return u.Suggestions[n-1], true, nil
+			}
+			fmt.Fprintln(env.stderr, "no such option")
+			continue
+		}
+		for _, ex := range data.AllExercises() {
+			if strings.EqualFold(ex, answer) {
+				return ex, true, nil
+			}
+		}
+		fmt.Fprintf(env.stderr, "%q is not an exercise in the program\n", answer)
+	}
+}
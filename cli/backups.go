@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+func runBackups(env *env, args []string) error {
+	fs := newFlagSet(env, "backups")
+	now := fs.Bool("now", false, "take a backup now")
+	keep := fs.Int("keep", 0, "set how many backups to keep")
+	restore := fs.String("restore", "", "restore the named backup, replacing all current data")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	b := env.backups
+
+	if *keep > 0 {
+		if err := b.SetKeep(env.ctx, *keep); err != nil {
+			return err
+		}
+	}
+	if *now {
+		info, err := b.Take(env.ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(env.stderr, "backup saved as", info.Name)
+	}
+	if *restore != "" {
+		path := *restore
+		if filepath.Base(path) == path {
+			path = filepath.Join(b.Dir(), path)
+		}
+		if err := b.Restore(env.ctx, path); err != nil {
+			return err
+		}
+		fmt.Fprintln(env.stderr, "restored", *restore, "(the previous state was backed up first)")
+		return nil
+	}
+
+	list, err := b.List()
+	if err != nil {
+		return err
+	}
+	n, err := b.Keep(env.ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "%d backups in %s, keeping %d\n", len(list), b.Dir(), n)
+	for _, info := range list {
+		p, err := b.Preview(env.ctx, info.Path)
+		if err != nil {
+			fmt.Fprintf(env.stdout, "  %s  unreadable: %v\n", info.Name, err)
+			continue
+		}
+		span := ""
+		if p.Entries > 0 {
+			span = fmt.Sprintf("  %s → %s", p.FirstDate, p.LastDate)
+		}
+		fmt.Fprintf(env.stdout, "  %s  %6d entries%s\n", info.Name, p.Entries, span)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+type env struct {
+	ctx     context.Context
+	repo    data.Store
+	tracker *logic.Tracker
+	anal    *logic.Analytics
+	backups *data.Backups
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(env *env, args []string) error
+}
+
+// commands is filled in init because the handlers refer back to it for
+// their usage text.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"log":        {"log [-sets n] [-rpe 8|\"2 rir\"] [-date YYYY-MM-DD] [-notes text] [-json] exercise weight reps", runLog},
+		"history":    {"history [-n count] [-chart weight|volume|e1rm] [-json] [exercise]", runHistory},
+		"pb":         {"pb [-json] [exercise]", runPB},
+		"plan":       {"plan [-day name] [-week 1|2] [-json]", runPlan},
+		"week":       {"week [-json] [1|2]", runWeek},
+		"plates":     {"plates [-bar type] [-json] weight", runPlates},
+		"rpe":        {"rpe [-json] [exercise reps rpe]", runRPE},
+		"program":    {"program [-json] [-clear] [-template linear|531|dup exercise[=tm] ...]", runProgram},
+		"fatigue":    {"fatigue [-json]", runFatigue},
+		"deload":     {"deload [-next] [-off] [-list] [date]", runDeload},
+		"goal":       {"goal [-json] [-delete id] [-e1rm] [-by date] [exercise|bodyweight target-kg]", runGoal},
+		"bodyweight": {"bodyweight [-date YYYY-MM-DD] [-json] [kg]", runBodyweight},
+		"export":     {"export [-o file] [-format csv|json] [-exercise name] [-from date] [-to date]", runExport},
+		"import":     {"import [-unit kg|lb] [-map field=Column,...] [-dry-run] file.csv", runImport},
+		"backup":     {"backup [-o file.json]", runBackup},
+		"restore":    {"restore [-mode merge|replace] file.json", runRestore},
+		"backups":    {"backups [-now] [-keep n] [-restore name]", runBackups},
+		"serve":      {"serve [-addr host:port] [-reset-token]", runServe},
+		"import-app": {"import-app -from strong|hevy|fitnotes [-unit kg|lb] [-dry-run] file.csv", runImportApp},
+	}
+}
+
+// IsCommand reports whether name is a known subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand in args[0] and returns the process exit code.
+// Cancelling ctx stops long-running commands such as serve.
+func Run(ctx context.Context, args []string, repo data.Store, tracker *logic.Tracker, anal *logic.Analytics, backups *data.Backups, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		usage(stderr)
+		return 2
+	}
+	env := &env{ctx: ctx, repo: repo, tracker: tracker, anal: anal, backups: backups, stdin: stdin, stdout: stdout, stderr: stderr}
+	if err := commands[args[0]].run(env, args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(stderr, "error:", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: progresstracker [-db file] [-config file] [-migrate] [-serve addr] [command [flags]]")
+	fmt.Fprintln(w)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, "  progresstracker", commands[name].usage)
+	}
+}
+
+func newFlagSet(env *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(env.stderr, "usage: progresstracker", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses fs from args, allowing flags after positional arguments
+// ("week 2 -json") as well as before them. "--" ends flag parsing, and an
+// argument starting with "-" and a digit, such as a date offset like "-1w",
+// is positional.
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' || (arg[1] >= '0' && arg[1] <= '9') {
+			positional = append(positional, arg)
+			continue
+		}
+		flags = append(flags, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			continue // fs.Parse reports it
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return fs.Parse(append(append(flags, "--"), positional...))
+}
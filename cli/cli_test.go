@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+func TestParseArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		json bool
+		n    int
+		date string
+		rest []string
+	}{
+		{args: []string{"2", "-json"}, json: true, rest: []string{"2"}},
+		{args: []string{"-n", "5", "squat"}, n: 5, rest: []string{"squat"}},
+		{args: []string{"squat", "-n", "5"}, n: 5, rest: []string{"squat"}},
+		{args: []string{"squat", "-n=5", "--json"}, n: 5, json: true, rest: []string{"squat"}},
+		{args: []string{"a", "-json", "b"}, json: true, rest: []string{"a", "b"}},
+		{args: []string{"a", "--", "-json", "b"}, rest: []string{"a", "-json", "b"}},
+		{args: []string{"-date", "-2d", "squat"}, date: "-2d", rest: []string{"squat"}},
+		{args: []string{"-1w", "-json"}, json: true, rest: []string{"-1w"}},
+		{args: []string{"-", "x"}, rest: []string{"-", "x"}},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		asJSON := fs.Bool("json", false, "")
+		n := fs.Int("n", 0, "")
+		date := fs.String("date", "", "")
+		if err := parseArgs(fs, tc.args); err != nil {
+			t.Errorf("parseArgs(%q): %v", tc.args, err)
+			continue
+		}
+		if *asJSON != tc.json || *n != tc.n || *date != tc.date || !slices.Equal(fs.Args(), tc.rest) {
+			t.Errorf("parseArgs(%q) = json %t, n %d, date %q, args %q", tc.args, *asJSON, *n, *date, fs.Args())
+		}
+	}
+	for _, args := range [][]string{{"-bogus"}, {"squat", "-n"}, {"-n", "five"}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Int("n", 0, "")
+		if err := parseArgs(fs, args); err == nil {
+			t.Errorf("parseArgs(%q) succeeded", args)
+		}
+	}
+}
+
+// cli runs commands against one in-memory store.
+type cli struct {
+	t    *testing.T
+	repo *data.MemoryStore
+}
+
+func newCLI(t *testing.T) *cli {
+	return &cli{t: t, repo: data.NewMemoryStore()}
+}
+
+func (c *cli) run(args ...string) (stdout, stderr string, code int) {
+	var out, errOut bytes.Buffer
+	code = Run(c.t.Context(), args, c.repo, logic.NewTracker(c.repo), logic.NewAnalytics(c.repo), nil,
+		strings.NewReader(""), &out, &errOut)
+	return out.String(), errOut.String(), code
+}
+
+func TestRunUsage(t *testing.T) {
+	c := newCLI(t)
+	for _, args := range [][]string{nil, {"lift"}} {
+		if _, stderr, code := c.run(args...); code != 2 || !strings.Contains(stderr, "usage: progresstracker") {
+			t.Errorf("Run(%q) = %d, stderr %q; want usage and 2", args, code, stderr)
+		}
+	}
+	// -h prints the command's usage, not an error
+	_, stderr, code := c.run("log", "-h")
+	if code != 1 || !strings.Contains(stderr, "usage: progresstracker log") || strings.Contains(stderr, "error:") {
+		t.Errorf("log -h = %d, stderr %q", code, stderr)
+	}
+}
+
+func TestRunLog(t *testing.T) {
+	c := newCLI(t)
+	out, stderr, code := c.run("log", "barbell squats", "100", "5", "-date", "2025-03-03")
+	if code != 0 || out != "logged Barbell Squats: 100.00 kg × 5 × 3 (volume 1500.0)\n" {
+		t.Fatalf("log = %d, %q, %q", code, out, stderr)
+	}
+	out, _, code = c.run("log", "-sets", "1", "-rpe", "2 rir", "barbell squats", "105", "5", "-date=2025-03-05")
+	if code != 0 || !strings.Contains(out, "105.00 kg × 5 × 1 @8") || !strings.Contains(out, "new personal best, up from 100.00 kg") {
+		t.Errorf("log of a PB = %d, %q", code, out)
+	}
+	out, _, code = c.run("log", "-json", "barbell squats", "60", "10", "-date", "2025-03-04")
+	var res struct {
+		Entry *data.Entry `json:"entry"`
+		NewPB bool        `json:"new_pb"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); code != 0 || err != nil || res.Entry.Weight != 60 || res.NewPB {
+		t.Errorf("log -json = %d, %q, %v", code, out, err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"log", "barbell squats", "100"}, "expected exercise, weight and reps"},
+		{[]string{"log", "zercher squat", "100", "5"}, `no exercise matches "zercher squat"`},
+		{[]string{"log", "curl", "20", "10"}, "is ambiguous"},
+		{[]string{"log", "barbell squats", "heavy", "5"}, "invalid weight"},
+		{[]string{"log", "-sets", "x", "barbell squats", "100", "5"}, "invalid sets"},
+	} {
+		out, stderr, code := c.run(tc.args...)
+		if code != 1 || out != "" || !strings.Contains(stderr, "error: ") || !strings.Contains(stderr, tc.want) {
+			t.Errorf("Run(%q) = %d, stdout %q, stderr %q; want an error with %q", tc.args, code, out, stderr, tc.want)
+		}
+	}
+	if all, _ := c.repo.All(t.Context()); len(all) != 3 {
+		t.Errorf("%d entries saved, want 3", len(all))
+	}
+}
+
+func TestRunQueries(t *testing.T) {
+	c := newCLI(t)
+	for _, args := range [][]string{
+		{"log", "barbell squats", "100", "5", "-date", "2025-03-03"},
+		{"log", "barbell squats", "110", "3", "-date", "2025-03-05", "-notes", "belt"},
+		{"log", "preacher curl", "20", "10", "-date", "2025-03-04"},
+	} {
+		if _, stderr, code := c.run(args...); code != 0 {
+			t.Fatalf("Run(%q): %s", args, stderr)
+		}
+	}
+
+	out, _, _ := c.run("history", "barbell squats")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "2025-03-05") || !strings.HasSuffix(lines[0], "belt") {
+		t.Errorf("history barbell squats =\n%s", out)
+	}
+	out, _, _ = c.run("history", "-json", "-n", "2")
+	var entries []*data.Entry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil || len(entries) != 2 || entries[0].Date != "2025-03-05" {
+		t.Errorf("history -json -n 2 = %s, %v", out, err)
+	}
+	out, _, _ = c.run("history", "barbell squats", "-chart", "weight")
+	if out != "2025-03-03      100.00\n2025-03-05      110.00\n" {
+		t.Errorf("history -chart weight = %q", out)
+	}
+	if _, stderr, code := c.run("history", "-chart", "weight"); code != 1 || !strings.Contains(stderr, "-chart needs an exercise") {
+		t.Errorf("history -chart without an exercise = %d, %q", code, stderr)
+	}
+
+	out, _, _ = c.run("pb")
+	if !strings.Contains(out, "Barbell Squats") || !strings.Contains(out, "110.00 kg") || !strings.Contains(out, "Preacher Curl") {
+		t.Errorf("pb =\n%s", out)
+	}
+	out, _, _ = c.run("pb", "-json", "plank")
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("pb of an exercise never logged = %q, want []", out)
+	}
+
+	if out, _, code := c.run("week", "2", "-json"); code != 0 || !strings.Contains(out, `"week": 2`) {
+		t.Errorf("week 2 -json = %d, %q", code, out)
+	}
+	if out, _, _ := c.run("week"); out != "week 2\n" {
+		t.Errorf("week = %q", out)
+	}
+	if _, stderr, code := c.run("week", "3"); code != 1 || !strings.Contains(stderr, "want 1 or 2") {
+		t.Errorf("week 3 = %d, %q", code, stderr)
+	}
+
+	out, _, _ = c.run("plan", "-day", "mon", "-week", "1")
+	if !strings.HasPrefix(out, "Monday, week 1\n") || !strings.Contains(out, "never logged") {
+		t.Errorf("plan -day mon =\n%s", out)
+	}
+	if out, _, _ := c.run("plan", "-day", "sunday"); out != "Sunday is a rest day\n" {
+		t.Errorf("plan -day sunday = %q", out)
+	}
+	if _, stderr, code := c.run("plan", "-day", "someday"); code != 1 || !strings.Contains(stderr, "unknown day") {
+		t.Errorf("plan -day someday = %d, %q", code, stderr)
+	}
+
+	// a date offset is a positional argument, not a flag
+	if out, stderr, code := c.run("deload", "-1w"); code != 0 || !strings.HasSuffix(out, "is a deload\n") {
+		t.Errorf("deload -1w = %d, %q, %q", code, out, stderr)
+	}
+}
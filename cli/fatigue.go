@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+)
+
+// runFatigue prints the fatigue report and whether a deload is due.
+func runFatigue(env *env, args []string) error {
+	fs := newFlagSet(env, "fatigue")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("expected no arguments")
+	}
+	r, err := env.anal.Fatigue(env.ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, r)
+	}
+	if r.Deloading {
+		fmt.Fprintf(env.stdout, "the week of %s is a deload\n", r.Week)
+		return nil
+	}
+	if r.ACWR > 0 {
+		fmt.Fprintf(env.stdout, "acute:chronic workload %.2f (%.0f kg in 7 days, %.0f a week over 4 weeks)\n", r.ACWR, r.AcuteVolume, r.ChronicVolume)
+	} else {
+		fmt.Fprintln(env.stdout, "acute:chronic workload needs two weeks of history")
+	}
+	if r.RPE > 0 {
+		fmt.Fprintf(env.stdout, "average RPE %g over two weeks, %g the two before\n", r.RPE, r.PrevRPE)
+	}
+	for _, d := range r.Drops {
+		fmt.Fprintf(env.stdout, "%s: e1RM %.1f kg on %s, %.0f%% under %.1f\n", d.Exercise, d.E1RM, d.Date, d.Drop()*100, d.Best)
+	}
+	if r.WeeksSinceDeload >= 0 {
+		fmt.Fprintf(env.stdout, "last deload %d weeks ago\n", r.WeeksSinceDeload)
+	}
+	switch {
+	case r.Deload:
+		fmt.Fprintf(env.stdout, "deload recommended: %s\n", strings.Join(r.Signals, "; "))
+	case len(r.Signals) > 0:
+		fmt.Fprintf(env.stdout, "watch: %s\n", strings.Join(r.Signals, "; "))
+	default:
+		fmt.Fprintln(env.stdout, "no signs of fatigue")
+	}
+	return nil
+}
+
+// runDeload marks the week a date falls in as a deload, or lists the marked
+// weeks.
+func runDeload(env *env, args []string) error {
+	fs := newFlagSet(env, "deload")
+	next := fs.Bool("next", false, "mark next week instead of this one")
+	off := fs.Bool("off", false, "unmark the week")
+	list := fs.Bool("list", false, "list the weeks marked as deloads")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *list {
+		weeks, err := env.tracker.DeloadWeeks(env.ctx)
+		if err != nil {
+			return err
+		}
+		if len(weeks) == 0 {
+			fmt.Fprintln(env.stdout, "no deload weeks")
+		}
+		for _, w := range weeks {
+			fmt.Fprintf(env.stdout, "week of %s\n", w)
+		}
+		return nil
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("expected at most one date")
+	}
+	day := data.Today()
+	if fs.NArg() == 1 {
+		var err error
+		if day, err = data.ResolveDate(fs.Arg(0), day); err != nil {
+			return err
+		}
+	}
+	if *next {
+		day = day.AddDays(7)
+	}
+	if err := env.tracker.SetDeload(env.ctx, day, !*off); err != nil {
+		return err
+	}
+	if *off {
+		fmt.Fprintf(env.stdout, "the week of %s is no longer a deload\n", day)
+	} else {
+		fmt.Fprintf(env.stdout, "the week of %s is a deload\n", day)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+// runGoal sets a goal, deletes one, or lists them with their forecasts.
+func runGoal(env *env, args []string) error {
+	fs := newFlagSet(env, "goal")
+	asJSON := fs.Bool("json", false, "print JSON")
+	del := fs.Int64("delete", 0, "delete the goal with this id")
+	e1rm := fs.Bool("e1rm", false, "measure the goal against the estimated 1RM rather than the heaviest set")
+	by := fs.String("by", "", "target date: YYYY-MM-DD or an offset such as +12w")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	switch {
+	case *del > 0:
+		if err := env.tracker.DeleteGoal(env.ctx, *del); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.stdout, "deleted goal %d\n", *del)
+		return nil
+	case fs.NArg() == 2:
+		return addGoal(env, fs.Arg(0), fs.Arg(1), *by, *e1rm, *asJSON)
+	case fs.NArg() != 0:
+		fs.Usage()
+		return errors.New("expected an exercise or bodyweight and a target")
+	}
+
+	goals, err := env.anal.Goals(env.ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, nonNil(goals))
+	}
+	if len(goals) == 0 {
+		fmt.Fprintln(env.stdout, "no goals")
+	}
+	for _, g := range goals {
+		fmt.Fprintf(env.stdout, "%d. %s: %g kg now, %.0f%% of the way; %s\n", g.ID, g.Title, g.Current, g.Progress*100, g.Status)
+	}
+	return nil
+}
+
+func addGoal(env *env, what, target, by string, e1rm, asJSON bool) error {
+	metric, exercise := data.GoalWeight, ""
+	if e1rm {
+		metric = data.GoalE1RM
+	}
+	if strings.EqualFold(what, data.GoalBodyweight) {
+		metric = data.GoalBodyweight
+	} else {
+		var err error
+		if exercise, err = resolveExercise(what); err != nil {
+			return err
+		}
+	}
+	target = strings.TrimSuffix(strings.ToLower(target), "kg")
+	g, err := env.tracker.AddGoal(env.ctx, metric, exercise, target, by, data.Today())
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(env.stdout, g)
+	}
+	fmt.Fprintf(env.stdout, "goal %d set: %s\n", g.ID, logic.GoalTitle(g))
+	return nil
+}
+
+// runBodyweight records the day's bodyweight, or lists the readings.
+func runBodyweight(env *env, args []string) error {
+	fs := newFlagSet(env, "bodyweight")
+	date := fs.String("date", "", "day of the reading: YYYY-MM-DD, yesterday or -2d (default today)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		readings, err := env.repo.Bodyweights(env.ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(env.stdout, nonNil(readings))
+		}
+		if len(readings) == 0 {
+			fmt.Fprintln(env.stdout, "no bodyweight readings")
+		}
+		for _, b := range readings {
+			fmt.Fprintf(env.stdout, "%s  %s kg\n", b.Date, strconv.FormatFloat(b.Weight, 'f', -1, 64))
+		}
+		return nil
+	case 1:
+	default:
+		fs.Usage()
+		return errors.New("expected a weight in kg")
+	}
+	b, err := env.tracker.LogBodyweight(env.ctx, strings.TrimSuffix(strings.ToLower(fs.Arg(0)), "kg"), *date)
+	if err != nil {
+		return err
+	}
+	reached, err := env.tracker.CheckGoals(env.ctx)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, struct {
+			Reading      data.Bodyweight `json:"reading"`
+			GoalsReached []*data.Goal    `json:"goals_reached,omitempty"`
+		}{b, reached})
+	}
+	fmt.Fprintf(env.stdout, "bodyweight %g kg on %s\n", b.Weight, b.Date)
+	printReached(env, reached)
+	return nil
+}
+
+func printReached(env *env, reached []*data.Goal) {
+	for _, g := range reached {
+		fmt.Fprintf(env.stdout, "goal reached: %s\n", logic.GoalTitle(g))
+	}
+}
@@ -0,0 +1,533 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+func runLog(env *env, args []string) error {
+	fs := newFlagSet(env, "log")
+	sets := fs.String("sets", "3", "number of sets")
+	date := fs.String("date", "", "date of the session: YYYY-MM-DD, yesterday or -2d (default today)")
+	rpe := fs.String("rpe", "", "how hard the sets were: an RPE such as 8, or reps in reserve such as \"2 rir\"")
+	notes := fs.String("notes", "", "free-text notes")
+	asJSON := fs.Bool("json", false, "print the saved entry as JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return errors.New("expected exercise, weight and reps")
+	}
+	exercise, err := resolveExercise(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	prev, err := env.tracker.GetLastEntry(env.ctx, exercise)
+	if err != nil {
+		return err
+	}
+	pb, err := env.tracker.GetPersonalBest(env.ctx, exercise)
+	if err != nil {
+		return err
+	}
+	e, err := env.tracker.AddEntry(env.ctx, exercise, fs.Arg(1), fs.Arg(2), *sets, *rpe, *notes, *date)
+	if err != nil {
+		return err
+	}
+	newPB := prev != nil && e.Weight > pb.MaxWeight
+	reached, err := env.tracker.CheckGoals(env.ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(env.stdout, struct {
+			Entry        *data.Entry  `json:"entry"`
+			NewPB        bool         `json:"new_pb"`
+			GoalsReached []*data.Goal `json:"goals_reached,omitempty"`
+		}{e, newPB, reached})
+	}
+	fmt.Fprintf(env.stdout, "logged %s: %s (volume %.1f)\n", e.Exercise, formatSet(e), e.Volume)
+	if newPB {
+		fmt.Fprintf(env.stdout, "new personal best, up from %.2f kg\n", pb.MaxWeight)
+	}
+	printReached(env, reached)
+	return nil
+}
+
+func runHistory(env *env, args []string) error {
+	fs := newFlagSet(env, "history")
+	limit := fs.Int("n", 0, "show only the newest n entries")
+	series := fs.String("chart", "", "print the weight, volume or e1rm series used by the charts instead")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("expected at most one exercise")
+	}
+	exercise := ""
+	if fs.NArg() == 1 {
+		var err error
+		if exercise, err = resolveExercise(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	if *series != "" {
+		if exercise == "" {
+			return errors.New("-chart needs an exercise")
+		}
+		var pts []logic.ChartPoint
+		var err error
+		switch *series {
+		case "weight":
+			pts, err = env.anal.WeightOverTime(env.ctx, exercise)
+		case "volume":
+			pts, err = env.anal.VolumeOverTime(env.ctx, exercise)
+		case "e1rm":
+			pts, err = env.anal.E1RMOverTime(env.ctx, exercise)
+		default:
+			return fmt.Errorf("unknown chart %q, want weight, volume or e1rm", *series)
+		}
+		if err != nil {
+			return err
+		}
+		if *limit > 0 && len(pts) > *limit {
+			pts = pts[len(pts)-*limit:]
+		}
+		if *asJSON {
+			return writeJSON(env.stdout, nonNil(pts))
+		}
+		for _, p := range pts {
+			fmt.Fprintf(env.stdout, "%s  %10.2f\n", p.Date, p.Value)
+		}
+		return nil
+	}
+
+	var entries []*data.Entry
+	var err error
+	if exercise == "" {
+		entries, err = env.tracker.GetAllEntries(env.ctx)
+	} else {
+		entries, err = env.tracker.GetHistory(env.ctx, exercise)
+	}
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, nonNil(entries))
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(env.stdout, "no entries")
+	}
+	for _, e := range entries {
+		line := fmt.Sprintf("%s  %-40s %s", e.Date, e.Exercise, formatSet(e))
+		if e.Warmup {
+			line += "  warm-up"
+		}
+		if e.Notes != "" {
+			line += "  " + e.Notes
+		}
+		fmt.Fprintln(env.stdout, line)
+	}
+	return nil
+}
+
+func runPB(env *env, args []string) error {
+	fs := newFlagSet(env, "pb")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	exercises := data.AllExercises()
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		ex, err := resolveExercise(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		exercises = []string{ex}
+	default:
+		fs.Usage()
+		return errors.New("expected at most one exercise")
+	}
+
+	var pbs []*data.PersonalBest
+	for _, ex := range exercises {
+		last, err := env.tracker.GetLastEntry(env.ctx, ex)
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			continue
+		}
+		pb, err := env.tracker.GetPersonalBest(env.ctx, ex)
+		if err != nil {
+			return err
+		}
+		pbs = append(pbs, pb)
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, nonNil(pbs))
+	}
+	if len(pbs) == 0 {
+		fmt.Fprintln(env.stdout, "no entries")
+	}
+	for _, pb := range pbs {
+		fmt.Fprintf(env.stdout, "%-40s %8.2f kg  %10.1f volume\n", pb.Exercise, pb.MaxWeight, pb.MaxVolume)
+	}
+	return nil
+}
+
+type planItem struct {
+	Exercise string      `json:"exercise"`
+	Last     *data.Entry `json:"last"`
+}
+
+func runPlan(env *env, args []string) error {
+	fs := newFlagSet(env, "plan")
+	dayName := fs.String("day", "", "day to show (default today)")
+	week := fs.Int("week", 0, "program week, 1 or 2 (default the current week)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	day := time.Now().Weekday().String()
+	if *dayName != "" {
+		var err error
+		if day, err = resolveDay(*dayName); err != nil {
+			return err
+		}
+	}
+	if *week == 0 {
+		var err error
+		if *week, err = env.repo.GetCurrentWeek(env.ctx); err != nil {
+			return err
+		}
+	}
+	if *week != 1 && *week != 2 {
+		return fmt.Errorf("invalid week %d, want 1 or 2", *week)
+	}
+
+	items := []planItem{}
+	for _, ex := range data.WorkoutDays(day, *week) {
+		last, err := env.tracker.GetLastEntry(env.ctx, ex)
+		if err != nil {
+			return err
+		}
+		items = append(items, planItem{Exercise: ex, Last: last})
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, struct {
+			Day       string     `json:"day"`
+			Week      int        `json:"week"`
+			Exercises []planItem `json:"exercises"`
+		}{day, *week, items})
+	}
+	if len(items) == 0 {
+		fmt.Fprintf(env.stdout, "%s is a rest day\n", day)
+		return nil
+	}
+	fmt.Fprintf(env.stdout, "%s, week %d\n", day, *week)
+	// supersets and circuits are lettered: 3a, 3b
+	labels := data.StepLabels(data.WorkoutBlocks(day, *week))
+	for i, it := range items {
+		last := "never logged"
+		if it.Last != nil {
+			last = fmt.Sprintf("last %s on %s", formatSet(it.Last), it.Last.Date)
+		}
+		fmt.Fprintf(env.stdout, "  %3s. %-40s %s\n", labels[i], it.Exercise, last)
+	}
+	return nil
+}
+
+func runWeek(env *env, args []string) error {
+	fs := newFlagSet(env, "week")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		w, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || (w != 1 && w != 2) {
+			return fmt.Errorf("invalid week %q, want 1 or 2", fs.Arg(0))
+		}
+		if err := env.repo.SetCurrentWeek(env.ctx, w); err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		return errors.New("expected at most one week number")
+	}
+	week, err := env.repo.GetCurrentWeek(env.ctx)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, struct {
+			Week int `json:"week"`
+		}{week})
+	}
+	fmt.Fprintf(env.stdout, "week %d\n", week)
+	return nil
+}
+
+type plateLoad struct {
+	Weight  float64   `json:"weight"`
+	Bar     float64   `json:"bar"`
+	PerSide []float64 `json:"per_side"`
+	Short   float64   `json:"short"` // per side, when the plates cannot make the weight
+}
+
+func runPlates(env *env, args []string) error {
+	fs := newFlagSet(env, "plates")
+	barType := fs.String("bar", data.Barbell, "bar type from the equipment profile, e.g. ez-bar")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected a weight")
+	}
+	w, err := strconv.ParseFloat(fs.Arg(0), 64)
+	if err != nil || w < 0 || w > logic.MaxWeight {
+		return fmt.Errorf("invalid weight %q", fs.Arg(0))
+	}
+	eq, err := env.tracker.Equipment(env.ctx)
+	if err != nil {
+		return err
+	}
+	bar, ok := eq.Bars[*barType]
+	if !ok {
+		return fmt.Errorf("no bar type %q in the equipment profile (%s)", *barType, eq.BarsString())
+	}
+	if w < bar {
+		return fmt.Errorf("%g kg is lighter than the %g kg bar", w, bar)
+	}
+	load := plateLoad{Weight: w, Bar: bar}
+	load.PerSide, load.Short = eq.LoadPlates(w, bar)
+	if *asJSON {
+		load.PerSide = nonNil(load.PerSide)
+		return writeJSON(env.stdout, load)
+	}
+	parts := make([]string, len(load.PerSide))
+	for i, p := range load.PerSide {
+		parts[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	if len(parts) == 0 {
+		parts = []string{"nothing"}
+	}
+	fmt.Fprintf(env.stdout, "%g kg on the %g kg bar: %s per side\n", w, bar, strings.Join(parts, " + "))
+	if load.Short > 0 {
+		fmt.Fprintf(env.stdout, "%g kg per side short with the plates available\n", load.Short)
+	}
+	return nil
+}
+
+type loadSuggestion struct {
+	Exercise string  `json:"exercise"`
+	E1RM     float64 `json:"e1rm"`
+	Reps     int     `json:"reps"`
+	RPE      float64 `json:"rpe"`
+	Weight   float64 `json:"weight"`
+}
+
+// runRPE prints the reference RPE chart, or with an exercise, reps and a
+// target RPE, the load to use from the exercise's last session.
+func runRPE(env *env, args []string) error {
+	fs := newFlagSet(env, "rpe")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	switch fs.NArg() {
+	case 0:
+		rows := logic.RPEChart()
+		if *asJSON {
+			return writeJSON(env.stdout, rows)
+		}
+		fmt.Fprint(env.stdout, "RPE  ")
+		for reps := 1; reps <= logic.ChartReps; reps++ {
+			fmt.Fprintf(env.stdout, "%6d", reps)
+		}
+		fmt.Fprintln(env.stdout)
+		for _, row := range rows {
+			fmt.Fprintf(env.stdout, "%-5g", row.RPE)
+			for _, p := range row.Percent {
+				fmt.Fprintf(env.stdout, "%6.1f", p)
+			}
+			fmt.Fprintln(env.stdout)
+		}
+		return nil
+	case 3:
+	default:
+		fs.Usage()
+		return errors.New("expected no arguments, or exercise, reps and rpe")
+	}
+	exercise, err := resolveExercise(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	reps, err := strconv.Atoi(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("invalid reps %q", fs.Arg(1))
+	}
+	rpe, err := logic.ParseEffort(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	e1rm, err := env.tracker.RecentE1RM(env.ctx, exercise)
+	if err != nil {
+		return err
+	}
+	if e1rm == 0 {
+		return fmt.Errorf("no sets of %s logged yet", exercise)
+	}
+	w, ok := logic.SuggestLoad(e1rm, reps, rpe)
+	if !ok {
+		return fmt.Errorf("the RPE chart does not cover %d reps at RPE %g", reps, rpe)
+	}
+	eq, err := env.tracker.Equipment(env.ctx)
+	if err != nil {
+		return err
+	}
+	s := loadSuggestion{exercise, math.Round(e1rm*10) / 10, reps, rpe, math.Round(eq.Round(exercise, w)*10) / 10}
+	if *asJSON {
+		return writeJSON(env.stdout, s)
+	}
+	fmt.Fprintf(env.stdout, "%s: %g kg for %d reps at RPE %g (e1RM %.1f kg from the last session)\n", s.Exercise, s.Weight, s.Reps, s.RPE, s.E1RM)
+	return nil
+}
+
+// runProgram shows what the program prescribes for each lift, or starts or
+// clears one. Lifts are given as exercise=training max; without one, 90% of
+// the recent e1RM is used.
+func runProgram(env *env, args []string) error {
+	fs := newFlagSet(env, "program")
+	template := fs.String("template", "", "start a program from this template: linear, 531 or dup")
+	clear := fs.Bool("clear", false, "drop the program and go back to the usual targets")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	today := data.Today()
+	switch {
+	case *clear:
+		if err := env.tracker.ClearProgram(env.ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(env.stdout, "program cleared")
+		return nil
+	case *template != "":
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return errors.New("expected the lifts to program")
+		}
+		lifts := make([]string, fs.NArg())
+		for i, arg := range fs.Args() {
+			name, tm, hasTM := strings.Cut(arg, "=")
+			ex, err := resolveExercise(name)
+			if err != nil {
+				return err
+			}
+			lifts[i] = ex
+			if hasTM {
+				lifts[i] += "=" + tm
+			}
+		}
+		if _, err := env.tracker.SetProgram(env.ctx, *template, strings.Join(lifts, ","), today); err != nil {
+			return err
+		}
+	case fs.NArg() > 0:
+		fs.Usage()
+		return errors.New("lifts are only given with -template")
+	}
+
+	prs, err := env.tracker.Prescriptions(env.ctx, today)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(env.stdout, nonNil(prs))
+	}
+	if len(prs) == 0 {
+		fmt.Fprintln(env.stdout, "no program; start one with -template")
+	}
+	for _, pr := range prs {
+		fmt.Fprintf(env.stdout, "%s (training max %g kg, cycle %d)\n  %s\n", pr.Exercise, pr.TrainingMax, pr.Cycle, pr)
+	}
+	return nil
+}
+
+// resolveExercise accepts any unambiguous, case-insensitive part of an
+// exercise name, so "pec dec" finds "Seated Pec Dec Flies Machine".
+func resolveExercise(name string) (string, error) {
+	want := strings.ToLower(strings.TrimSpace(name))
+	var matches []string
+	for _, ex := range data.AllExercises() {
+		lower := strings.ToLower(ex)
+		if lower == want {
+			return ex, nil
+		}
+		if strings.Contains(lower, want) {
+			matches = append(matches, ex)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no exercise matches %q", name)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("%q is ambiguous: %s", name, strings.Join(matches, ", "))
+}
+
+func resolveDay(name string) (string, error) {
+	for _, d := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		if len(name) >= 3 && strings.HasPrefix(strings.ToLower(d.String()), strings.ToLower(name)) {
+			return d.String(), nil
+		}
+	}
+	return "", fmt.Errorf("unknown day %q", name)
+}
+
+func formatSet(e *data.Entry) string {
+	s := fmt.Sprintf("%.2f kg × %d × %d", e.Weight, e.Reps, e.Sets)
+	if e.RPE != 0 {
+		s += " " + logic.FormatEffort(e.RPE)
+	}
+	return s
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// nonNil makes empty results encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"progresstracker/server"
+)
+
+func runServe(env *env, args []string) error {
+	fs := newFlagSet(env, "serve")
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	reset := fs.Bool("reset-token", false, "replace the stored API token with a new one")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	if *reset {
+		if _, err := server.ResetToken(env.ctx, env.repo); err != nil {
+			return err
+		}
+	}
+	token, err := server.Token(env.ctx, env.repo)
+	if err != nil {
+		return err
+	}
+
+	go env.backups.RunDaily(env.ctx, func(err error) {
+		fmt.Fprintln(env.stderr, "backup failed:", err)
+	})
+
+	h := server.New(env.repo, env.tracker, env.anal, token)
+	return server.ListenAndServe(env.ctx, *addr, h, func(bound string) {
+		fmt.Fprintf(env.stderr, "serving on http://%s (token %s)\n", bound, token)
+	})
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+func runExport(env *env, args []string) error {
+	fs := newFlagSet(env, "export")
+	out := fs.String("o", "", "output file (default stdout)")
+	var f logic.ExportFilter
+	fs.StringVar(&f.Exercise, "exercise", "", "only export this exercise")
+	fs.TextVar(&f.From, "from", data.Date(""), "first `date` to include (YYYY-MM-DD)")
+	fs.TextVar(&f.To, "to", data.Date(""), "last `date` to include (YYYY-MM-DD)")
+	format := fs.String("format", "csv", "csv or json")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if f.Exercise != "" {
+		ex, err := resolveExercise(f.Exercise)
+		if err != nil {
+			return err
+		}
+		f.Exercise = ex
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q, want csv or json", *format)
+	}
+
+	var w io.Writer = env.stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	var n int
+	if *format == "json" {
+		entries, err := env.tracker.ExportEntries(env.ctx, f)
+		if err != nil {
+			return err
+		}
+		if err := writeJSON(w, nonNil(entries)); err != nil {
+			return err
+		}
+		n = len(entries)
+	} else {
+		var err error
+		if n, err = env.tracker.ExportCSV(env.ctx, w, f); err != nil {
+			return err
+		}
+	}
+	if *out != "" {
+		fmt.Fprintf(env.stderr, "exported %d entries to %s\n", n, *out)
+	}
+	return nil
+}
+
+func runImport(env *env, args []string) error {
+	fs := newFlagSet(env, "import")
+	var opts logic.ImportOptions
+	fs.StringVar(&opts.Unit, "unit", "", "weight unit of the file, kg or lb (default: detect from header, else kg)")
+	mapping := fs.String("map", "", "column mapping, e.g. date=Day,weight=Load (lbs)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "preview the import without saving")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one CSV file")
+	}
+	m, err := logic.ParseColumnMapping(*mapping)
+	if err != nil {
+		return err
+	}
+	opts.Mapping = m
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	res, err := env.tracker.ImportCSV(env.ctx, file, opts)
+	if err != nil {
+		return err
+	}
+	printImportResult(env.stdout, res, opts.DryRun)
+	return nil
+}
+
+func printImportResult(w io.Writer, res *logic.ImportResult, dryRun bool) {
+	for _, e := range res.Invalid {
+		fmt.Fprintln(w, "skipped", e.Error())
+	}
+	if dryRun {
+		for _, e := range res.Entries {
+			fmt.Fprintf(w, "%s  %-40s %7.2f kg × %d × %d\n", e.Date, e.Exercise, e.Weight, e.Reps, e.Sets)
+		}
+		fmt.Fprintf(w, "dry run: %d rows, %d would be imported, %d duplicates, %d ignored, %d invalid\n",
+			res.Rows, len(res.Entries), res.Duplicates, res.Ignored, len(res.Invalid))
+		return
+	}
+	fmt.Fprintf(w, "%d rows, %d imported, %d duplicates, %d ignored, %d invalid\n",
+		res.Rows, res.Imported, res.Duplicates, res.Ignored, len(res.Invalid))
+}
+
+func runBackup(env *env, args []string) error {
+	fs := newFlagSet(env, "backup")
+	out := fs.String("o", "", "output file (default stdout)")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return env.repo.Backup(env.ctx, env.stdout)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := env.repo.Backup(env.ctx, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintln(env.stderr, "backup written to", *out)
+	return nil
+}
+
+func runRestore(env *env, args []string) error {
+	fs := newFlagSet(env, "restore")
+	modeName := fs.String("mode", "merge", "merge keeps existing data, replace wipes it first")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one archive file")
+	}
+	var mode data.RestoreMode
+	switch *modeName {
+	case "merge":
+		mode = data.RestoreMerge
+	case "replace":
+		mode = data.RestoreReplace
+	default:
+		return fmt.Errorf("unknown mode %q, want merge or replace", *modeName)
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	report, err := env.repo.Restore(env.ctx, file, mode)
+	if err != nil {
+		return err
+	}
+	printRestoreReport(env.stdout, report)
+	return nil
+}
+
+func printRestoreReport(w io.Writer, r *data.RestoreReport) {
+	for _, c := range r.Conflicts {
+		fmt.Fprintf(w, "conflict: %s %s: kept %q, archive has %q\n", c.Kind, c.Key, c.Local, c.Incoming)
+	}
+	fmt.Fprintf(w, "%d entries added, %d already present, %d settings applied, %d conflicts\n",
+		r.EntriesAdded, r.EntriesSkipped, r.SettingsApplied, len(r.Conflicts))
+}
@@ -0,0 +1,263 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	appName    = "progresstracker"
+	dbFileName = "progress.db"
+
+	EnvDB     = "PROGRESSTRACKER_DB"
+	EnvConfig = "PROGRESSTRACKER_CONFIG"
+
+	// LegacyDBPath is where releases before the config layer kept the
+	// database: the current working directory.
+	LegacyDBPath = dbFileName
+)
+
+type Config struct {
+	DBPath    string
+	BackupDir string
+	// DBSource says where DBPath came from: "flag", "env", "config file"
+	// or "default".
+	DBSource string
+	// ConfigFile is the config file that was read, if any.
+	ConfigFile string
+	// Migrate is set by -migrate to move a legacy ./progress.db without
+	// asking.
+	Migrate bool
+	// Serve is the address given to -serve, for running the HTTP API
+	// alongside the window.
+	Serve string
+}
+
+// file is the on-disk config format.
+type file struct {
+	DBPath    string `json:"db_path,omitempty"`
+	BackupDir string `json:"backup_dir,omitempty"`
+}
+
+// Load resolves the configuration from global flags in args, the
+// environment, the config file and platform defaults, in that order of
+// precedence. It returns the arguments left after the global flags.
+func Load(args []string, stderr io.Writer) (*Config, []string, error) {
+	fs := flag.NewFlagSet(appName, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dbFlag := fs.String("db", "", "database file (env "+EnvDB+")")
+	configFlag := fs.String("config", "", "config file (env "+EnvConfig+")")
+	migrate := fs.Bool("migrate", false, "move ./"+dbFileName+" to the data directory without asking")
+	serve := fs.String("serve", "", "also serve the HTTP API on this address while the window is open")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &Config{Migrate: *migrate, Serve: *serve}
+	f, path, err := readFile(firstNonEmpty(*configFlag, os.Getenv(EnvConfig)))
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.ConfigFile = path
+
+	switch {
+	case *dbFlag != "":
+		cfg.DBPath, cfg.DBSource = *dbFlag, "flag"
+	case os.Getenv(EnvDB) != "":
+		cfg.DBPath, cfg.DBSource = os.Getenv(EnvDB), "env"
+	case f.DBPath != "":
+		cfg.DBPath, cfg.DBSource = f.DBPath, "config file"
+	default:
+		dir, err := DataDir()
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.DBPath, cfg.DBSource = filepath.Join(dir, dbFileName), "default"
+	}
+	if cfg.DBPath, err = expandHome(cfg.DBPath); err != nil {
+		return nil, nil, err
+	}
+
+	cfg.BackupDir = filepath.Join(filepath.Dir(cfg.DBPath), "backups")
+	if f.BackupDir != "" {
+		if cfg.BackupDir, err = expandHome(f.BackupDir); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cfg, fs.Args(), nil
+}
+
+// readFile loads the config file at path, or at the default location when
+// path is empty. A missing default file is not an error.
+func readFile(path string) (file, string, error) {
+	var f file
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return f, "", nil
+		}
+		path = filepath.Join(dir, appName, "config.json")
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return f, "", nil
+	}
+	if err != nil {
+		return f, "", err
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return f, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return f, path, nil
+}
+
+// DataDir is the per-user directory for app data: $XDG_DATA_HOME (or
+// ~/.local/share) on Linux and the BSDs, Application Support on macOS and
+// %LocalAppData% on Windows.
+func DataDir() (string, error) {
+	if d := os.Getenv("XDG_DATA_HOME"); d != "" {
+		return filepath.Join(d, appName), nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		if d := os.Getenv("LocalAppData"); d != "" {
+			return filepath.Join(d, appName), nil
+		}
+		fallthrough
+	case "darwin", "ios":
+		d, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(d, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", appName), nil
+}
+
+// LegacyDB returns the path of a database left in the working directory by
+// an older release, when it should be offered for migration: it exists, the
+// configured path does not, and the location was not chosen explicitly.
+func (c *Config) LegacyDB() (string, bool) {
+	if c.DBSource != "default" {
+		return "", false
+	}
+	if _, err := os.Stat(c.DBPath); err == nil {
+		return "", false
+	}
+	abs, err := filepath.Abs(LegacyDBPath)
+	if err != nil {
+		return "", false
+	}
+	if st, err := os.Stat(abs); err != nil || st.IsDir() {
+		return "", false
+	}
+	return abs, true
+}
+
+// sidecars are the files SQLite keeps next to a database. The WAL can hold
+// committed pages that are not in the database file yet.
+var sidecars = []string{"-wal", "-shm", "-journal"}
+
+// MigrateLegacy moves the legacy database with its sidecar files, and its
+// backups directory if there is one, into the configured locations.
+func (c *Config) MigrateLegacy(legacy string) error {
+	if err := os.MkdirAll(filepath.Dir(c.DBPath), 0o755); err != nil {
+		return err
+	}
+	if err := move(legacy, c.DBPath); err != nil {
+		return err
+	}
+	for _, suffix := range sidecars {
+		// a stale one left at the destination would be applied to the
+		// moved database
+		if err := os.Remove(c.DBPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if _, err := os.Stat(legacy + suffix); err != nil {
+			continue
+		}
+		if err := move(legacy+suffix, c.DBPath+suffix); err != nil {
+			return err
+		}
+	}
+	oldBackups := filepath.Join(filepath.Dir(legacy), "backups")
+	if st, err := os.Stat(oldBackups); err == nil && st.IsDir() {
+		if _, err := os.Stat(c.BackupDir); errors.Is(err, os.ErrNotExist) {
+			return move(oldBackups, c.BackupDir)
+		}
+	}
+	return nil
+}
+
+// EnsureDirs creates the directories the database and backups live in.
+func (c *Config) EnsureDirs() error {
+	if err := os.MkdirAll(filepath.Dir(c.DBPath), 0o755); err != nil {
+		return err
+	}
+	return os.MkdirAll(c.BackupDir, 0o755)
+}
+
+// move renames src to dst, falling back to copy and delete across
+// filesystems. Directories are only renamed.
+func move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	st, statErr := os.Stat(src)
+	if statErr != nil || st.IsDir() {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
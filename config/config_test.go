@@ -0,0 +1,182 @@
+package config_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"progresstracker/config"
+)
+
+// isolate points every location Load looks at into a temp dir and returns
+// it.
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv(config.EnvDB, "")
+	t.Setenv(config.EnvConfig, "")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := isolate(t)
+	defaultDB := filepath.Join(dir, "data", "progresstracker", "progress.db")
+
+	cfg, args, err := config.Load([]string{"log", "squat"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPath != defaultDB || cfg.DBSource != "default" || cfg.ConfigFile != "" {
+		t.Errorf("no config: %s from %s (file %q)", cfg.DBPath, cfg.DBSource, cfg.ConfigFile)
+	}
+	if want := filepath.Join(dir, "data", "progresstracker", "backups"); cfg.BackupDir != want {
+		t.Errorf("backups in %s, want %s", cfg.BackupDir, want)
+	}
+	if !slices.Equal(args, []string{"log", "squat"}) {
+		t.Errorf("args = %v", args)
+	}
+
+	// the default config file, read without being asked for
+	file := filepath.Join(dir, "config", "progresstracker", "config.json")
+	writeFile(t, file, `{"db_path": "~/file.db", "backup_dir": "~/bk"}`)
+	cfg, _, err = config.Load(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPath != filepath.Join(dir, "file.db") || cfg.DBSource != "config file" || cfg.ConfigFile != file {
+		t.Errorf("config file: %s from %s (file %q)", cfg.DBPath, cfg.DBSource, cfg.ConfigFile)
+	}
+	if cfg.BackupDir != filepath.Join(dir, "bk") {
+		t.Errorf("backup_dir from the config file = %s", cfg.BackupDir)
+	}
+
+	t.Setenv(config.EnvDB, "/env/progress.db")
+	cfg, _, err = config.Load(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPath != "/env/progress.db" || cfg.DBSource != "env" {
+		t.Errorf("env: %s from %s", cfg.DBPath, cfg.DBSource)
+	}
+
+	cfg, args, err = config.Load([]string{"-db", "/flag/progress.db", "-migrate", "export"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBPath != "/flag/progress.db" || cfg.DBSource != "flag" || !cfg.Migrate {
+		t.Errorf("flag: %s from %s, migrate %t", cfg.DBPath, cfg.DBSource, cfg.Migrate)
+	}
+	if !slices.Equal(args, []string{"export"}) {
+		t.Errorf("args after global flags = %v", args)
+	}
+	// backups follow the database unless the config file says otherwise
+	if cfg.BackupDir != filepath.Join(dir, "bk") {
+		t.Errorf("backup dir with -db = %s", cfg.BackupDir)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := isolate(t)
+	other := filepath.Join(dir, "other.json")
+	writeFile(t, other, `{"db_path": "/other/progress.db"}`)
+
+	t.Setenv(config.EnvConfig, other)
+	cfg, _, err := config.Load(nil, io.Discard)
+	if err != nil || cfg.DBPath != "/other/progress.db" || cfg.ConfigFile != other {
+		t.Fatalf("config from the environment = %+v, %v", cfg, err)
+	}
+	if cfg.BackupDir != "/other/backups" {
+		t.Errorf("backups in %s, want next to the database", cfg.BackupDir)
+	}
+
+	// an explicit file has to exist and parse
+	if _, _, err := config.Load([]string{"-config", filepath.Join(dir, "missing.json")}, io.Discard); err == nil {
+		t.Error("Load with a missing -config file succeeded")
+	}
+	bad := filepath.Join(dir, "bad.json")
+	writeFile(t, bad, `{"db_path": `)
+	if _, _, err := config.Load([]string{"-config", bad}, io.Discard); err == nil {
+		t.Error("Load with a malformed config file succeeded")
+	}
+	if _, _, err := config.Load([]string{"-nope"}, io.Discard); err == nil {
+		t.Error("Load with an unknown flag succeeded")
+	}
+}
+
+func TestDataDir(t *testing.T) {
+	dir := isolate(t)
+	if got, err := config.DataDir(); err != nil || got != filepath.Join(dir, "data", "progresstracker") {
+		t.Errorf("DataDir with XDG_DATA_HOME = %s, %v", got, err)
+	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	t.Setenv("XDG_DATA_HOME", "")
+	if got, err := config.DataDir(); err != nil || got != filepath.Join(dir, ".local", "share", "progresstracker") {
+		t.Errorf("DataDir without XDG_DATA_HOME = %s, %v", got, err)
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	dir := isolate(t)
+	work := filepath.Join(dir, "work")
+	writeFile(t, filepath.Join(work, "progress.db"), "db")
+	writeFile(t, filepath.Join(work, "progress.db-wal"), "wal")
+	writeFile(t, filepath.Join(work, "backups", "progress-20240101-120000.db"), "snapshot")
+	t.Chdir(work)
+
+	cfg, _, err := config.Load(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a stale sidecar at the destination must not outlive the move
+	writeFile(t, cfg.DBPath+"-shm", "stale")
+
+	legacy, ok := cfg.LegacyDB()
+	if !ok {
+		t.Fatal("LegacyDB found nothing")
+	}
+	if err := cfg.MigrateLegacy(legacy); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		cfg.DBPath:          "db",
+		cfg.DBPath + "-wal": "wal",
+		filepath.Join(cfg.BackupDir, "progress-20240101-120000.db"): "snapshot",
+	} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Errorf("%s after migrating = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	for _, gone := range []string{legacy, legacy + "-wal", filepath.Join(work, "backups"), cfg.DBPath + "-shm"} {
+		if _, err := os.Stat(gone); err == nil {
+			t.Errorf("%s still exists after migrating", gone)
+		}
+	}
+	if _, ok := cfg.LegacyDB(); ok {
+		t.Error("LegacyDB still offers a migration afterwards")
+	}
+
+	// an explicitly chosen database is never swapped for the legacy one
+	writeFile(t, filepath.Join(work, "progress.db"), "db")
+	cfg, _, _ = config.Load([]string{"-db", filepath.Join(dir, "chosen.db")}, io.Discard)
+	if _, ok := cfg.LegacyDB(); ok {
+		t.Error("LegacyDB offered a migration with -db given")
+	}
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExerciseAlias remembers which of our exercises a name from another app's
+// export maps to. An empty Exercise means the name is deliberately ignored.
+type ExerciseAlias struct {
+	Source   string
+	Name     string
+	Exercise string
+}
+
+func (db *DB) GetAliases(ctx context.Context, source string) (map[string]string, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT name, exercise FROM exercise_aliases WHERE source=?`, source)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s aliases: %w", source, err)
+	}
+	defer rows.Close()
+	aliases := map[string]string{}
+	for rows.Next() {
+		var name, exercise string
+		if err := rows.Scan(&name, &exercise); err != nil {
+			return nil, err
+		}
+		aliases[name] = exercise
+	}
+	return aliases, rows.Err()
+}
+
+func (db *DB) GetAllAliases(ctx context.Context) ([]ExerciseAlias, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT source, name, exercise FROM exercise_aliases ORDER BY source, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var aliases []ExerciseAlias
+	for rows.Next() {
+		var a ExerciseAlias
+		if err := rows.Scan(&a.Source, &a.Name, &a.Exercise); err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+func (db *DB) SetAlias(ctx context.Context, source, name, exercise string) error {
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT OR REPLACE INTO exercise_aliases (source, name, exercise) VALUES (?, ?, ?)`,
+		source, name, exercise,
+	)
+	if err != nil {
+		return fmt.Errorf("saving alias %s: %w", name, err)
+	}
+	return nil
+}
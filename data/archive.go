@@ -0,0 +1,456 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	ArchiveFormat  = "progresstracker-archive"
+	ArchiveVersion = 6
+)
+
+// Archive is the versioned JSON backup format. It deliberately has its own
+// field names rather than reusing the table structs, so the file format only
+// changes together with ArchiveVersion.
+type Archive struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Settings   map[string]string `json:"settings"`
+	Entries    []ArchiveEntry    `json:"entries"`
+	Aliases    []ArchiveAlias    `json:"exercise_aliases"`
+	Goals      []ArchiveGoal     `json:"goals"`
+	Bodyweight []Bodyweight      `json:"bodyweight"`
+}
+
+type ArchiveEntry struct {
+	ID        int64     `json:"id"`
+	Exercise  string    `json:"exercise"`
+	Weight    float64   `json:"weight"`
+	Reps      int       `json:"reps"`
+	Sets      int       `json:"sets"`
+	Volume    float64   `json:"volume"`
+	Notes     string    `json:"notes,omitempty"`
+	Date      Date      `json:"date"`
+	Warmup    bool      `json:"warmup,omitempty"`
+	RPE       float64   `json:"rpe,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ArchiveGoal struct {
+	ID        int64     `json:"id"`
+	Metric    string    `json:"metric"`
+	Exercise  string    `json:"exercise,omitempty"`
+	Target    float64   `json:"target"`
+	Start     float64   `json:"start"`
+	By        Date      `json:"by,omitempty"`
+	Achieved  Date      `json:"achieved,omitempty"`
+	SetOn     Date      `json:"set_on"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ArchiveAlias struct {
+	Source   string `json:"source"`
+	Name     string `json:"name"`
+	Exercise string `json:"exercise"`
+}
+
+// archiveMigrations upgrade a decoded archive one version at a time:
+// archiveMigrations[v] turns a version v archive into version v+1.
+var archiveMigrations = map[int]func(raw map[string]json.RawMessage) error{
+	// v2 added exercise aliases for app imports
+	1: func(raw map[string]json.RawMessage) error {
+		raw["exercise_aliases"] = json.RawMessage(`[]`)
+		return nil
+	},
+	// v3 added warm-up sets; older entries are all working sets, which is
+	// what a missing flag means
+	2: func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// v4 added RPE, which older entries never recorded
+	3: func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// v5 added goals and bodyweight readings
+	4: func(raw map[string]json.RawMessage) error {
+		raw["goals"] = json.RawMessage(`[]`)
+		raw["bodyweight"] = json.RawMessage(`[]`)
+		return nil
+	},
+	// v6 only holds YYYY-MM-DD dates; older archives can have the free-text
+	// dates repairDates fixes in the database
+	5: repairArchiveDates,
+}
+
+// repairArchiveDates normalizes entry dates like repairDates, moving an
+// entry whose date cannot be read to the day it was created.
+func repairArchiveDates(raw map[string]json.RawMessage) error {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(raw["entries"], &entries); err != nil || entries == nil {
+		return err
+	}
+	for _, e := range entries {
+		var date string
+		var created time.Time
+		json.Unmarshal(e["date"], &date)
+		d, err := ParseDate(date)
+		switch {
+		case err == nil:
+		case json.Unmarshal(e["created_at"], &created) == nil && !created.IsZero():
+			d = DateOf(created.Local())
+		default:
+			d = Today()
+		}
+		e["date"], _ = json.Marshal(d)
+	}
+	var err error
+	raw["entries"], err = json.Marshal(entries)
+	return err
+}
+
+type RestoreMode int
+
+const (
+	// RestoreMerge adds archived entries that are not already present and
+	// keeps local values wherever the two disagree.
+	RestoreMerge RestoreMode = iota
+	// RestoreReplace wipes entries and settings and loads the archive as is.
+	RestoreReplace
+)
+
+type Conflict struct {
+	Kind     string // "setting", "entry", "alias" or "bodyweight"
+	Key      string
+	Local    string
+	Incoming string
+}
+
+type RestoreReport struct {
+	EntriesAdded    int
+	EntriesSkipped  int
+	SettingsApplied int
+	// Conflicts lists records present on both sides with different values.
+	// The local value is kept in every case.
+	Conflicts []Conflict
+}
+
+func (db *DB) ExportArchive(ctx context.Context) (*Archive, error) {
+	a := &Archive{
+		Format:     ArchiveFormat,
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Settings:   map[string]string{},
+	}
+	rows, err := db.conn.QueryContext(ctx, `SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		a.Settings[k] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := db.GetAllEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// oldest first reads more naturally in the file
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		a.Entries = append(a.Entries, ArchiveEntry{
+			ID: e.ID, Exercise: e.Exercise, Weight: e.Weight, Reps: e.Reps, Sets: e.Sets,
+			Volume: e.Volume, Notes: e.Notes, Date: e.Date, Warmup: e.Warmup, RPE: e.RPE, CreatedAt: e.CreatedAt,
+		})
+	}
+
+	aliases, err := db.GetAllAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, al := range aliases {
+		a.Aliases = append(a.Aliases, ArchiveAlias(al))
+	}
+
+	goals, err := db.GetGoals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range goals {
+		a.Goals = append(a.Goals, ArchiveGoal(*g))
+	}
+	if a.Bodyweight, err = db.GetBodyweights(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func WriteArchive(w io.Writer, a *Archive) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// ReadArchive decodes an archive, checks that it is one of ours and upgrades
+// it to ArchiveVersion.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("not a valid archive: %w", err)
+	}
+	var format string
+	var version int
+	if err := json.Unmarshal(raw["format"], &format); err != nil || format != ArchiveFormat {
+		return nil, errors.New("not a progresstracker archive")
+	}
+	if err := json.Unmarshal(raw["version"], &version); err != nil || version < 1 {
+		return nil, errors.New("archive has no valid version")
+	}
+	if version > ArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than supported version %d", version, ArchiveVersion)
+	}
+	for ; version < ArchiveVersion; version++ {
+		if err := archiveMigrations[version](raw); err != nil {
+			return nil, fmt.Errorf("migrating archive from version %d: %w", version, err)
+		}
+	}
+	raw["version"], _ = json.Marshal(version)
+
+	buf, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{}
+	if err := json.Unmarshal(buf, a); err != nil {
+		return nil, fmt.Errorf("not a valid archive: %w", err)
+	}
+	for i, e := range a.Entries {
+		if e.Exercise == "" || e.Date.IsZero() || e.Reps <= 0 || e.Sets <= 0 || e.Weight < 0 {
+			return nil, fmt.Errorf("archive entry %d (id %d) is invalid", i+1, e.ID)
+		}
+	}
+	for i, g := range a.Goals {
+		if g.Metric == "" || g.Target <= 0 || g.SetOn.IsZero() {
+			return nil, fmt.Errorf("archive goal %d (id %d) is invalid", i+1, g.ID)
+		}
+	}
+	for _, b := range a.Bodyweight {
+		if b.Date.IsZero() || b.Weight <= 0 {
+			return nil, fmt.Errorf("archive bodyweight of %q is invalid", b.Date)
+		}
+	}
+	return a, nil
+}
+
+// RestoreArchive loads a into the database in a single transaction.
+func (db *DB) RestoreArchive(ctx context.Context, a *Archive, mode RestoreMode) (*RestoreReport, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &RestoreReport{}
+	if mode == RestoreReplace {
+		if _, err := tx.Exec(`DELETE FROM entries`); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM settings`); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM exercise_aliases`); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM goals`); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM bodyweight`); err != nil {
+			return nil, err
+		}
+	}
+	if err := restoreSettings(tx, a.Settings, report); err != nil {
+		return nil, err
+	}
+	if err := restoreEntries(tx, a.Entries, mode, report); err != nil {
+		return nil, err
+	}
+	if err := restoreAliases(tx, a.Aliases, report); err != nil {
+		return nil, err
+	}
+	if err := restoreGoals(tx, a.Goals, mode); err != nil {
+		return nil, err
+	}
+	if err := restoreBodyweight(tx, a.Bodyweight, report); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	// a replace may have dropped defaults the archive did not carry
+	return report, db.migrate()
+}
+
+func restoreSettings(tx *sql.Tx, settings map[string]string, report *RestoreReport) error {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := settings[k]
+		var local string
+		err := tx.QueryRow(`SELECT value FROM settings WHERE key=?`, k).Scan(&local)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES (?, ?)`, k, v); err != nil {
+				return err
+			}
+			report.SettingsApplied++
+		case err != nil:
+			return err
+		case local != v:
+			report.Conflicts = append(report.Conflicts, Conflict{Kind: "setting", Key: k, Local: local, Incoming: v})
+		}
+	}
+	return nil
+}
+
+func restoreEntries(tx *sql.Tx, entries []ArchiveEntry, mode RestoreMode, report *RestoreReport) error {
+	var local localMatches
+	if mode == RestoreMerge {
+		var err error
+		if local, err = readLocalMatches(tx); err != nil {
+			return err
+		}
+	}
+	for _, e := range entries {
+		if mode == RestoreMerge {
+			if notes, ok := local.take(e); ok {
+				report.EntriesSkipped++
+				if notes != e.Notes {
+					report.Conflicts = append(report.Conflicts, entryConflict(e, notes))
+				}
+				continue
+			}
+		}
+		// stored in UTC: the driver cannot read back times in an unnamed
+		// fixed zone, which is what JSON offsets decode to
+		created := e.CreatedAt.UTC()
+		if e.CreatedAt.IsZero() {
+			created = time.Now().UTC()
+		}
+		volume := e.Weight * float64(e.Reps) * float64(e.Sets)
+		// ids are kept on replace so the restored database matches the
+		// original; merged entries get fresh ids to avoid collisions
+		var id any
+		if mode == RestoreReplace && e.ID > 0 {
+			id = e.ID
+		}
+		_, err := tx.Exec(
+			`INSERT INTO entries (id, exercise, weight, reps, sets, volume, notes, date, warmup, rpe, created_at) VALUES (?,?,?,?,?,?,?,?,?,?,?)`,
+			id, e.Exercise, e.Weight, e.Reps, e.Sets, volume, e.Notes, e.Date, e.Warmup, e.RPE, created,
+		)
+		if err != nil {
+			return err
+		}
+		report.EntriesAdded++
+	}
+	return nil
+}
+
+// mergeKey holds the fields a merge compares to find an archived entry that
+// is already present.
+type mergeKey struct {
+	date       Date
+	exercise   string
+	weight     float64
+	reps, sets int
+	warmup     bool
+}
+
+func (e ArchiveEntry) mergeKey() mergeKey {
+	return mergeKey{e.Date, e.Exercise, e.Weight, e.Reps, e.Sets, e.Warmup}
+}
+
+// localMatches holds the notes of the entries stored before a merge, by
+// mergeKey in id order. Each stored entry matches one archived entry, so
+// identical sets in the archive are only skipped as often as they are
+// already stored.
+type localMatches map[mergeKey][]string
+
+func (m localMatches) add(e *Entry) {
+	k := mergeKey{e.Date, e.Exercise, e.Weight, e.Reps, e.Sets, e.Warmup}
+	m[k] = append(m[k], e.Notes)
+}
+
+// take returns the notes of the first stored entry matching e that no
+// earlier archived entry has taken.
+func (m localMatches) take(e ArchiveEntry) (string, bool) {
+	k := e.mergeKey()
+	notes := m[k]
+	if len(notes) == 0 {
+		return "", false
+	}
+	m[k] = notes[1:]
+	return notes[0], true
+}
+
+func readLocalMatches(tx *sql.Tx) (localMatches, error) {
+	rows, err := tx.Query(`SELECT date, exercise, weight, reps, sets, warmup, notes FROM entries ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	m := localMatches{}
+	for rows.Next() {
+		var e Entry
+		var notes sql.NullString
+		if err := rows.Scan(&e.Date, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Warmup, &notes); err != nil {
+			return nil, err
+		}
+		e.Notes = notes.String
+		m.add(&e)
+	}
+	return m, rows.Err()
+}
+
+func entryConflict(e ArchiveEntry, localNotes string) Conflict {
+	return Conflict{
+		Kind:     "entry",
+		Key:      fmt.Sprintf("%s %s %gkg×%d×%d", e.Date, e.Exercise, e.Weight, e.Reps, e.Sets),
+		Local:    localNotes,
+		Incoming: e.Notes,
+	}
+}
+
+func restoreAliases(tx *sql.Tx, aliases []ArchiveAlias, report *RestoreReport) error {
+	for _, al := range aliases {
+		var local string
+		err := tx.QueryRow(`SELECT exercise FROM exercise_aliases WHERE source=? AND name=?`, al.Source, al.Name).Scan(&local)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(`INSERT INTO exercise_aliases (source, name, exercise) VALUES (?, ?, ?)`, al.Source, al.Name, al.Exercise); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case local != al.Exercise:
+			report.Conflicts = append(report.Conflicts, Conflict{Kind: "alias", Key: al.Source + ": " + al.Name, Local: local, Incoming: al.Exercise})
+		}
+	}
+	return nil
+}
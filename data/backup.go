@@ -0,0 +1,353 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBackupKeep = 7
+	backupInterval    = 24 * time.Hour
+	backupPrefix      = "progress-"
+	backupSuffix      = ".db"
+	backupTimeLayout  = "20060102-150405"
+)
+
+// Backups keeps rotating snapshots of the database in a directory. Snapshots
+// are taken with VACUUM INTO, which produces a consistent copy even while the
+// app is using the database.
+type Backups struct {
+	db  *DB
+	dir string
+}
+
+type BackupInfo struct {
+	Path string
+	Name string
+	Time time.Time
+	Size int64
+}
+
+type BackupPreview struct {
+	Entries   int
+	Exercises int
+	FirstDate Date
+	LastDate  Date
+}
+
+func NewBackups(db *DB, dir string) *Backups {
+	return &Backups{db: db, dir: dir}
+}
+
+func (b *Backups) Dir() string {
+	return b.dir
+}
+
+// Keep returns how many snapshots are kept, from the backup_keep setting.
+func (b *Backups) Keep(ctx context.Context) (int, error) {
+	v, ok, err := b.db.GetSetting(ctx, "backup_keep")
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(v)
+	if !ok || err != nil || n < 1 {
+		return DefaultBackupKeep, nil
+	}
+	return n, nil
+}
+
+func (b *Backups) SetKeep(ctx context.Context, n int) error {
+	if n < 1 {
+		return Invalid("backup_keep", "must keep at least one backup")
+	}
+	if err := b.db.SetSetting(ctx, "backup_keep", strconv.Itoa(n)); err != nil {
+		return err
+	}
+	return b.prune(ctx)
+}
+
+// Take writes a new snapshot and prunes the oldest ones beyond Keep.
+func (b *Backups) Take(ctx context.Context) (*BackupInfo, error) {
+	info, err := b.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return info, b.prune(ctx)
+}
+
+func (b *Backups) snapshot(ctx context.Context) (*BackupInfo, error) {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stamp := now.Format(backupTimeLayout)
+	// same-second snapshots are numbered after the newest one, even when
+	// earlier ones have been pruned, so List keeps them in order
+	next := -1
+	files, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if s, seq, ok := parseBackupName(f.Name()); ok && s == stamp {
+			next = max(next, seq)
+		}
+	}
+	name := backupPrefix + stamp + backupSuffix
+	if next++; next > 0 {
+		name = fmt.Sprintf("%s%s-%d%s", backupPrefix, stamp, next, backupSuffix)
+	}
+	path := filepath.Join(b.dir, name)
+	if _, err := b.db.conn.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return nil, fmt.Errorf("writing backup %s: %w", filepath.Base(path), err)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupInfo{Path: path, Name: filepath.Base(path), Time: now, Size: st.Size()}, nil
+}
+
+// List returns the snapshots in the backup directory, newest first.
+func (b *Backups) List() ([]BackupInfo, error) {
+	files, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []BackupInfo
+	seq := map[string]int{} // same-second snapshots get a -N suffix
+	for _, f := range files {
+		name := f.Name()
+		stamp, n, ok := parseBackupName(name)
+		if f.IsDir() || !ok {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		seq[name] = n
+		info, err := f.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, BackupInfo{Path: filepath.Join(b.dir, name), Name: name, Time: t, Size: info.Size()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Time.Equal(out[j].Time) {
+			return out[i].Time.After(out[j].Time)
+		}
+		return seq[out[i].Name] > seq[out[j].Name]
+	})
+	return out, nil
+}
+
+// parseBackupName splits a snapshot name into its time stamp and its number
+// among snapshots taken in the same second, 0 for the first.
+func parseBackupName(name string) (stamp string, seq int, ok bool) {
+	if !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+		return "", 0, false
+	}
+	stamp = strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+	if len(stamp) > len(backupTimeLayout) {
+		seq, _ = strconv.Atoi(strings.TrimPrefix(stamp[len(backupTimeLayout):], "-"))
+		stamp = stamp[:len(backupTimeLayout)]
+	}
+	return stamp, seq, true
+}
+
+func (b *Backups) prune(ctx context.Context) error {
+	list, err := b.List()
+	if err != nil {
+		return err
+	}
+	keep, err := b.Keep(ctx)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(list); i++ {
+		if err := os.Remove(list[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunDaily takes a snapshot straight away and then whenever the newest one is
+// a day old, until ctx is done. Errors are reported to onErr.
+func (b *Backups) RunDaily(ctx context.Context, onErr func(error)) {
+	if _, err := b.Take(ctx); err != nil {
+		onErr(err)
+	}
+	tick := time.NewTicker(time.Hour)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			list, err := b.List()
+			if err != nil {
+				onErr(err)
+				continue
+			}
+			if len(list) > 0 && time.Since(list[0].Time) < backupInterval {
+				continue
+			}
+			if _, err := b.Take(ctx); err != nil {
+				onErr(err)
+			}
+		}
+	}
+}
+
+// Preview summarises what a snapshot contains without touching the live
+// database.
+func (b *Backups) Preview(ctx context.Context, path string) (*BackupPreview, error) {
+	if !fileExists(path) {
+		return nil, fmt.Errorf("backup %s: %w", path, ErrNotFound)
+	}
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	p := &BackupPreview{}
+	var first, last sql.NullString
+	err = conn.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(DISTINCT exercise), MIN(date), MAX(date) FROM entries`).
+		Scan(&p.Entries, &p.Exercises, &first, &last)
+	if err != nil {
+		return nil, err
+	}
+	p.FirstDate, p.LastDate = Date(first.String), Date(last.String)
+	return p, nil
+}
+
+// Restore replaces the live data with the contents of a snapshot. A snapshot
+// of the current state is taken first so a restore can itself be undone;
+// pruning waits until afterwards so it cannot remove the snapshot being
+// restored.
+// Tables are copied column by column, so snapshots from older schema
+// versions restore cleanly.
+func (b *Backups) Restore(ctx context.Context, path string) error {
+	if _, err := b.Preview(ctx, path); err != nil {
+		return err
+	}
+	if _, err := b.snapshot(ctx); err != nil {
+		return fmt.Errorf("safety backup before restore: %w", err)
+	}
+
+	// ATTACH is per connection, so pin one for the whole restore
+	conn, err := b.db.conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS bk`, path); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `DETACH DATABASE bk`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	tables, err := tableNames(ctx, tx, "main")
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM main.`+table); err != nil {
+			return err
+		}
+		cols, err := sharedColumns(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		if len(cols) == 0 {
+			continue
+		}
+		list := strings.Join(cols, ", ")
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO main.%s (%s) SELECT %s FROM bk.%s`, table, list, list, table)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	// restore defaults an older snapshot may not have had
+	if err := b.db.migrate(); err != nil {
+		return err
+	}
+	return b.prune(ctx)
+}
+
+func tableNames(ctx context.Context, tx *sql.Tx, schema string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM `+schema+`.sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// sharedColumns lists the columns of table present in both main and bk.
+func sharedColumns(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	columns := func(schema string) (map[string]bool, []string, error) {
+		rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT name FROM pragma_table_info('%s', '%s')`, table, schema))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rows.Close()
+		set := map[string]bool{}
+		var order []string
+		for rows.Next() {
+			var n string
+			if err := rows.Scan(&n); err != nil {
+				return nil, nil, err
+			}
+			set[n] = true
+			order = append(order, n)
+		}
+		return set, order, rows.Err()
+	}
+	_, mainCols, err := columns("main")
+	if err != nil {
+		return nil, err
+	}
+	bkCols, _, err := columns("bk")
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	for _, c := range mainCols {
+		if bkCols[c] {
+			shared = append(shared, c)
+		}
+	}
+	return shared, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,192 @@
+package data_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func openBackups(t *testing.T) (*data.Backups, *data.Repository) {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := data.NewDB(filepath.Join(dir, "progress.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return data.NewBackups(db, filepath.Join(dir, "backups")), data.NewRepository(db)
+}
+
+func TestBackupRotation(t *testing.T) {
+	ctx := t.Context()
+	b, _ := openBackups(t)
+	if list, err := b.List(); err != nil || len(list) != 0 {
+		t.Fatalf("List before any backup = %v, %v", list, err)
+	}
+	if keep, _ := b.Keep(ctx); keep != data.DefaultBackupKeep {
+		t.Errorf("Keep = %d, want the default %d", keep, data.DefaultBackupKeep)
+	}
+	if err := b.SetKeep(ctx, 0); err == nil {
+		t.Error("SetKeep(0) succeeded")
+	}
+	if err := b.SetKeep(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// several in the same second get numbered names, in order
+	var taken []string
+	for range 5 {
+		info, err := b.Take(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		taken = append(taken, info.Name)
+	}
+	if err := os.WriteFile(filepath.Join(b.Dir(), "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := b.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("%d backups kept, want 3", len(list))
+	}
+	for i, info := range list {
+		if want := taken[len(taken)-1-i]; info.Name != want {
+			t.Errorf("backup %d is %s, want %s (newest first)", i, info.Name, want)
+		}
+	}
+
+	// lowering the limit prunes straight away
+	if err := b.SetKeep(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := b.List(); len(list) != 1 || list[0].Name != taken[4] {
+		t.Errorf("after SetKeep(1): %v", list)
+	}
+}
+
+func TestBackupRunDaily(t *testing.T) {
+	b, _ := openBackups(t)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		b.RunDaily(ctx, func(err error) { t.Error(err) })
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if list, _ := b.List(); len(list) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("RunDaily took no backup at startup")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := t.Context()
+	b, repo := openBackups(t)
+	save := func(weight float64) {
+		t.Helper()
+		if err := repo.Save(ctx, &data.Entry{Exercise: "Squat", Weight: weight, Reps: 5, Sets: 1, Date: "2024-03-01"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(100)
+	snap, err := b.Take(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(110)
+	if err := repo.SetCurrentWeek(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := b.Preview(ctx, snap.Path)
+	if err != nil || p.Entries != 1 || p.FirstDate != "2024-03-01" {
+		t.Errorf("Preview = %+v, %v", p, err)
+	}
+	if err := b.Restore(ctx, snap.Path); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := repo.All(ctx)
+	if len(all) != 1 || all[0].Weight != 100 {
+		t.Errorf("entries after restore: %s", describeAll(all))
+	}
+	if w, _ := repo.GetCurrentWeek(ctx); w != 1 {
+		t.Errorf("week %d after restore, want 1", w)
+	}
+	// the state before the restore is kept as its own backup
+	if list, _ := b.List(); len(list) != 2 {
+		t.Errorf("%d backups after restore, want the snapshot and a safety copy", len(list))
+	}
+	if err := b.Restore(ctx, filepath.Join(b.Dir(), "missing.db")); err == nil {
+		t.Error("Restore of a missing file succeeded")
+	}
+}
+
+func TestBackupRestoreOlderSchema(t *testing.T) {
+	ctx := t.Context()
+	b, repo := openBackups(t)
+	if err := os.MkdirAll(b.Dir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// a snapshot from before warm-up sets, settings and date validation
+	path := filepath.Join(b.Dir(), "progress-20240101-120000.db")
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			exercise TEXT NOT NULL,
+			weight REAL NOT NULL,
+			reps INTEGER NOT NULL,
+			sets INTEGER NOT NULL,
+			volume REAL NOT NULL,
+			notes TEXT,
+			date TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO entries (exercise, weight, reps, sets, volume, notes, date) VALUES ('Squat', 90, 5, 3, 1350, '', '2024-1-5');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetCurrentWeek(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Restore(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := repo.All(ctx)
+	if len(all) != 1 || all[0].Date != "2024-01-05" || all[0].Warmup {
+		t.Errorf("entries after restoring an old snapshot: %s", describeAll(all))
+	}
+	// migrate puts back the default week the snapshot had no table for
+	if w, err := repo.GetCurrentWeek(ctx); err != nil || w != 1 {
+		t.Errorf("week after restore = %d, %v; want 1", w, err)
+	}
+}
+
+func describeAll(entries []*data.Entry) string {
+	var s string
+	for _, e := range entries {
+		s += string(e.Date) + " " + e.Exercise + "; "
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the one form dates are stored and exchanged in.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day. It is always held in the YYYY-MM-DD form, so dates
+// sort and compare correctly as strings, both in Go and in SQL. Build one
+// with ParseDate, ResolveDate, DateOf or Today; the zero Date means unset.
+type Date string
+
+// DateOf returns the day t falls on, in t's location.
+func DateOf(t time.Time) Date {
+	return Date(t.Format(DateLayout))
+}
+
+// Today returns the current local day.
+func Today() Date {
+	return DateOf(time.Now())
+}
+
+var (
+	ymdDate   = regexp.MustCompile(`^(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})$`)
+	dmyDate   = regexp.MustCompile(`^(\d{1,2})[-/.](\d{1,2})[-/.](\d{4})$`)
+	timeOfDay = regexp.MustCompile(`(?i)^\d{1,2}:\d{2}(:\d{2}(\.\d+)?)? ?(z|[-+]\d{2}:?\d{2})?$`)
+)
+
+// ParseDate reads a date as year first (2024-01-05, 2024-1-5, 2024/01/05)
+// or day first (05/01/2024, 5.1.2024), optionally followed by a time of day
+// which is dropped. The result is normalized to YYYY-MM-DD.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " Tt"); i > 0 {
+		if !timeOfDay.MatchString(strings.TrimSpace(s[i+1:])) {
+			return "", fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+		}
+		s = s[:i]
+	}
+	var y, m, d string
+	if p := ymdDate.FindStringSubmatch(s); p != nil {
+		y, m, d = p[1], p[2], p[3]
+	} else if p := dmyDate.FindStringSubmatch(s); p != nil {
+		d, m, y = p[1], p[2], p[3]
+	} else {
+		return "", fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+	}
+	year, _ := strconv.Atoi(y)
+	month, _ := strconv.Atoi(m)
+	day, _ := strconv.Atoi(d)
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Month() != time.Month(month) || t.Day() != day {
+		return "", fmt.Errorf("invalid date %q: no such day", s)
+	}
+	return DateOf(t), nil
+}
+
+var relativeDate = regexp.MustCompile(`^([-+]\d+)([dw])$`)
+
+// ResolveDate is ParseDate plus shortcuts relative to today: "today",
+// "yesterday", and offsets such as "-2d" or "-1w".
+func ResolveDate(s string, today Date) (Date, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDays(-1), nil
+	}
+	if p := relativeDate.FindStringSubmatch(s); p != nil {
+		n, err := strconv.Atoi(p[1])
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q", s)
+		}
+		if p[2] == "w" {
+			n *= 7
+		}
+		return today.AddDays(n), nil
+	}
+	return ParseDate(s)
+}
+
+// Time returns midnight UTC at the start of d.
+func (d Date) Time() time.Time {
+	t, _ := time.Parse(DateLayout, string(d))
+	return t
+}
+
+// AddDays returns the date n days after d, or before it for negative n.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time().AddDate(0, 0, n))
+}
+
+// DaysSince returns how many days d is after other.
+func (d Date) DaysSince(other Date) int {
+	return int(d.Time().Sub(other.Time()).Hours() / 24)
+}
+
+func (d Date) IsZero() bool {
+	return d == ""
+}
+
+func (d Date) String() string {
+	return string(d)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+// UnmarshalText normalizes dates read from JSON or flags, rejecting ones
+// ParseDate cannot read.
+func (d *Date) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*d = ""
+		return nil
+	}
+	p, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = p
+	return nil
+}
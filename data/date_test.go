@@ -0,0 +1,135 @@
+package data_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestParseDate(t *testing.T) {
+	for in, want := range map[string]data.Date{
+		"2024-01-05":                  "2024-01-05",
+		"2024-1-5":                    "2024-01-05",
+		"2024/01/05":                  "2024-01-05",
+		"05/01/2024":                  "2024-01-05",
+		"5.1.2024":                    "2024-01-05",
+		" 2024-01-05 ":                "2024-01-05",
+		"2024-01-05 18:30:00":         "2024-01-05",
+		"2024-01-05T18:30:00Z":        "2024-01-05",
+		"2024-01-05 18:30":            "2024-01-05",
+		"2024-01-05t18:30:00":         "2024-01-05",
+		"2024-01-05T18:30:00.5+01:00": "2024-01-05",
+	} {
+		if got, err := data.ParseDate(in); err != nil || got != want {
+			t.Errorf("ParseDate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "2024-13-01", "2024-02-30", "31/02/2024", "24-01-05", "2024-01",
+		"2024-01-05 garbage", "2024-01-05T", "5th"} {
+		if got, err := data.ParseDate(in); err == nil {
+			t.Errorf("ParseDate(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestResolveDate(t *testing.T) {
+	const today data.Date = "2024-03-01"
+	for in, want := range map[string]data.Date{
+		"today":      "2024-03-01",
+		"Yesterday":  "2024-02-29",
+		"-2d":        "2024-02-28",
+		"-1w":        "2024-02-23",
+		"+1d":        "2024-03-02",
+		"2024-1-5":   "2024-01-05",
+		"01/02/2024": "2024-02-01",
+	} {
+		if got, err := data.ResolveDate(in, today); err != nil || got != want {
+			t.Errorf("ResolveDate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := data.ResolveDate("-2x", today); err == nil {
+		t.Error("ResolveDate(-2x) succeeded")
+	}
+}
+
+func TestOpenRepairsDates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := data.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// rows as older versions could store them
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2023, 6, 7, 12, 0, 0, 0, time.Local)
+	for _, date := range []string{"2024-1-5", "05/01/2024", "2023-12-31", "last week"} {
+		_, err := conn.Exec(`INSERT INTO entries (exercise, weight, reps, sets, volume, notes, date, created_at) VALUES ('Squat', 100, 5, 1, 500, '', ?, ?)`,
+			date, created.UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.Close()
+
+	db, err = data.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	entries, err := data.NewRepository(db).HistoryFor(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []data.Date
+	for _, e := range entries {
+		got = append(got, e.Date)
+	}
+	want := []data.Date{"2024-01-05", "2024-01-05", "2023-12-31", "2023-06-07"}
+	if len(got) != len(want) {
+		t.Fatalf("dates %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("dates %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReadArchiveRepairsDates(t *testing.T) {
+	// a version 4 archive, from before dates were validated
+	const old = `{"format":"progresstracker-archive","version":4,"settings":{},"entries":[
+		{"exercise":"Squat","weight":100,"reps":5,"sets":1,"date":"2024-1-5"},
+		{"exercise":"Squat","weight":100,"reps":5,"sets":1,"date":"05/01/2024 18:30"},
+		{"exercise":"Squat","weight":100,"reps":5,"sets":1,"date":"5th","created_at":"2023-06-07T12:00:00Z"},
+		{"exercise":"Squat","weight":100,"reps":5,"sets":1,"date":"2024-01-05 garbage"}
+	]}`
+	a, err := data.ReadArchive(strings.NewReader(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []data.Date
+	for _, e := range a.Entries {
+		got = append(got, e.Date)
+	}
+	created := data.DateOf(time.Date(2023, 6, 7, 12, 0, 0, 0, time.UTC).Local())
+	want := []data.Date{"2024-01-05", "2024-01-05", created, data.Today()}
+	if !slices.Equal(got, want) || a.Version != data.ArchiveVersion {
+		t.Errorf("dates %v (version %d), want %v", got, a.Version, want)
+	}
+
+	// current archives must already hold valid dates
+	bad := strings.Replace(old, `"version":4`, fmt.Sprintf(`"version":%d`, data.ArchiveVersion), 1)
+	if _, err := data.ReadArchive(strings.NewReader(bad)); err == nil {
+		t.Error("ReadArchive accepted free-text dates in a current archive")
+	}
+}
@@ -1,28 +1,83 @@
 package data
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
 type DB struct {
-	conn *sql.DB
+	conn  *sql.DB
+	stmts statements
+}
+
+const entryColumns = `id, exercise, weight, reps, sets, volume, notes, date, warmup, rpe, created_at`
+
+// statements are prepared once when the database is opened for the queries
+// the UI, CLI and API run over and over.
+type statements struct {
+	insert     *sql.Stmt
+	byExercise *sql.Stmt
+	all        *sql.Stmt
+	get        *sql.Stmt
+	last       *sql.Stmt
+	update     *sql.Stmt
+	delete     *sql.Stmt
+	pb         *sql.Stmt
+	daily      *sql.Stmt
+	getSetting *sql.Stmt
+	setSetting *sql.Stmt
 }
 
 func NewDB(path string) (*DB, error) {
-	conn, err := sql.Open("sqlite", path)
+	// the window, the API server and background backups share the file, so
+	// wait for locks rather than failing with SQLITE_BUSY
+	conn, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, err
 	}
 	db := &DB{conn: conn}
 	if err := db.migrate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := db.prepare(); err != nil {
+		conn.Close()
 		return nil, err
 	}
 	return db, nil
 }
 
+func (db *DB) prepare() error {
+	for _, q := range []struct {
+		stmt **sql.Stmt
+		sql  string
+	}{
+		{&db.stmts.insert, `INSERT INTO entries (exercise, weight, reps, sets, volume, notes, date, warmup, rpe, created_at) VALUES (?,?,?,?,?,?,?,?,?,?)`},
+		{&db.stmts.byExercise, `SELECT ` + entryColumns + ` FROM entries WHERE exercise=? ORDER BY date DESC, id DESC`},
+		{&db.stmts.all, `SELECT ` + entryColumns + ` FROM entries ORDER BY date DESC, id DESC`},
+		{&db.stmts.get, `SELECT ` + entryColumns + ` FROM entries WHERE id=?`},
+		{&db.stmts.last, `SELECT ` + entryColumns + ` FROM entries WHERE exercise=? AND warmup=0 ORDER BY date DESC, id DESC LIMIT 1`},
+		{&db.stmts.update, `UPDATE entries SET exercise=?, weight=?, reps=?, sets=?, volume=?, notes=?, date=?, warmup=?, rpe=? WHERE id=?`},
+		{&db.stmts.delete, `DELETE FROM entries WHERE id=?`},
+		{&db.stmts.pb, `SELECT MAX(weight), MAX(volume) FROM entries WHERE exercise=? AND warmup=0`},
+		{&db.stmts.daily, `SELECT date, MAX(weight), SUM(volume) FROM entries WHERE exercise=? AND warmup=0 GROUP BY date ORDER BY date`},
+		{&db.stmts.getSetting, `SELECT value FROM settings WHERE key=?`},
+		{&db.stmts.setSetting, `INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)`},
+	} {
+		stmt, err := db.conn.Prepare(q.sql)
+		if err != nil {
+			return err
+		}
+		*q.stmt = stmt
+	}
+	return nil
+}
+
 // func (db *DB) migrate() error {
 // 	_, err := db.conn.Exec(`
 // 		CREATE TABLE IF NOT EXISTS entries (
@@ -41,27 +96,82 @@ func NewDB(path string) (*DB, error) {
 // }
 
 func (db *DB) Close() error {
+	for _, stmt := range []*sql.Stmt{
+		db.stmts.insert, db.stmts.byExercise, db.stmts.all, db.stmts.get, db.stmts.last,
+		db.stmts.update, db.stmts.delete, db.stmts.pb, db.stmts.daily,
+		db.stmts.getSetting, db.stmts.setSetting,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
 	return db.conn.Close()
 }
 
-func (db *DB) InsertEntry(e *Entry) error {
+func (db *DB) InsertEntry(ctx context.Context, e *Entry) error {
 	e.Volume = e.Weight * float64(e.Reps) * float64(e.Sets)
-	res, err := db.conn.Exec(
-		`INSERT INTO entries (exercise, weight, reps, sets, volume, notes, date, created_at) VALUES (?,?,?,?,?,?,?,?)`,
-		e.Exercise, e.Weight, e.Reps, e.Sets, e.Volume, e.Notes, e.Date, time.Now(),
+	e.CreatedAt = time.Now()
+	res, err := db.stmts.insert.ExecContext(ctx,
+		e.Exercise, e.Weight, e.Reps, e.Sets, e.Volume, e.Notes, e.Date, e.Warmup, e.RPE, e.CreatedAt,
 	)
 	if err != nil {
-		return err
+		return fmt.Errorf("saving entry: %w", err)
+	}
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("saving entry: %w", err)
 	}
-	e.ID, _ = res.LastInsertId()
 	return nil
 }
 
-func (db *DB) GetEntriesByExercise(exercise string) ([]*Entry, error) {
-	rows, err := db.conn.Query(
-		`SELECT id, exercise, weight, reps, sets, volume, notes, date, created_at FROM entries WHERE exercise=? ORDER BY date DESC, id DESC`,
-		exercise,
-	)
+// InsertEntries stores a batch of entries in a single transaction, so an
+// import either lands completely or not at all.
+func (db *DB) InsertEntries(ctx context.Context, entries []*Entry) error {
+	if err := db.insertEntries(ctx, entries); err != nil {
+		return fmt.Errorf("saving entries: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) insertEntries(ctx context.Context, entries []*Entry) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt := tx.StmtContext(ctx, db.stmts.insert)
+	defer stmt.Close()
+	now := time.Now()
+	for _, e := range entries {
+		e.Volume = e.Weight * float64(e.Reps) * float64(e.Sets)
+		e.CreatedAt = now
+		res, err := stmt.ExecContext(ctx, e.Exercise, e.Weight, e.Reps, e.Sets, e.Volume, e.Notes, e.Date, e.Warmup, e.RPE, now)
+		if err != nil {
+			return err
+		}
+		if e.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetEntriesByExercise(ctx context.Context, exercise string) ([]*Entry, error) {
+	entries, err := scanEntries(db.stmts.byExercise.QueryContext(ctx, exercise))
+	if err != nil {
+		return nil, fmt.Errorf("reading history of %s: %w", exercise, err)
+	}
+	return entries, nil
+}
+
+func (db *DB) GetAllEntries(ctx context.Context) ([]*Entry, error) {
+	entries, err := scanEntries(db.stmts.all.QueryContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("reading entries: %w", err)
+	}
+	return entries, nil
+}
+
+func scanEntries(rows *sql.Rows, err error) ([]*Entry, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -69,42 +179,55 @@ func (db *DB) GetEntriesByExercise(exercise string) ([]*Entry, error) {
 	var entries []*Entry
 	for rows.Next() {
 		e := &Entry{}
-		if err := rows.Scan(&e.ID, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Volume, &e.Notes, &e.Date, &e.CreatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Volume, &e.Notes, &e.Date, &e.Warmup, &e.RPE, &e.CreatedAt); err != nil {
 			return nil, err
 		}
 		entries = append(entries, e)
 	}
-	return entries, nil
+	return entries, rows.Err()
 }
 
-func (db *DB) GetAllEntries() ([]*Entry, error) {
-	rows, err := db.conn.Query(
-		`SELECT id, exercise, weight, reps, sets, volume, notes, date, created_at FROM entries ORDER BY date DESC, id DESC`,
-	)
+func scanEntry(row *sql.Row) (*Entry, error) {
+	e := &Entry{}
+	if err := row.Scan(&e.ID, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Volume, &e.Notes, &e.Date, &e.Warmup, &e.RPE, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetDailyTotals returns one row per training day for exercise, oldest
+// first, with the heaviest weight and the summed volume of that day.
+func (db *DB) GetDailyTotals(ctx context.Context, exercise string) ([]DailyTotal, error) {
+	totals, err := db.dailyTotals(ctx, exercise)
+	if err != nil {
+		return nil, fmt.Errorf("reading daily totals of %s: %w", exercise, err)
+	}
+	return totals, nil
+}
+
+func (db *DB) dailyTotals(ctx context.Context, exercise string) ([]DailyTotal, error) {
+	rows, err := db.stmts.daily.QueryContext(ctx, exercise)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var entries []*Entry
+	var totals []DailyTotal
 	for rows.Next() {
-		e := &Entry{}
-		if err := rows.Scan(&e.ID, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Volume, &e.Notes, &e.Date, &e.CreatedAt); err != nil {
+		var t DailyTotal
+		if err := rows.Scan(&t.Date, &t.MaxWeight, &t.Volume); err != nil {
 			return nil, err
 		}
-		entries = append(entries, e)
+		totals = append(totals, t)
 	}
-	return entries, nil
+	return totals, rows.Err()
 }
 
-func (db *DB) GetPersonalBest(exercise string) (*PersonalBest, error) {
+func (db *DB) GetPersonalBest(ctx context.Context, exercise string) (*PersonalBest, error) {
 	pb := &PersonalBest{Exercise: exercise}
-	row := db.conn.QueryRow(
-		`SELECT MAX(weight), MAX(volume) FROM entries WHERE exercise=?`,
-		exercise,
-	)
+	row := db.stmts.pb.QueryRowContext(ctx, exercise)
 	var maxW, maxV sql.NullFloat64
 	if err := row.Scan(&maxW, &maxV); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("reading personal best of %s: %w", exercise, err)
 	}
 	if maxW.Valid {
 		pb.MaxWeight = maxW.Float64
@@ -115,18 +238,55 @@ func (db *DB) GetPersonalBest(exercise string) (*PersonalBest, error) {
 	return pb, nil
 }
 
-func (db *DB) GetLastEntry(exercise string) (*Entry, error) {
-	row := db.conn.QueryRow(
-		`SELECT id, exercise, weight, reps, sets, volume, notes, date, created_at FROM entries WHERE exercise=? ORDER BY date DESC, id DESC LIMIT 1`,
-		exercise,
+// GetEntry returns the entry with the given id, or ErrNotFound.
+func (db *DB) GetEntry(ctx context.Context, id int64) (*Entry, error) {
+	e, err := scanEntry(db.stmts.get.QueryRowContext(ctx, id))
+	if err == sql.ErrNoRows {
+		err = ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", id, err)
+	}
+	return e, nil
+}
+
+// UpdateEntry overwrites the stored entry with e.ID, or returns ErrNotFound.
+func (db *DB) UpdateEntry(ctx context.Context, e *Entry) error {
+	e.Volume = e.Weight * float64(e.Reps) * float64(e.Sets)
+	res, err := db.stmts.update.ExecContext(ctx,
+		e.Exercise, e.Weight, e.Reps, e.Sets, e.Volume, e.Notes, e.Date, e.Warmup, e.RPE, e.ID,
 	)
-	e := &Entry{}
-	err := row.Scan(&e.ID, &e.Exercise, &e.Weight, &e.Reps, &e.Sets, &e.Volume, &e.Notes, &e.Date, &e.CreatedAt)
+	return affectedOne(res, err, "updating entry", e.ID)
+}
+
+// DeleteEntry removes the entry with the given id, or returns ErrNotFound.
+func (db *DB) DeleteEntry(ctx context.Context, id int64) error {
+	res, err := db.stmts.delete.ExecContext(ctx, id)
+	return affectedOne(res, err, "deleting entry", id)
+}
+
+func affectedOne(res sql.Result, err error, op string, id int64) error {
+	if err == nil {
+		var n int64
+		if n, err = res.RowsAffected(); err == nil && n == 0 {
+			err = ErrNotFound
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s %d: %w", op, id, err)
+	}
+	return nil
+}
+
+// GetLastEntry returns the newest working set entry for exercise, or nil if
+// it has never been logged.
+func (db *DB) GetLastEntry(ctx context.Context, exercise string) (*Entry, error) {
+	e, err := scanEntry(db.stmts.last.QueryRowContext(ctx, exercise))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("reading last entry of %s: %w", exercise, err)
 	}
 	return e, nil
 }
@@ -142,12 +302,32 @@ func (db *DB) migrate() error {
 			volume REAL NOT NULL,
 			notes TEXT,
 			date TEXT NOT NULL,
+			warmup INTEGER NOT NULL DEFAULT 0,
+			rpe REAL NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
 	if err != nil {
 		return err
 	}
+	if err := db.addColumn("entries", "warmup", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := db.addColumn("entries", "rpe", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	// per-exercise history filters on exercise and sorts by date; weight,
+	// volume and warmup are included so PBs and daily chart totals never
+	// touch the table. The full history and the dashboard sort or range over
+	// date alone. The index without warmup is from before warm-up sets.
+	_, err = db.conn.Exec(`
+		DROP INDEX IF EXISTS idx_entries_exercise_date;
+		CREATE INDEX IF NOT EXISTS idx_entries_exercise ON entries (exercise, date, weight, volume, warmup);
+		CREATE INDEX IF NOT EXISTS idx_entries_date ON entries (date)
+	`)
+	if err != nil {
+		return err
+	}
 	_, err = db.conn.Exec(`
 		CREATE TABLE IF NOT EXISTS settings (
 			key TEXT PRIMARY KEY,
@@ -157,30 +337,163 @@ func (db *DB) migrate() error {
 	if err != nil {
 		return err
 	}
+	_, err = db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS exercise_aliases (
+			source TEXT NOT NULL,
+			name TEXT NOT NULL,
+			exercise TEXT NOT NULL,
+			PRIMARY KEY (source, name)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if err := db.migrateGoals(); err != nil {
+		return err
+	}
 	// default to week 1
 	_, err = db.conn.Exec(`
 		INSERT OR IGNORE INTO settings (key, value) VALUES ('current_week', '1')
 	`)
+	if err != nil {
+		return err
+	}
+	return db.repairDates()
+}
+
+// addColumn adds a column to a table created by an older version.
+func (db *DB) addColumn(table, column, def string) error {
+	rows, err := db.conn.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	_, err = db.conn.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + def)
 	return err
 }
 
-func (db *DB) GetCurrentWeek() int {
-	row := db.conn.QueryRow(`SELECT value FROM settings WHERE key='current_week'`)
+// repairDates rewrites dates stored before they were validated, such as
+// "2024-1-5" or "05/01/2024", in the YYYY-MM-DD form the date ordering
+// relies on. An entry whose date cannot be read at all is moved to the day
+// it was created.
+func (db *DB) repairDates() error {
+	rows, err := db.conn.Query(`SELECT DISTINCT date FROM entries`)
+	if err != nil {
+		return err
+	}
+	var bad []string
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			rows.Close()
+			return err
+		}
+		if d, err := ParseDate(raw); err != nil || string(d) != raw {
+			bad = append(bad, raw)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(bad) == 0 {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, raw := range bad {
+		if d, err := ParseDate(raw); err == nil {
+			if _, err := tx.Exec(`UPDATE entries SET date=? WHERE date=?`, d, raw); err != nil {
+				return fmt.Errorf("repairing date %q: %w", raw, err)
+			}
+			continue
+		}
+		if err := repairByCreation(tx, raw); err != nil {
+			return fmt.Errorf("repairing date %q: %w", raw, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func repairByCreation(tx *sql.Tx, raw string) error {
+	rows, err := tx.Query(`SELECT id, created_at FROM entries WHERE date=?`, raw)
+	if err != nil {
+		return err
+	}
+	created := map[int64]Date{}
+	for rows.Next() {
+		var id int64
+		var at sql.NullTime
+		if err := rows.Scan(&id, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		created[id] = Today()
+		if at.Valid {
+			created[id] = DateOf(at.Time.Local())
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, d := range created {
+		if _, err := tx.Exec(`UPDATE entries SET date=? WHERE id=?`, d, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSetting returns the value stored under key and whether it was set.
+func (db *DB) GetSetting(ctx context.Context, key string) (string, bool, error) {
 	var val string
-	if err := row.Scan(&val); err != nil {
-		return 1
+	err := db.stmts.getSetting.QueryRowContext(ctx, key).Scan(&val)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("reading setting %s: %w", key, err)
+	}
+	return val, true, nil
+}
+
+func (db *DB) SetSetting(ctx context.Context, key, value string) error {
+	if _, err := db.stmts.setSetting.ExecContext(ctx, key, value); err != nil {
+		return fmt.Errorf("saving setting %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetCurrentWeek returns the program week, 1 or 2. It is 1 until set.
+func (db *DB) GetCurrentWeek(ctx context.Context) (int, error) {
+	val, _, err := db.GetSetting(ctx, "current_week")
+	if err != nil {
+		return 0, err
 	}
 	if val == "2" {
-		return 2
+		return 2, nil
 	}
-	return 1
+	return 1, nil
 }
 
-func (db *DB) SetCurrentWeek(week int) error {
-	v := "1"
-	if week == 2 {
-		v = "2"
+func (db *DB) SetCurrentWeek(ctx context.Context, week int) error {
+	if week != 1 && week != 2 {
+		return Invalid("week", "week must be 1 or 2")
 	}
-	_, err := db.conn.Exec(`INSERT OR REPLACE INTO settings (key, value) VALUES ('current_week', ?)`, v)
-	return err
+	return db.SetSetting(ctx, "current_week", strconv.Itoa(week))
 }
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a record looked up by its key does not
+	// exist.
+	ErrNotFound = errors.New("not found")
+	// ErrValidation matches every *ValidationError with errors.Is.
+	ErrValidation = errors.New("invalid input")
+)
+
+// FieldError is one rejected input. Field uses the JSON field names.
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+// ValidationError reports values that were rejected before they reached
+// storage, one FieldError per offending input in the order they were
+// checked.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Msg
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Add records a problem with field.
+func (e *ValidationError) Add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Msg: msg})
+}
+
+// Reason returns why field was rejected, or "" if it was not.
+func (e *ValidationError) Reason(field string) string {
+	for _, f := range e.Fields {
+		if f.Field == field {
+			return f.Msg
+		}
+	}
+	return ""
+}
+
+// Err returns e if any field was rejected and nil otherwise, so checks can
+// be collected with Add before returning.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Invalid returns a *ValidationError for a single field.
+func Invalid(field, msg string) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Msg: msg}}}
+}
@@ -0,0 +1,193 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Goal metrics: what a goal's target is measured against.
+const (
+	GoalWeight     = "weight"     // heaviest working set of an exercise
+	GoalE1RM       = "e1rm"       // best RPE-adjusted estimated 1RM of an exercise
+	GoalBodyweight = "bodyweight" // bodyweight readings
+)
+
+// Goal is a target value for a metric, optionally by a date. A goal is
+// reached when the metric gets to Target from the direction of Start.
+type Goal struct {
+	ID        int64     `json:"id"`
+	Metric    string    `json:"metric"`
+	Exercise  string    `json:"exercise,omitempty"` // empty for bodyweight
+	Target    float64   `json:"target"`
+	Start     float64   `json:"start"`              // value when the goal was set, 0 if none yet
+	By        Date      `json:"by,omitempty"`       // target date, if any
+	Achieved  Date      `json:"achieved,omitempty"` // day it was reached
+	SetOn     Date      `json:"set_on"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Bodyweight is the reading of one day, in kg.
+type Bodyweight struct {
+	Date   Date    `json:"date"`
+	Weight float64 `json:"weight"`
+}
+
+const goalColumns = `id, metric, exercise, target, start, by_date, achieved, set_on, created_at`
+
+func (db *DB) GetGoals(ctx context.Context) ([]*Goal, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+goalColumns+` FROM goals ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("reading goals: %w", err)
+	}
+	defer rows.Close()
+	var goals []*Goal
+	for rows.Next() {
+		g := &Goal{}
+		if err := rows.Scan(&g.ID, &g.Metric, &g.Exercise, &g.Target, &g.Start, &g.By, &g.Achieved, &g.SetOn, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func (db *DB) InsertGoal(ctx context.Context, g *Goal) error {
+	g.CreatedAt = time.Now()
+	res, err := db.conn.ExecContext(ctx,
+		`INSERT INTO goals (metric, exercise, target, start, by_date, achieved, set_on, created_at) VALUES (?,?,?,?,?,?,?,?)`,
+		g.Metric, g.Exercise, g.Target, g.Start, g.By, g.Achieved, g.SetOn, g.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("saving goal: %w", err)
+	}
+	if g.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("saving goal: %w", err)
+	}
+	return nil
+}
+
+// UpdateGoal overwrites the stored goal with g.ID, or returns ErrNotFound.
+func (db *DB) UpdateGoal(ctx context.Context, g *Goal) error {
+	res, err := db.conn.ExecContext(ctx,
+		`UPDATE goals SET metric=?, exercise=?, target=?, start=?, by_date=?, achieved=?, set_on=? WHERE id=?`,
+		g.Metric, g.Exercise, g.Target, g.Start, g.By, g.Achieved, g.SetOn, g.ID,
+	)
+	return affectedOne(res, err, "updating goal", g.ID)
+}
+
+// DeleteGoal removes the goal with id, or returns ErrNotFound.
+func (db *DB) DeleteGoal(ctx context.Context, id int64) error {
+	res, err := db.conn.ExecContext(ctx, `DELETE FROM goals WHERE id=?`, id)
+	return affectedOne(res, err, "deleting goal", id)
+}
+
+func (db *DB) GetBodyweights(ctx context.Context) ([]Bodyweight, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT date, weight FROM bodyweight ORDER BY date`)
+	if err != nil {
+		return nil, fmt.Errorf("reading bodyweight: %w", err)
+	}
+	defer rows.Close()
+	var readings []Bodyweight
+	for rows.Next() {
+		var b Bodyweight
+		if err := rows.Scan(&b.Date, &b.Weight); err != nil {
+			return nil, err
+		}
+		readings = append(readings, b)
+	}
+	return readings, rows.Err()
+}
+
+// SetBodyweight replaces the reading of b.Date; a weight of 0 removes it.
+func (db *DB) SetBodyweight(ctx context.Context, b Bodyweight) error {
+	var err error
+	if b.Weight == 0 {
+		_, err = db.conn.ExecContext(ctx, `DELETE FROM bodyweight WHERE date=?`, b.Date)
+	} else {
+		_, err = db.conn.ExecContext(ctx, `INSERT OR REPLACE INTO bodyweight (date, weight) VALUES (?, ?)`, b.Date, b.Weight)
+	}
+	if err != nil {
+		return fmt.Errorf("saving bodyweight of %s: %w", b.Date, err)
+	}
+	return nil
+}
+
+func (db *DB) migrateGoals() error {
+	_, err := db.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS goals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			metric TEXT NOT NULL,
+			exercise TEXT NOT NULL DEFAULT '',
+			target REAL NOT NULL,
+			start REAL NOT NULL DEFAULT 0,
+			by_date TEXT NOT NULL DEFAULT '',
+			achieved TEXT NOT NULL DEFAULT '',
+			set_on TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS bodyweight (
+			date TEXT PRIMARY KEY,
+			weight REAL NOT NULL
+		)
+	`)
+	return err
+}
+
+// restoreGoals adds the archived goals; a merge skips those already set.
+func restoreGoals(tx *sql.Tx, goals []ArchiveGoal, mode RestoreMode) error {
+	for _, g := range goals {
+		if mode == RestoreMerge {
+			var id int64
+			err := tx.QueryRow(`SELECT id FROM goals WHERE metric=? AND exercise=? AND target=? AND by_date=? LIMIT 1`,
+				g.Metric, g.Exercise, g.Target, g.By).Scan(&id)
+			if err == nil {
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+		created := g.CreatedAt.UTC()
+		if g.CreatedAt.IsZero() {
+			created = time.Now().UTC()
+		}
+		var id any
+		if mode == RestoreReplace && g.ID > 0 {
+			id = g.ID
+		}
+		_, err := tx.Exec(
+			`INSERT INTO goals (id, metric, exercise, target, start, by_date, achieved, set_on, created_at) VALUES (?,?,?,?,?,?,?,?,?)`,
+			id, g.Metric, g.Exercise, g.Target, g.Start, g.By, g.Achieved, g.SetOn, created,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreBodyweight(tx *sql.Tx, readings []Bodyweight, report *RestoreReport) error {
+	for _, b := range readings {
+		var local float64
+		err := tx.QueryRow(`SELECT weight FROM bodyweight WHERE date=?`, b.Date).Scan(&local)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(`INSERT INTO bodyweight (date, weight) VALUES (?, ?)`, b.Date, b.Weight); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case local != b.Weight:
+			report.Conflicts = append(report.Conflicts, Conflict{
+				Kind: "bodyweight", Key: b.Date.String(), Local: formatKg(local), Incoming: formatKg(b.Weight),
+			})
+		}
+	}
+	return nil
+}
+
+func formatKg(v float64) string {
+	return fmt.Sprintf("%gkg", v)
+}
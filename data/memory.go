@@ -0,0 +1,542 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps everything in memory, for tests and for
+// anything else that does not need the data to outlive the process. It
+// behaves like the SQLite Repository, down to the ordering of results.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	entries  map[int64]*Entry
+	nextID   int64
+	settings map[string]string
+	aliases  map[aliasKey]string
+	goals    map[int64]*Goal
+	nextGoal int64
+	weights  map[Date]float64
+}
+
+type aliasKey struct{ source, name string }
+
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{
+		entries:  map[int64]*Entry{},
+		nextID:   1,
+		settings: map[string]string{},
+		aliases:  map[aliasKey]string{},
+		goals:    map[int64]*Goal{},
+		nextGoal: 1,
+		weights:  map[Date]float64{},
+	}
+	m.defaults()
+	return m
+}
+
+// defaults mirrors what DB.migrate inserts.
+func (m *MemoryStore) defaults() {
+	if _, ok := m.settings["current_week"]; !ok {
+		m.settings["current_week"] = "1"
+	}
+}
+
+func (m *MemoryStore) insert(e *Entry, now time.Time) {
+	e.Volume = e.Weight * float64(e.Reps) * float64(e.Sets)
+	e.CreatedAt = now
+	e.ID = m.nextID
+	m.nextID++
+	stored := *e
+	m.entries[e.ID] = &stored
+}
+
+func (m *MemoryStore) Save(ctx context.Context, e *Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.insert(e, time.Now())
+	return nil
+}
+
+func (m *MemoryStore) SaveAll(ctx context.Context, entries []*Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, e := range entries {
+		m.insert(e, now)
+	}
+	return nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id int64) (*Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[id]
+	if !ok {
+		return nil, fmt.Errorf("entry %d: %w", id, ErrNotFound)
+	}
+	c := *e
+	return &c, nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, e *Entry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e.Volume = e.Weight * float64(e.Reps) * float64(e.Sets)
+	old, ok := m.entries[e.ID]
+	if !ok {
+		return fmt.Errorf("updating entry %d: %w", e.ID, ErrNotFound)
+	}
+	stored := *e
+	stored.CreatedAt = old.CreatedAt
+	m.entries[e.ID] = &stored
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[id]; !ok {
+		return fmt.Errorf("deleting entry %d: %w", id, ErrNotFound)
+	}
+	delete(m.entries, id)
+	return nil
+}
+
+// sorted returns copies of the entries that pass keep, newest first.
+func (m *MemoryStore) sorted(keep func(*Entry) bool) []*Entry {
+	var out []*Entry
+	for _, e := range m.entries {
+		if keep(e) {
+			c := *e
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Date != out[j].Date {
+			return out[i].Date > out[j].Date
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+func (m *MemoryStore) HistoryFor(ctx context.Context, exercise string) ([]*Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sorted(func(e *Entry) bool { return e.Exercise == exercise }), nil
+}
+
+func (m *MemoryStore) All(ctx context.Context) ([]*Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sorted(func(*Entry) bool { return true }), nil
+}
+
+func (m *MemoryStore) DailyTotals(ctx context.Context, exercise string) ([]DailyTotal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	byDate := map[Date]*DailyTotal{}
+	for _, e := range m.entries {
+		if e.Exercise != exercise || e.Warmup {
+			continue
+		}
+		t, ok := byDate[e.Date]
+		if !ok {
+			t = &DailyTotal{Date: e.Date, MaxWeight: e.Weight}
+			byDate[e.Date] = t
+		}
+		t.MaxWeight = max(t.MaxWeight, e.Weight)
+		t.Volume += e.Volume
+	}
+	var totals []DailyTotal
+	for _, t := range byDate {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date < totals[j].Date })
+	return totals, nil
+}
+
+func (m *MemoryStore) PersonalBest(ctx context.Context, exercise string) (*PersonalBest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	pb := &PersonalBest{Exercise: exercise}
+	for _, e := range m.entries {
+		if e.Exercise == exercise && !e.Warmup {
+			pb.MaxWeight = max(pb.MaxWeight, e.Weight)
+			pb.MaxVolume = max(pb.MaxVolume, e.Volume)
+		}
+	}
+	return pb, nil
+}
+
+func (m *MemoryStore) LastEntry(ctx context.Context, exercise string) (*Entry, error) {
+	h, err := m.HistoryFor(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range h {
+		if !e.Warmup {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStore) GetCurrentWeek(ctx context.Context) (int, error) {
+	v, _, err := m.Setting(ctx, "current_week")
+	if err != nil {
+		return 0, err
+	}
+	if v == "2" {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+func (m *MemoryStore) SetCurrentWeek(ctx context.Context, week int) error {
+	if week != 1 && week != 2 {
+		return Invalid("week", "week must be 1 or 2")
+	}
+	return m.SetSetting(ctx, "current_week", strconv.Itoa(week))
+}
+
+func (m *MemoryStore) Setting(ctx context.Context, key string) (string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.settings[key]
+	return v, ok, nil
+}
+
+func (m *MemoryStore) SetSetting(ctx context.Context, key, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[key] = value
+	return nil
+}
+
+func (m *MemoryStore) Aliases(ctx context.Context, source string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	aliases := map[string]string{}
+	for k, v := range m.aliases {
+		if k.source == source {
+			aliases[k.name] = v
+		}
+	}
+	return aliases, nil
+}
+
+func (m *MemoryStore) SetAlias(ctx context.Context, source, name, exercise string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.aliases[aliasKey{source, name}] = exercise
+	return nil
+}
+
+func (m *MemoryStore) Goals(ctx context.Context) ([]*Goal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedGoals(), nil
+}
+
+// sortedGoals returns copies of the goals, oldest first.
+func (m *MemoryStore) sortedGoals() []*Goal {
+	var goals []*Goal
+	for _, g := range m.goals {
+		c := *g
+		goals = append(goals, &c)
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals
+}
+
+func (m *MemoryStore) SaveGoal(ctx context.Context, g *Goal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g.CreatedAt = time.Now()
+	g.ID = m.nextGoal
+	m.nextGoal++
+	stored := *g
+	m.goals[g.ID] = &stored
+	return nil
+}
+
+func (m *MemoryStore) UpdateGoal(ctx context.Context, g *Goal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.goals[g.ID]
+	if !ok {
+		return fmt.Errorf("updating goal %d: %w", g.ID, ErrNotFound)
+	}
+	stored := *g
+	stored.CreatedAt = old.CreatedAt
+	m.goals[g.ID] = &stored
+	return nil
+}
+
+func (m *MemoryStore) DeleteGoal(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.goals[id]; !ok {
+		return fmt.Errorf("deleting goal %d: %w", id, ErrNotFound)
+	}
+	delete(m.goals, id)
+	return nil
+}
+
+func (m *MemoryStore) Bodyweights(ctx context.Context) ([]Bodyweight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sortedWeights(), nil
+}
+
+func (m *MemoryStore) sortedWeights() []Bodyweight {
+	var readings []Bodyweight
+	for d, w := range m.weights {
+		readings = append(readings, Bodyweight{Date: d, Weight: w})
+	}
+	sort.Slice(readings, func(i, j int) bool { return readings[i].Date < readings[j].Date })
+	return readings
+}
+
+func (m *MemoryStore) SetBodyweight(ctx context.Context, b Bodyweight) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b.Weight == 0 {
+		delete(m.weights, b.Date)
+	} else {
+		m.weights[b.Date] = b.Weight
+	}
+	return nil
+}
+
+func (m *MemoryStore) Backup(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	a := &Archive{
+		Format:     ArchiveFormat,
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Settings:   maps.Clone(m.settings),
+	}
+	entries := m.sorted(func(*Entry) bool { return true })
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		a.Entries = append(a.Entries, ArchiveEntry{
+			ID: e.ID, Exercise: e.Exercise, Weight: e.Weight, Reps: e.Reps, Sets: e.Sets,
+			Volume: e.Volume, Notes: e.Notes, Date: e.Date, Warmup: e.Warmup, RPE: e.RPE, CreatedAt: e.CreatedAt,
+		})
+	}
+	for k, v := range m.aliases {
+		a.Aliases = append(a.Aliases, ArchiveAlias{Source: k.source, Name: k.name, Exercise: v})
+	}
+	for _, g := range m.sortedGoals() {
+		a.Goals = append(a.Goals, ArchiveGoal(*g))
+	}
+	a.Bodyweight = m.sortedWeights()
+	m.mu.RUnlock()
+	sort.Slice(a.Aliases, func(i, j int) bool {
+		if a.Aliases[i].Source != a.Aliases[j].Source {
+			return a.Aliases[i].Source < a.Aliases[j].Source
+		}
+		return a.Aliases[i].Name < a.Aliases[j].Name
+	})
+	return WriteArchive(w, a)
+}
+
+// Restore follows DB.RestoreArchive: merge keeps local values and skips
+// entries already present, replace starts from empty and keeps archived ids.
+func (m *MemoryStore) Restore(ctx context.Context, r io.Reader, mode RestoreMode) (*RestoreReport, error) {
+	a, err := ReadArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings, entries, aliases := maps.Clone(m.settings), maps.Clone(m.entries), maps.Clone(m.aliases)
+	goals, weights := maps.Clone(m.goals), maps.Clone(m.weights)
+	nextID, nextGoal := m.nextID, m.nextGoal
+	if mode == RestoreReplace {
+		settings, entries, aliases = map[string]string{}, map[int64]*Entry{}, map[aliasKey]string{}
+		goals, weights = map[int64]*Goal{}, map[Date]float64{}
+	}
+	report := &RestoreReport{}
+
+	keys := make([]string, 0, len(a.Settings))
+	for k := range a.Settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := a.Settings[k]
+		local, ok := settings[k]
+		switch {
+		case !ok:
+			settings[k] = v
+			report.SettingsApplied++
+		case local != v:
+			report.Conflicts = append(report.Conflicts, Conflict{Kind: "setting", Key: k, Local: local, Incoming: v})
+		}
+	}
+
+	local := localMatches{}
+	for _, id := range slices.Sorted(maps.Keys(entries)) {
+		local.add(entries[id])
+	}
+	for _, e := range a.Entries {
+		if mode == RestoreMerge {
+			if notes, ok := local.take(e); ok {
+				report.EntriesSkipped++
+				if notes != e.Notes {
+					report.Conflicts = append(report.Conflicts, entryConflict(e, notes))
+				}
+				continue
+			}
+		}
+		created := e.CreatedAt.UTC()
+		if e.CreatedAt.IsZero() {
+			created = time.Now().UTC()
+		}
+		id := nextID
+		if mode == RestoreReplace && e.ID > 0 {
+			if _, taken := entries[e.ID]; taken {
+				return nil, fmt.Errorf("archive has entry id %d twice", e.ID)
+			}
+			id = e.ID
+		}
+		nextID = max(nextID, id+1)
+		entries[id] = &Entry{
+			ID: id, Exercise: e.Exercise, Weight: e.Weight, Reps: e.Reps, Sets: e.Sets,
+			Volume: e.Weight * float64(e.Reps) * float64(e.Sets), Notes: e.Notes, Date: e.Date, Warmup: e.Warmup, RPE: e.RPE, CreatedAt: created,
+		}
+		report.EntriesAdded++
+	}
+
+	for _, al := range a.Aliases {
+		k := aliasKey{al.Source, al.Name}
+		local, ok := aliases[k]
+		switch {
+		case !ok:
+			aliases[k] = al.Exercise
+		case local != al.Exercise:
+			report.Conflicts = append(report.Conflicts, Conflict{Kind: "alias", Key: al.Source + ": " + al.Name, Local: local, Incoming: al.Exercise})
+		}
+	}
+
+	for _, g := range a.Goals {
+		if mode == RestoreMerge && hasGoal(goals, g) {
+			continue
+		}
+		created := g.CreatedAt.UTC()
+		if g.CreatedAt.IsZero() {
+			created = time.Now().UTC()
+		}
+		id := nextGoal
+		if mode == RestoreReplace && g.ID > 0 {
+			id = g.ID
+		}
+		nextGoal = max(nextGoal, id+1)
+		stored := Goal(g)
+		stored.ID, stored.CreatedAt = id, created
+		goals[id] = &stored
+	}
+
+	for _, b := range a.Bodyweight {
+		local, ok := weights[b.Date]
+		switch {
+		case !ok:
+			weights[b.Date] = b.Weight
+		case local != b.Weight:
+			report.Conflicts = append(report.Conflicts, Conflict{
+				Kind: "bodyweight", Key: b.Date.String(), Local: formatKg(local), Incoming: formatKg(b.Weight),
+			})
+		}
+	}
+
+	m.settings, m.entries, m.aliases, m.nextID = settings, entries, aliases, nextID
+	m.goals, m.weights, m.nextGoal = goals, weights, nextGoal
+	m.defaults()
+	return report, nil
+}
+
+// hasGoal reports whether goals has one for the same metric, exercise,
+// target and date as g, like the lookup in restoreGoals.
+func hasGoal(goals map[int64]*Goal, g ArchiveGoal) bool {
+	for _, local := range goals {
+		if local.Metric == g.Metric && local.Exercise == g.Exercise && local.Target == g.Target && local.By == g.By {
+			return true
+		}
+	}
+	return false
+}
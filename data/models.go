@@ -1,130 +1,262 @@
 package data
 
-import "time"
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
 
 type Entry struct {
-	ID        int64
-	Exercise  string
-	Weight    float64
-	Reps      int
-	Sets      int
-	Volume    float64
-	Notes     string
-	Date      string
-	CreatedAt time.Time
+	ID        int64     `json:"id"`
+	Exercise  string    `json:"exercise"`
+	Weight    float64   `json:"weight"`
+	Reps      int       `json:"reps"`
+	Sets      int       `json:"sets"`
+	Volume    float64   `json:"volume"`
+	Notes     string    `json:"notes"`
+	Date      Date      `json:"date"`
+	Warmup    bool      `json:"warmup,omitempty"` // left out of volume, PBs and charts
+	RPE       float64   `json:"rpe,omitempty"`    // how hard the set was, 6 to 10; 0 if not recorded
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type PersonalBest struct {
-	Exercise  string
+	Exercise  string  `json:"exercise"`
+	MaxWeight float64 `json:"max_weight"`
+	MaxVolume float64 `json:"max_volume"`
+	Date      Date    `json:"date,omitempty"`
+}
+
+// DailyTotal sums up one exercise on one day.
+type DailyTotal struct {
+	Date      Date
 	MaxWeight float64
-	MaxVolume float64
-	Date      string
+	Volume    float64
+}
+
+// Block is one step of a day's plan: a single exercise, or exercises done
+// back to back with no rest between them, as a superset (two) or a circuit
+// (three or more).
+type Block []string
+
+// Kind names a grouped block, "superset" or "circuit", and is empty for a
+// single exercise.
+func (b Block) Kind() string {
+	switch {
+	case len(b) < 2:
+		return ""
+	case len(b) == 2:
+		return "superset"
+	}
+	return "circuit"
 }
 
 type WeekPlan struct {
-	Week1 []string
-	Week2 []string
+	Week1 []Block
+	Week2 []Block
 }
 
 var WorkoutPlans = map[string]WeekPlan{
 	"Monday": {
-		Week1: []string{
-			"Flat Bench Barbell Chest Press",
-			"Inclined Dumbbell Press",
-			"Seated Pec Dec Flies Machine",
-			"Cable Flies (Low to High)",
-			"Close-Grip Dumbbell Press",
+		Week1: []Block{
+			{"Flat Bench Barbell Chest Press"},
+			{"Inclined Dumbbell Press"},
+			{"Seated Pec Dec Flies Machine"},
+			{"Cable Flies (Low to High)"},
+			{"Close-Grip Dumbbell Press"},
 		},
-		Week2: []string{
-			"Incline Barbell Bench Press",
-			"Decline Dumbbell Press",
-			"Flat Bench Cable Flies",
-			"Standing Cable Crossover (High to Low)",
-			"Dumbbell Pullover",
+		Week2: []Block{
+			{"Incline Barbell Bench Press"},
+			{"Decline Dumbbell Press"},
+			{"Flat Bench Cable Flies"},
+			{"Standing Cable Crossover (High to Low)"},
+			{"Dumbbell Pullover"},
 		},
 	},
 	"Tuesday": {
-		Week1: []string{
-			"Wide Grip Lat Pulldown",
-			"Seated V Bar Cable Rowing",
-			"Dumbbell Rowing",
-			"Close Grip Lat Pulldown",
-			"Lat Pushdown",
+		Week1: []Block{
+			{"Wide Grip Lat Pulldown"},
+			{"Seated V Bar Cable Rowing"},
+			{"Dumbbell Rowing"},
+			{"Close Grip Lat Pulldown"},
+			{"Lat Pushdown"},
 		},
-		Week2: []string{
-			"Mid Grip Lat Pulldown",
-			"T-Bar Row",
-			"Single Arm Cable Rowing",
-			"Reverse Cable Crossovers",
-			"Hyperextensions",
+		Week2: []Block{
+			{"Mid Grip Lat Pulldown"},
+			{"T-Bar Row"},
+			{"Single Arm Cable Rowing"},
+			{"Reverse Cable Crossovers"},
+			{"Hyperextensions"},
 		},
 	},
 	"Wednesday": {
-		Week1: []string{
-			"Seated Dumbbell Press",
-			"Dumbbell Lateral Raises",
-			"Dumbbell Alternate Front Raises",
-			"Upright Rows",
-			"Shrugs",
-			"Crunches",
-			"Russian Twists",
+		Week1: []Block{
+			{"Seated Dumbbell Press"},
+			{"Dumbbell Lateral Raises"},
+			{"Dumbbell Alternate Front Raises"},
+			{"Upright Rows"},
+			{"Shrugs"},
+			{"Crunches", "Russian Twists"},
 		},
-		Week2: []string{
-			"Seated Overhead Barbell Press",
-			"Cable Lateral Raises",
-			"Front Plate Raises",
-			"Rope Face Pulls",
-			"Smith Machine Shrugs",
-			"Hanging Leg Raises",
-			"Cable Crunches",
+		Week2: []Block{
+			{"Seated Overhead Barbell Press"},
+			{"Cable Lateral Raises"},
+			{"Front Plate Raises"},
+			{"Rope Face Pulls"},
+			{"Smith Machine Shrugs"},
+			{"Hanging Leg Raises", "Cable Crunches"},
 		},
 	},
 	"Thursday": {
-		Week1: []string{
-			"Standing Alternate Bicep Curl",
-			"Seated Single Arm Tricep Extensions",
-			"Standing Alternate Hammer Curls",
-			"Cable Rope Pushdown",
-			"Reverse Grip Barbell Curl",
-			"Tricep Cable Kickbacks",
-			"Leg Raises",
-			"Plank",
+		Week1: []Block{
+			{"Standing Alternate Bicep Curl", "Seated Single Arm Tricep Extensions"},
+			{"Standing Alternate Hammer Curls", "Cable Rope Pushdown"},
+			{"Reverse Grip Barbell Curl", "Tricep Cable Kickbacks"},
+			{"Leg Raises", "Plank"},
 		},
-		Week2: []string{
-			"Barbell Curl",
-			"Overhead Dumbbell Tricep Extensions",
-			"Preacher Curl",
-			"Straight Bar Pushdown",
-			"Zottman Curl",
-			"Overhead Rope Extensions",
-			"Side Plank",
-			"Toe Touches",
+		Week2: []Block{
+			{"Barbell Curl", "Overhead Dumbbell Tricep Extensions"},
+			{"Preacher Curl", "Straight Bar Pushdown"},
+			{"Zottman Curl", "Overhead Rope Extensions"},
+			{"Side Plank", "Toe Touches"},
 		},
 	},
 	"Friday": {
-		Week1: []string{
-			"Smith Machine Squats",
-			"Leg Extensions",
-			"Walking Lunges",
-			"Leg Press",
-			"Hamstring Curls",
-			"Standing Calf Raises",
+		Week1: []Block{
+			{"Smith Machine Squats"},
+			{"Leg Extensions"},
+			{"Walking Lunges"},
+			{"Leg Press"},
+			{"Hamstring Curls"},
+			{"Standing Calf Raises"},
 		},
-		Week2: []string{
-			"Barbell Squats",
-			"Bulgarian Split Squats",
-			"Standing Lunges",
-			"Hack Squats",
-			"Romanian Deadlifts",
-			"Seated Calf Raises",
+		Week2: []Block{
+			{"Barbell Squats"},
+			{"Bulgarian Split Squats"},
+			{"Standing Lunges"},
+			{"Hack Squats"},
+			{"Romanian Deadlifts"},
+			{"Seated Calf Raises"},
 		},
 	},
 }
 
 var DayOrder = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}
 
-// WorkoutDays returns the exercise list for a given day and week (1 or 2)
-func WorkoutDays(day string, week int) []string {
+// DefaultRest is the rest between sets for exercises not in RestSeconds.
+const DefaultRest = 90 * time.Second
+
+// RestSeconds is the program's rest between sets where it differs from
+// DefaultRest: longer for heavy compound lifts, shorter for core work.
+var RestSeconds = map[string]int{
+	"Flat Bench Barbell Chest Press": 150,
+	"Incline Barbell Bench Press":    150,
+	"Seated Overhead Barbell Press":  150,
+	"T-Bar Row":                      120,
+	"Smith Machine Squats":           150,
+	"Barbell Squats":                 180,
+	"Hack Squats":                    150,
+	"Leg Press":                      150,
+	"Romanian Deadlifts":             150,
+	"Crunches":                       45,
+	"Russian Twists":                 45,
+	"Hanging Leg Raises":             60,
+	"Cable Crunches":                 45,
+	"Leg Raises":                     45,
+	"Plank":                          45,
+	"Side Plank":                     45,
+	"Toe Touches":                    45,
+}
+
+// RestFor returns the program's rest between sets of exercise.
+func RestFor(exercise string) time.Duration {
+	if s, ok := RestSeconds[exercise]; ok {
+		return time.Duration(s) * time.Second
+	}
+	return DefaultRest
+}
+
+// WarmupExercises are the heavy compounds worked up to with warm-up sets.
+var WarmupExercises = []string{
+	"Flat Bench Barbell Chest Press",
+	"Barbell Squats",
+	"Romanian Deadlifts",
+	"T-Bar Row",
+}
+
+// Equipment kinds. Barbell, EZBar, Smith and Sled are loaded with plates
+// on a bar of that type; dumbbells and machines go up in fixed steps.
+// Machine covers cable stacks too.
+const (
+	Barbell  = "barbell"
+	EZBar    = "ez-bar"
+	Smith    = "smith"
+	Sled     = "sled"
+	Dumbbell = "dumbbell"
+	Machine  = "machine"
+)
+
+// ExerciseEquipment is what each program exercise is loaded with.
+// Exercises missing from it, such as bodyweight ones, take any weight.
+var ExerciseEquipment = map[string]string{
+	"Flat Bench Barbell Chest Press":         Barbell,
+	"Incline Barbell Bench Press":            Barbell,
+	"Seated Overhead Barbell Press":          Barbell,
+	"T-Bar Row":                              Barbell,
+	"Upright Rows":                           Barbell,
+	"Barbell Curl":                           Barbell,
+	"Reverse Grip Barbell Curl":              Barbell,
+	"Barbell Squats":                         Barbell,
+	"Romanian Deadlifts":                     Barbell,
+	"Preacher Curl":                          EZBar,
+	"Smith Machine Squats":                   Smith,
+	"Smith Machine Shrugs":                   Smith,
+	"Leg Press":                              Sled,
+	"Hack Squats":                            Sled,
+	"Inclined Dumbbell Press":                Dumbbell,
+	"Close-Grip Dumbbell Press":              Dumbbell,
+	"Decline Dumbbell Press":                 Dumbbell,
+	"Dumbbell Pullover":                      Dumbbell,
+	"Dumbbell Rowing":                        Dumbbell,
+	"Seated Dumbbell Press":                  Dumbbell,
+	"Dumbbell Lateral Raises":                Dumbbell,
+	"Dumbbell Alternate Front Raises":        Dumbbell,
+	"Shrugs":                                 Dumbbell,
+	"Standing Alternate Bicep Curl":          Dumbbell,
+	"Seated Single Arm Tricep Extensions":    Dumbbell,
+	"Standing Alternate Hammer Curls":        Dumbbell,
+	"Overhead Dumbbell Tricep Extensions":    Dumbbell,
+	"Zottman Curl":                           Dumbbell,
+	"Bulgarian Split Squats":                 Dumbbell,
+	"Walking Lunges":                         Dumbbell,
+	"Standing Lunges":                        Dumbbell,
+	"Seated Pec Dec Flies Machine":           Machine,
+	"Leg Extensions":                         Machine,
+	"Hamstring Curls":                        Machine,
+	"Standing Calf Raises":                   Machine,
+	"Seated Calf Raises":                     Machine,
+	"Cable Flies (Low to High)":              Machine,
+	"Flat Bench Cable Flies":                 Machine,
+	"Standing Cable Crossover (High to Low)": Machine,
+	"Wide Grip Lat Pulldown":                 Machine,
+	"Seated V Bar Cable Rowing":              Machine,
+	"Close Grip Lat Pulldown":                Machine,
+	"Lat Pushdown":                           Machine,
+	"Mid Grip Lat Pulldown":                  Machine,
+	"Single Arm Cable Rowing":                Machine,
+	"Reverse Cable Crossovers":               Machine,
+	"Cable Lateral Raises":                   Machine,
+	"Rope Face Pulls":                        Machine,
+	"Cable Crunches":                         Machine,
+	"Cable Rope Pushdown":                    Machine,
+	"Tricep Cable Kickbacks":                 Machine,
+	"Straight Bar Pushdown":                  Machine,
+	"Overhead Rope Extensions":               Machine,
+}
+
+// WorkoutBlocks returns the plan for a given day and week (1 or 2).
+func WorkoutBlocks(day string, week int) []Block {
 	plan, ok := WorkoutPlans[day]
 	if !ok {
 		return nil
@@ -134,3 +266,57 @@ func WorkoutDays(day string, week int) []string {
 	}
 	return plan.Week1
 }
+
+// WorkoutDays returns the exercise list for a given day and week (1 or 2)
+func WorkoutDays(day string, week int) []string {
+	var exs []string
+	for _, b := range WorkoutBlocks(day, week) {
+		exs = append(exs, b...)
+	}
+	return exs
+}
+
+// GroupOf returns the superset or circuit exercise is part of, or nil if it
+// is done on its own.
+func GroupOf(exercise string) Block {
+	for _, plan := range WorkoutPlans {
+		for _, b := range append(append([]Block{}, plan.Week1...), plan.Week2...) {
+			if len(b) > 1 && slices.Contains(b, exercise) {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// StepLabels numbers the exercises of blocks in order, lettering the ones
+// in a group: 1, 2, 3a, 3b.
+func StepLabels(blocks []Block) []string {
+	var labels []string
+	for i, b := range blocks {
+		for j := range b {
+			l := strconv.Itoa(i + 1)
+			if len(b) > 1 {
+				l += string(rune('a' + j))
+			}
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// AllExercises returns every exercise in the program, sorted by name.
+func AllExercises() []string {
+	seen := map[string]bool{}
+	var all []string
+	for day := range WorkoutPlans {
+		for _, ex := range append(WorkoutDays(day, 1), WorkoutDays(day, 2)...) {
+			if !seen[ex] {
+				seen[ex] = true
+				all = append(all, ex)
+			}
+		}
+	}
+	sort.Strings(all)
+	return all
+}
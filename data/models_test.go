@@ -0,0 +1,56 @@
+package data_test
+
+import (
+	"slices"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestRestSecondsNameProgramExercises(t *testing.T) {
+	all := data.AllExercises()
+	for ex := range data.RestSeconds {
+		if !slices.Contains(all, ex) {
+			t.Errorf("RestSeconds has %q, which is not in the program", ex)
+		}
+	}
+}
+
+func TestWarmupExercisesNameProgramExercises(t *testing.T) {
+	all := data.AllExercises()
+	for _, ex := range data.WarmupExercises {
+		if !slices.Contains(all, ex) {
+			t.Errorf("WarmupExercises has %q, which is not in the program", ex)
+		}
+	}
+}
+
+func TestExerciseEquipmentNamesProgramExercises(t *testing.T) {
+	all := data.AllExercises()
+	for ex := range data.ExerciseEquipment {
+		if !slices.Contains(all, ex) {
+			t.Errorf("ExerciseEquipment has %q, which is not in the program", ex)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	blocks := data.WorkoutBlocks("Thursday", 1)
+	if len(blocks) == 0 || blocks[0].Kind() != "superset" {
+		t.Fatalf("Thursday starts with %v, want a superset", blocks)
+	}
+	if got := data.GroupOf("Cable Rope Pushdown"); !slices.Equal(got, data.Block{"Standing Alternate Hammer Curls", "Cable Rope Pushdown"}) {
+		t.Errorf("GroupOf(Cable Rope Pushdown) = %v", got)
+	}
+	if got := data.GroupOf("Barbell Squats"); got != nil {
+		t.Errorf("GroupOf(Barbell Squats) = %v, want nil for an exercise done alone", got)
+	}
+	if got := (data.Block{"A", "B", "C"}).Kind(); got != "circuit" {
+		t.Errorf("Kind of three exercises = %q", got)
+	}
+
+	got := data.StepLabels([]data.Block{{"A"}, {"B", "C"}, {"D"}})
+	if want := []string{"1", "2a", "2b", "3"}; !slices.Equal(got, want) {
+		t.Errorf("StepLabels = %v, want %v", got, want)
+	}
+}
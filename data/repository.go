@@ -1,5 +1,10 @@
 package data
 
+import (
+	"context"
+	"io"
+)
+
 type Repository struct {
 	db *DB
 }
@@ -8,30 +13,109 @@ func NewRepository(db *DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Save(e *Entry) error {
-	return r.db.InsertEntry(e)
+func (r *Repository) Save(ctx context.Context, e *Entry) error {
+	return r.db.InsertEntry(ctx, e)
+}
+
+func (r *Repository) SaveAll(ctx context.Context, entries []*Entry) error {
+	return r.db.InsertEntries(ctx, entries)
+}
+
+func (r *Repository) Get(ctx context.Context, id int64) (*Entry, error) {
+	return r.db.GetEntry(ctx, id)
+}
+
+func (r *Repository) Update(ctx context.Context, e *Entry) error {
+	return r.db.UpdateEntry(ctx, e)
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	return r.db.DeleteEntry(ctx, id)
+}
+
+func (r *Repository) HistoryFor(ctx context.Context, exercise string) ([]*Entry, error) {
+	return r.db.GetEntriesByExercise(ctx, exercise)
+}
+
+func (r *Repository) DailyTotals(ctx context.Context, exercise string) ([]DailyTotal, error) {
+	return r.db.GetDailyTotals(ctx, exercise)
+}
+
+func (r *Repository) All(ctx context.Context) ([]*Entry, error) {
+	return r.db.GetAllEntries(ctx)
+}
+
+func (r *Repository) PersonalBest(ctx context.Context, exercise string) (*PersonalBest, error) {
+	return r.db.GetPersonalBest(ctx, exercise)
+}
+
+func (r *Repository) LastEntry(ctx context.Context, exercise string) (*Entry, error) {
+	return r.db.GetLastEntry(ctx, exercise)
+}
+
+func (r *Repository) GetCurrentWeek(ctx context.Context) (int, error) {
+	return r.db.GetCurrentWeek(ctx)
+}
+
+func (r *Repository) SetCurrentWeek(ctx context.Context, week int) error {
+	return r.db.SetCurrentWeek(ctx, week)
+}
+
+func (r *Repository) Setting(ctx context.Context, key string) (string, bool, error) {
+	return r.db.GetSetting(ctx, key)
+}
+
+func (r *Repository) SetSetting(ctx context.Context, key, value string) error {
+	return r.db.SetSetting(ctx, key, value)
+}
+
+func (r *Repository) Aliases(ctx context.Context, source string) (map[string]string, error) {
+	return r.db.GetAliases(ctx, source)
+}
+
+func (r *Repository) SetAlias(ctx context.Context, source, name, exercise string) error {
+	return r.db.SetAlias(ctx, source, name, exercise)
+}
+
+func (r *Repository) Goals(ctx context.Context) ([]*Goal, error) {
+	return r.db.GetGoals(ctx)
+}
+
+func (r *Repository) SaveGoal(ctx context.Context, g *Goal) error {
+	return r.db.InsertGoal(ctx, g)
 }
 
-func (r *Repository) HistoryFor(exercise string) ([]*Entry, error) {
-	return r.db.GetEntriesByExercise(exercise)
+func (r *Repository) UpdateGoal(ctx context.Context, g *Goal) error {
+	return r.db.UpdateGoal(ctx, g)
 }
 
-func (r *Repository) All() ([]*Entry, error) {
-	return r.db.GetAllEntries()
+func (r *Repository) DeleteGoal(ctx context.Context, id int64) error {
+	return r.db.DeleteGoal(ctx, id)
 }
 
-func (r *Repository) PersonalBest(exercise string) (*PersonalBest, error) {
-	return r.db.GetPersonalBest(exercise)
+func (r *Repository) Bodyweights(ctx context.Context) ([]Bodyweight, error) {
+	return r.db.GetBodyweights(ctx)
 }
 
-func (r *Repository) LastEntry(exercise string) (*Entry, error) {
-	return r.db.GetLastEntry(exercise)
+func (r *Repository) SetBodyweight(ctx context.Context, b Bodyweight) error {
+	return r.db.SetBodyweight(ctx, b)
 }
 
-func (r *Repository) GetCurrentWeek() int {
-	return r.db.GetCurrentWeek()
+// Backup writes a full JSON archive of the database to w.
+func (r *Repository) Backup(ctx context.Context, w io.Writer) error {
+	a, err := r.db.ExportArchive(ctx)
+	if err != nil {
+		return err
+	}
+	return WriteArchive(w, a)
 }
 
-func (r *Repository) SetCurrentWeek(week int) error {
-	return r.db.SetCurrentWeek(week)
+// Restore reads an archive written by Backup (or an older version of it)
+// and loads it using mode.
+func (r *Repository) Restore(ctx context.Context, rd io.Reader, mode RestoreMode) (*RestoreReport, error) {
+	a, err := ReadArchive(rd)
+	if err != nil {
+		return nil, err
+	}
+	return r.db.RestoreArchive(ctx, a, mode)
 }
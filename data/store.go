@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"io"
+)
+
+// Store is the storage the rest of the app works against. Repository keeps
+// everything in SQLite and MemoryStore keeps it in memory; every
+// implementation has to pass the conformance suite in data/storetest.
+//
+// Lookups by id return an error wrapping ErrNotFound when there is no such
+// record, and rejected values an error wrapping a *ValidationError.
+type Store interface {
+	Save(ctx context.Context, e *Entry) error
+	// SaveAll stores entries all together or not at all.
+	SaveAll(ctx context.Context, entries []*Entry) error
+	Get(ctx context.Context, id int64) (*Entry, error)
+	Update(ctx context.Context, e *Entry) error
+	Delete(ctx context.Context, id int64) error
+
+	// HistoryFor and All return entries newest first.
+	HistoryFor(ctx context.Context, exercise string) ([]*Entry, error)
+	All(ctx context.Context) ([]*Entry, error)
+	// DailyTotals returns one row per training day, oldest first. It,
+	// PersonalBest and LastEntry leave out warm-up sets.
+	DailyTotals(ctx context.Context, exercise string) ([]DailyTotal, error)
+	PersonalBest(ctx context.Context, exercise string) (*PersonalBest, error)
+	// LastEntry returns nil, with no error, for an exercise never logged.
+	LastEntry(ctx context.Context, exercise string) (*Entry, error)
+
+	GetCurrentWeek(ctx context.Context) (int, error)
+	SetCurrentWeek(ctx context.Context, week int) error
+	Setting(ctx context.Context, key string) (string, bool, error)
+	SetSetting(ctx context.Context, key, value string) error
+
+	Aliases(ctx context.Context, source string) (map[string]string, error)
+	SetAlias(ctx context.Context, source, name, exercise string) error
+
+	// Goals returns every goal, oldest first.
+	Goals(ctx context.Context) ([]*Goal, error)
+	SaveGoal(ctx context.Context, g *Goal) error
+	UpdateGoal(ctx context.Context, g *Goal) error
+	DeleteGoal(ctx context.Context, id int64) error
+	// Bodyweights returns the readings oldest first, one per day.
+	Bodyweights(ctx context.Context) ([]Bodyweight, error)
+	// SetBodyweight replaces the reading of b.Date; a weight of 0 removes it.
+	SetBodyweight(ctx context.Context, b Bodyweight) error
+
+	Backup(ctx context.Context, w io.Writer) error
+	Restore(ctx context.Context, r io.Reader, mode RestoreMode) (*RestoreReport, error)
+}
+
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,79 @@
+package data_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"progresstracker/data"
+	"progresstracker/data/storetest"
+)
+
+func TestRepository(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) data.Store {
+		db, err := data.NewDB(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return data.NewRepository(db)
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) data.Store {
+		return data.NewMemoryStore()
+	})
+}
+
+func TestOpenAddsWarmupColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	// the entries table as versions before warm-up sets created it
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			exercise TEXT NOT NULL,
+			weight REAL NOT NULL,
+			reps INTEGER NOT NULL,
+			sets INTEGER NOT NULL,
+			volume REAL NOT NULL,
+			notes TEXT,
+			date TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO entries (exercise, weight, reps, sets, volume, notes, date) VALUES ('Squat', 100, 5, 3, 1500, '', '2024-03-01');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := data.NewDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := data.NewRepository(db)
+	pb, err := repo.PersonalBest(t.Context(), "Squat")
+	if err != nil || pb.MaxWeight != 100 {
+		t.Fatalf("PersonalBest after upgrade = %v, %v", pb, err)
+	}
+	warm := &data.Entry{Exercise: "Squat", Weight: 120, Reps: 1, Sets: 1, Date: "2024-03-02", Warmup: true}
+	if err := repo.Save(t.Context(), warm); err != nil {
+		t.Fatal(err)
+	}
+	if pb, _ := repo.PersonalBest(t.Context(), "Squat"); pb.MaxWeight != 100 {
+		t.Errorf("a warm-up set changed the PB to %g", pb.MaxWeight)
+	}
+	hard := &data.Entry{Exercise: "Squat", Weight: 100, Reps: 5, Sets: 1, Date: "2024-03-03", RPE: 9.5}
+	if err := repo.Save(t.Context(), hard); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.Get(t.Context(), hard.ID); got == nil || got.RPE != 9.5 {
+		t.Errorf("RPE after upgrade read back as %v", got)
+	}
+}
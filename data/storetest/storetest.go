@@ -0,0 +1,711 @@
+// Package storetest is the conformance suite for data.Store implementations.
+// A backend's tests call Run with a constructor for empty stores:
+//
+//	func TestMemoryStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) data.Store { return data.NewMemoryStore() })
+//	}
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"progresstracker/data"
+)
+
+// Run checks that stores made by open behave the way the app relies on.
+// Every subtest gets a fresh, empty store.
+func Run(t *testing.T, open func(t *testing.T) data.Store) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, data.Store)
+	}{
+		{"SaveAndGet", testSaveAndGet},
+		{"SaveAll", testSaveAll},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"Ordering", testOrdering},
+		{"DailyTotals", testDailyTotals},
+		{"PersonalBest", testPersonalBest},
+		{"LastEntry", testLastEntry},
+		{"Warmups", testWarmups},
+		{"RPE", testRPE},
+		{"CurrentWeek", testCurrentWeek},
+		{"Settings", testSettings},
+		{"Aliases", testAliases},
+		{"Goals", testGoals},
+		{"Bodyweight", testBodyweight},
+		{"BackupRestoreReplace", testBackupRestoreReplace},
+		{"RestoreMerge", testRestoreMerge},
+		{"RestoreMergeRepeatedSets", testRestoreMergeRepeatedSets},
+		{"RestoreRejectsBadArchive", testRestoreRejectsBadArchive},
+		{"Canceled", testCanceled},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, open(t))
+		})
+	}
+}
+
+func entry(exercise string, weight float64, reps, sets int, date data.Date) *data.Entry {
+	return &data.Entry{Exercise: exercise, Weight: weight, Reps: reps, Sets: sets, Date: date}
+}
+
+func save(t *testing.T, s data.Store, entries ...*data.Entry) {
+	t.Helper()
+	for _, e := range entries {
+		if err := s.Save(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// describe renders the fields a store must keep, for comparison.
+func describe(entries ...*data.Entry) string {
+	var parts []string
+	for _, e := range entries {
+		if e == nil {
+			parts = append(parts, "<nil>")
+			continue
+		}
+		p := fmt.Sprintf("%d %s %s %g×%d×%d=%g %q", e.ID, e.Date, e.Exercise, e.Weight, e.Reps, e.Sets, e.Volume, e.Notes)
+		if e.Warmup {
+			p += " warm-up"
+		}
+		if e.RPE > 0 {
+			p += fmt.Sprintf(" @%g", e.RPE)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func check(t *testing.T, what, got, want string) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s:\n got %s\nwant %s", what, got, want)
+	}
+}
+
+func testSaveAndGet(t *testing.T, s data.Store) {
+	e := entry("Squat", 100, 5, 3, "2024-03-01")
+	e.Notes = "felt good"
+	save(t, s, e)
+	if e.ID == 0 {
+		t.Fatal("Save did not set an id")
+	}
+	if e.Volume != 1500 {
+		t.Errorf("Save set volume %g, want 1500", e.Volume)
+	}
+	if e.CreatedAt.IsZero() {
+		t.Error("Save did not set created_at")
+	}
+
+	got, err := s.Get(t.Context(), e.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "Get", describe(got), describe(e))
+	if got != nil && got.CreatedAt.IsZero() {
+		t.Error("Get lost created_at")
+	}
+
+	// the caller's copy is not the stored one
+	got.Weight = 1
+	again, _ := s.Get(t.Context(), e.ID)
+	if again.Weight != 100 {
+		t.Error("changing a returned entry changed the store")
+	}
+
+	missing, err := s.Get(t.Context(), e.ID+1000)
+	if !errors.Is(err, data.ErrNotFound) || missing != nil {
+		t.Errorf("Get(missing) = %v, %v; want nil, ErrNotFound", missing, err)
+	}
+}
+
+func testSaveAll(t *testing.T, s data.Store) {
+	batch := []*data.Entry{
+		entry("Squat", 100, 5, 3, "2024-03-01"),
+		entry("Bench", 80, 5, 3, "2024-03-01"),
+	}
+	if err := s.SaveAll(t.Context(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].ID == 0 || batch[1].ID == 0 || batch[0].ID == batch[1].ID {
+		t.Fatalf("SaveAll ids %d and %d", batch[0].ID, batch[1].ID)
+	}
+	if batch[1].Volume != 1200 {
+		t.Errorf("SaveAll set volume %g, want 1200", batch[1].Volume)
+	}
+	all, err := s.All(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("All returned %d entries after SaveAll, want 2", len(all))
+	}
+}
+
+func testUpdate(t *testing.T, s data.Store) {
+	e := entry("Squat", 100, 5, 3, "2024-03-01")
+	save(t, s, e)
+	created := e.CreatedAt
+
+	upd := entry("Front Squat", 90, 6, 2, "2024-03-02")
+	upd.ID = e.ID
+	upd.Notes = "changed"
+	if err := s.Update(t.Context(), upd); err != nil {
+		t.Fatal(err)
+	}
+	if upd.Volume != 1080 {
+		t.Errorf("Update set volume %g, want 1080", upd.Volume)
+	}
+	got, _ := s.Get(t.Context(), e.ID)
+	check(t, "after Update", describe(got), describe(upd))
+	if got != nil && !got.CreatedAt.Equal(created) {
+		t.Errorf("Update changed created_at from %v to %v", created, got.CreatedAt)
+	}
+
+	upd.ID += 1000
+	if err := s.Update(t.Context(), upd); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("Update(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func testDelete(t *testing.T, s data.Store) {
+	e := entry("Squat", 100, 5, 3, "2024-03-01")
+	save(t, s, e)
+	if err := s.Delete(t.Context(), e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(t.Context(), e.ID); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(t.Context(), e.ID); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func testOrdering(t *testing.T, s data.Store) {
+	a := entry("Squat", 100, 5, 3, "2024-03-01")
+	b := entry("Squat", 105, 5, 3, "2024-03-03")
+	c := entry("Bench", 80, 5, 3, "2024-03-02")
+	d := entry("Squat", 110, 3, 1, "2024-03-03") // same day as b, saved later
+	save(t, s, a, b, c, d)
+
+	h, err := s.HistoryFor(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "HistoryFor", describe(h...), describe(d, b, a))
+
+	all, err := s.All(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "All", describe(all...), describe(d, b, c, a))
+
+	if h, _ := s.HistoryFor(t.Context(), "Deadlift"); len(h) != 0 {
+		t.Errorf("HistoryFor(unknown) returned %d entries", len(h))
+	}
+}
+
+func testDailyTotals(t *testing.T, s data.Store) {
+	save(t, s,
+		entry("Squat", 100, 5, 3, "2024-03-02"),
+		entry("Squat", 110, 3, 1, "2024-03-02"),
+		entry("Squat", 90, 8, 2, "2024-03-01"),
+		entry("Bench", 200, 1, 1, "2024-03-01"),
+	)
+	totals, err := s.DailyTotals(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "DailyTotals", fmt.Sprint(totals), "[{2024-03-01 90 1440} {2024-03-02 110 1830}]")
+
+	if totals, _ := s.DailyTotals(t.Context(), "Deadlift"); len(totals) != 0 {
+		t.Errorf("DailyTotals(unknown) = %v", totals)
+	}
+}
+
+func testPersonalBest(t *testing.T, s data.Store) {
+	save(t, s,
+		entry("Squat", 120, 1, 1, "2024-03-01"),
+		entry("Squat", 100, 5, 3, "2024-03-02"),
+		entry("Bench", 150, 1, 1, "2024-03-02"),
+	)
+	pb, err := s.PersonalBest(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "PersonalBest", fmt.Sprintf("%s %g %g", pb.Exercise, pb.MaxWeight, pb.MaxVolume), "Squat 120 1500")
+
+	pb, err = s.PersonalBest(t.Context(), "Deadlift")
+	if err != nil || pb == nil {
+		t.Fatalf("PersonalBest(unknown) = %v, %v", pb, err)
+	}
+	check(t, "PersonalBest(unknown)", fmt.Sprintf("%s %g %g", pb.Exercise, pb.MaxWeight, pb.MaxVolume), "Deadlift 0 0")
+}
+
+func testLastEntry(t *testing.T, s data.Store) {
+	if e, err := s.LastEntry(t.Context(), "Squat"); err != nil || e != nil {
+		t.Errorf("LastEntry on empty store = %v, %v; want nil, nil", e, err)
+	}
+	newer := entry("Squat", 105, 5, 3, "2024-03-05")
+	save(t, s, newer, entry("Squat", 100, 5, 3, "2024-03-01"))
+	e, err := s.LastEntry(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "LastEntry", describe(e), describe(newer))
+}
+
+func testWarmups(t *testing.T, s data.Store) {
+	work := entry("Squat", 100, 5, 1, "2024-03-01")
+	warm := entry("Squat", 60, 20, 1, "2024-03-02")
+	warm.Warmup = true
+	save(t, s, work, warm)
+
+	if got, _ := s.Get(t.Context(), warm.ID); got == nil || !got.Warmup {
+		t.Fatalf("Get lost the warm-up flag: %s", describe(got))
+	}
+	if h, _ := s.HistoryFor(t.Context(), "Squat"); len(h) != 2 {
+		t.Errorf("HistoryFor left out warm-ups: %s", describe(h...))
+	}
+	if e, _ := s.LastEntry(t.Context(), "Squat"); e == nil || e.ID != work.ID {
+		t.Errorf("LastEntry = %s, want the working set", describe(e))
+	}
+	pb, err := s.PersonalBest(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "PersonalBest", fmt.Sprintf("%g %g", pb.MaxWeight, pb.MaxVolume), "100 500")
+	totals, err := s.DailyTotals(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "DailyTotals", fmt.Sprint(totals), "[{2024-03-01 100 500}]")
+
+	warm.Weight = 70
+	if err := s.Update(t.Context(), warm); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(t.Context(), warm.ID); got == nil || !got.Warmup {
+		t.Errorf("Update lost the warm-up flag: %s", describe(got))
+	}
+
+	if _, err := s.Restore(t.Context(), bytes.NewReader(backup(t, s)), data.RestoreReplace); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(t.Context(), warm.ID); got == nil || !got.Warmup {
+		t.Errorf("a backup lost the warm-up flag: %s", describe(got))
+	}
+}
+
+func testRPE(t *testing.T, s data.Store) {
+	e := entry("Squat", 100, 5, 1, "2024-03-01")
+	e.RPE = 8.5
+	save(t, s, e)
+	got, _ := s.Get(t.Context(), e.ID)
+	check(t, "Get", describe(got), describe(e))
+
+	e.RPE = 9
+	if err := s.Update(t.Context(), e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Restore(t.Context(), bytes.NewReader(backup(t, s)), data.RestoreReplace); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = s.Get(t.Context(), e.ID)
+	check(t, "Get after update and restore", describe(got), describe(e))
+}
+
+func week(t *testing.T, s data.Store) int {
+	t.Helper()
+	w, err := s.GetCurrentWeek(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func testCurrentWeek(t *testing.T, s data.Store) {
+	if w := week(t, s); w != 1 {
+		t.Errorf("new store is on week %d, want 1", w)
+	}
+	if v, ok, _ := s.Setting(t.Context(), "current_week"); !ok || v != "1" {
+		t.Errorf("current_week setting = %q, %v; want \"1\", true", v, ok)
+	}
+	if err := s.SetCurrentWeek(t.Context(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if w := week(t, s); w != 2 {
+		t.Errorf("week %d after SetCurrentWeek(2)", w)
+	}
+	err := s.SetCurrentWeek(t.Context(), 7)
+	var verr *data.ValidationError
+	if !errors.As(err, &verr) || verr.Reason("week") == "" {
+		t.Errorf("SetCurrentWeek(7) = %v, want a validation error for week", err)
+	}
+	if w := week(t, s); w != 2 {
+		t.Errorf("week %d after a rejected SetCurrentWeek, want 2", w)
+	}
+}
+
+func testSettings(t *testing.T, s data.Store) {
+	if v, ok, err := s.Setting(t.Context(), "missing"); err != nil || ok || v != "" {
+		t.Errorf("Setting(missing) = %q, %v, %v", v, ok, err)
+	}
+	for _, v := range []string{"a", "b"} {
+		if err := s.SetSetting(t.Context(), "key", v); err != nil {
+			t.Fatal(err)
+		}
+		if got, ok, err := s.Setting(t.Context(), "key"); err != nil || !ok || got != v {
+			t.Errorf("Setting after SetSetting(%q) = %q, %v, %v", v, got, ok, err)
+		}
+	}
+}
+
+func testAliases(t *testing.T, s data.Store) {
+	got, err := s.Aliases(t.Context(), "strong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("Aliases on empty store = %#v, want an empty map", got)
+	}
+	for _, al := range [][3]string{
+		{"strong", "Squat (Barbell)", "Squat"},
+		{"strong", "Bench Press (Barbell)", "Bench"},
+		{"hevy", "Squat (Barbell)", "Back Squat"},
+		{"strong", "Bench Press (Barbell)", ""}, // ignored from now on
+	} {
+		if err := s.SetAlias(t.Context(), al[0], al[1], al[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, _ = s.Aliases(t.Context(), "strong")
+	check(t, "Aliases", fmt.Sprint(got), "map[Bench Press (Barbell): Squat (Barbell):Squat]")
+}
+
+func describeGoals(goals []*data.Goal) string {
+	var parts []string
+	for _, g := range goals {
+		parts = append(parts, fmt.Sprintf("%d %s %q %g from %g by %q set %s achieved %q", g.ID, g.Metric, g.Exercise, g.Target, g.Start, g.By, g.SetOn, g.Achieved))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func testGoals(t *testing.T, s data.Store) {
+	ctx := t.Context()
+	if goals, err := s.Goals(ctx); err != nil || len(goals) != 0 {
+		t.Fatalf("Goals on empty store = %v, %v", goals, err)
+	}
+	squat := &data.Goal{Metric: data.GoalWeight, Exercise: "Squat", Target: 140, Start: 120, By: "2025-03-01", SetOn: "2024-09-01"}
+	bw := &data.Goal{Metric: data.GoalBodyweight, Target: 80, Start: 85, SetOn: "2024-09-02"}
+	for _, g := range []*data.Goal{squat, bw} {
+		if err := s.SaveGoal(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if squat.ID == 0 || bw.ID <= squat.ID {
+		t.Errorf("goal ids %d and %d, want increasing", squat.ID, bw.ID)
+	}
+	squat.Achieved = "2025-02-10"
+	if err := s.UpdateGoal(ctx, squat); err != nil {
+		t.Fatal(err)
+	}
+	goals, _ := s.Goals(ctx)
+	check(t, "Goals", describeGoals(goals), describeGoals([]*data.Goal{squat, bw}))
+
+	if err := s.DeleteGoal(ctx, bw.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteGoal(ctx, bw.ID); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("deleting a deleted goal = %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateGoal(ctx, bw); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("updating a deleted goal = %v, want ErrNotFound", err)
+	}
+
+	// a replace keeps ids, a merge skips goals already set
+	archive := backup(t, s)
+	if _, err := s.Restore(ctx, bytes.NewReader(archive), data.RestoreReplace); err != nil {
+		t.Fatal(err)
+	}
+	goals, _ = s.Goals(ctx)
+	check(t, "Goals after replace", describeGoals(goals), describeGoals([]*data.Goal{squat}))
+	if _, err := s.Restore(ctx, bytes.NewReader(archive), data.RestoreMerge); err != nil {
+		t.Fatal(err)
+	}
+	if goals, _ = s.Goals(ctx); len(goals) != 1 {
+		t.Errorf("%d goals after merging a backup of themselves, want 1", len(goals))
+	}
+	next := &data.Goal{Metric: data.GoalE1RM, Exercise: "Bench", Target: 100, SetOn: "2024-10-01"}
+	if err := s.SaveGoal(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	if next.ID <= squat.ID {
+		t.Errorf("new goal got id %d after restoring id %d", next.ID, squat.ID)
+	}
+}
+
+func testBodyweight(t *testing.T, s data.Store) {
+	ctx := t.Context()
+	for _, b := range []data.Bodyweight{{Date: "2024-03-02", Weight: 84.5}, {Date: "2024-03-01", Weight: 85}, {Date: "2024-03-02", Weight: 84.2}, {Date: "2024-03-03", Weight: 84}} {
+		if err := s.SetBodyweight(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a weight of 0 removes the day's reading
+	if err := s.SetBodyweight(ctx, data.Bodyweight{Date: "2024-03-03"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Bodyweights(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, "Bodyweights", fmt.Sprint(got), "[{2024-03-01 85} {2024-03-02 84.2}]")
+
+	archive := backup(t, s)
+	if err := s.SetBodyweight(ctx, data.Bodyweight{Date: "2024-03-02", Weight: 84}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetBodyweight(ctx, data.Bodyweight{Date: "2024-03-01"}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Restore(ctx, bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].Kind != "bodyweight" || report.Conflicts[0].Local != "84kg" {
+		t.Errorf("conflicts = %+v, want the local 84kg against the archived reading", report.Conflicts)
+	}
+	got, _ = s.Bodyweights(ctx)
+	check(t, "Bodyweights after merge", fmt.Sprint(got), "[{2024-03-01 85} {2024-03-02 84}]")
+}
+
+// fill gives s one of everything a backup carries.
+func fill(t *testing.T, s data.Store) []*data.Entry {
+	t.Helper()
+	entries := []*data.Entry{
+		entry("Squat", 100, 5, 3, "2024-03-01"),
+		entry("Bench", 80, 5, 3, "2024-03-02"),
+	}
+	entries[1].Notes = "paused"
+	save(t, s, entries...)
+	if err := s.SetCurrentWeek(t.Context(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSetting(t.Context(), "unit", "kg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(t.Context(), "strong", "Squat (Barbell)", "Squat"); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func backup(t *testing.T, s data.Store) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.Backup(t.Context(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testBackupRestoreReplace(t *testing.T, s data.Store) {
+	want := fill(t, s)
+	archive := backup(t, s)
+
+	// replace throws away whatever was there, settings included
+	save(t, s, entry("Deadlift", 180, 1, 1, "2024-04-01"))
+	if err := s.SetSetting(t.Context(), "unit", "lb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSetting(t.Context(), "extra", "x"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := s.Restore(t.Context(), bytes.NewReader(archive), data.RestoreReplace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 2 || report.EntriesSkipped != 0 || len(report.Conflicts) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	all, _ := s.All(t.Context())
+	check(t, "entries after replace", describe(all...), describe(want[1], want[0]))
+	if v, _, _ := s.Setting(t.Context(), "unit"); v != "kg" {
+		t.Errorf("unit = %q after replace, want kg", v)
+	}
+	if _, ok, _ := s.Setting(t.Context(), "extra"); ok {
+		t.Error("replace kept a setting the archive did not have")
+	}
+	if w := week(t, s); w != 2 {
+		t.Errorf("week %d after replace, want 2", w)
+	}
+	aliases, _ := s.Aliases(t.Context(), "strong")
+	check(t, "aliases after replace", fmt.Sprint(aliases), "map[Squat (Barbell):Squat]")
+
+	// new entries never reuse a restored id
+	e := entry("Squat", 1, 1, 1, "2024-05-01")
+	save(t, s, e)
+	if e.ID <= want[1].ID {
+		t.Errorf("new entry got id %d, restored ids go up to %d", e.ID, want[1].ID)
+	}
+}
+
+func testRestoreMerge(t *testing.T, s data.Store) {
+	fill(t, s)
+	archive := backup(t, s)
+
+	// diverge locally: different notes, setting and alias, plus a new entry
+	all, _ := s.All(t.Context())
+	for _, e := range all {
+		if e.Exercise == "Bench" {
+			e.Notes = "touch and go"
+			if err := s.Update(t.Context(), e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := s.SetSetting(t.Context(), "unit", "lb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetAlias(t.Context(), "strong", "Squat (Barbell)", "Front Squat"); err != nil {
+		t.Fatal(err)
+	}
+	local := entry("Deadlift", 180, 1, 1, "2024-04-01")
+	save(t, s, local)
+
+	report, err := s.Restore(t.Context(), bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 0 || report.EntriesSkipped != 2 {
+		t.Errorf("added %d, skipped %d; want 0 and 2", report.EntriesAdded, report.EntriesSkipped)
+	}
+	var conflicts []string
+	for _, c := range report.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%s %s: %s/%s", c.Kind, c.Key, c.Local, c.Incoming))
+	}
+	check(t, "conflicts", strings.Join(conflicts, "; "),
+		"setting unit: lb/kg; entry 2024-03-02 Bench 80kg×5×3: touch and go/paused; alias strong: Squat (Barbell): Front Squat/Squat")
+
+	if v, _, _ := s.Setting(t.Context(), "unit"); v != "lb" {
+		t.Errorf("merge overwrote the local unit with %q", v)
+	}
+	if got, _ := s.Get(t.Context(), local.ID); got == nil {
+		t.Error("merge dropped a local entry")
+	}
+
+	// entries missing locally are added, with fresh ids
+	for _, e := range all {
+		if err := s.Delete(t.Context(), e.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report, err = s.Restore(t.Context(), bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 2 {
+		t.Errorf("added %d after deleting, want 2", report.EntriesAdded)
+	}
+	after, _ := s.All(t.Context())
+	if len(after) != 3 {
+		t.Errorf("%d entries after merge, want 3", len(after))
+	}
+	for _, e := range after {
+		if e.Exercise != "Deadlift" && e.ID <= local.ID {
+			t.Errorf("merged %s reused id %d", e.Exercise, e.ID)
+		}
+	}
+}
+
+// A session logs each set as its own identical entry; a merge must keep
+// them all rather than match them against each other.
+func testRestoreMergeRepeatedSets(t *testing.T, s data.Store) {
+	ctx := t.Context()
+	for range 3 {
+		save(t, s, entry("Squat", 80, 8, 1, "2024-03-01"))
+	}
+	archive := backup(t, s)
+	deleteAll := func(keep int) {
+		all, _ := s.All(ctx)
+		for _, e := range all[keep:] {
+			if err := s.Delete(ctx, e.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deleteAll(0)
+	report, err := s.Restore(ctx, bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 3 || report.EntriesSkipped != 0 {
+		t.Errorf("added %d, skipped %d into an empty store; want 3 and 0", report.EntriesAdded, report.EntriesSkipped)
+	}
+	deleteAll(1)
+	report, err = s.Restore(ctx, bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 2 || report.EntriesSkipped != 1 {
+		t.Errorf("added %d, skipped %d with one set stored; want 2 and 1", report.EntriesAdded, report.EntriesSkipped)
+	}
+	report, err = s.Restore(ctx, bytes.NewReader(archive), data.RestoreMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.EntriesAdded != 0 || report.EntriesSkipped != 3 {
+		t.Errorf("added %d, skipped %d on a second merge; want 0 and 3", report.EntriesAdded, report.EntriesSkipped)
+	}
+	if after, _ := s.All(ctx); len(after) != 3 {
+		t.Errorf("%d entries after merging, want 3", len(after))
+	}
+}
+
+func testRestoreRejectsBadArchive(t *testing.T, s data.Store) {
+	fill(t, s)
+	for _, bad := range []string{
+		`not json`,
+		`{"format":"something-else","version":1}`,
+		`{"format":"progresstracker-archive","version":99}`,
+		`{"format":"progresstracker-archive","version":2,"entries":[{"exercise":"","date":"2024-01-01","reps":1,"sets":1}]}`,
+	} {
+		if _, err := s.Restore(t.Context(), strings.NewReader(bad), data.RestoreReplace); err == nil {
+			t.Errorf("Restore accepted %s", bad)
+		}
+	}
+	if all, _ := s.All(t.Context()); len(all) != 2 {
+		t.Errorf("%d entries left after rejected restores, want 2", len(all))
+	}
+}
+
+func testCanceled(t *testing.T, s data.Store) {
+	fill(t, s)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if err := s.Save(ctx, entry("Squat", 1, 1, 1, "2024-05-01")); !errors.Is(err, context.Canceled) {
+		t.Errorf("Save with a canceled context = %v", err)
+	}
+	if _, err := s.All(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("All with a canceled context = %v", err)
+	}
+	if _, err := s.GetCurrentWeek(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetCurrentWeek with a canceled context = %v", err)
+	}
+	if all, _ := s.All(t.Context()); len(all) != 2 {
+		t.Errorf("%d entries after a canceled Save, want 2", len(all))
+	}
+}
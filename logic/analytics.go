@@ -1,57 +1,45 @@
 package logic
 
 import (
+	"context"
 	"progresstracker/data"
-	"sort"
 )
 
 type ChartPoint struct {
-	Date   string
-	Value  float64
+	Date   data.Date `json:"date"`
+	Value  float64 `json:"value"`
 }
 
 type Analytics struct {
-	repo *data.Repository
+	repo data.Store
 }
 
-func NewAnalytics(repo *data.Repository) *Analytics {
+func NewAnalytics(repo data.Store) *Analytics {
 	return &Analytics{repo: repo}
 }
 
-func (a *Analytics) WeightOverTime(exercise string) ([]ChartPoint, error) {
-	entries, err := a.repo.HistoryFor(exercise)
+// WeightOverTime returns the heaviest weight lifted on each training day.
+func (a *Analytics) WeightOverTime(ctx context.Context, exercise string) ([]ChartPoint, error) {
+	totals, err := a.repo.DailyTotals(ctx, exercise)
 	if err != nil {
 		return nil, err
 	}
-	// deduplicate by date, take max weight per date
-	byDate := map[string]float64{}
-	for _, e := range entries {
-		if e.Weight > byDate[e.Date] {
-			byDate[e.Date] = e.Weight
-		}
+	pts := make([]ChartPoint, len(totals))
+	for i, t := range totals {
+		pts[i] = ChartPoint{Date: t.Date, Value: t.MaxWeight}
 	}
-	return sortedPoints(byDate), nil
+	return pts, nil
 }
 
-func (a *Analytics) VolumeOverTime(exercise string) ([]ChartPoint, error) {
-	entries, err := a.repo.HistoryFor(exercise)
+// VolumeOverTime returns the total volume of each training day.
+func (a *Analytics) VolumeOverTime(ctx context.Context, exercise string) ([]ChartPoint, error) {
+	totals, err := a.repo.DailyTotals(ctx, exercise)
 	if err != nil {
 		return nil, err
 	}
-	byDate := map[string]float64{}
-	for _, e := range entries {
-		byDate[e.Date] += e.Volume
+	pts := make([]ChartPoint, len(totals))
+	for i, t := range totals {
+		pts[i] = ChartPoint{Date: t.Date, Value: t.Volume}
 	}
-	return sortedPoints(byDate), nil
-}
-
-func sortedPoints(m map[string]float64) []ChartPoint {
-	var pts []ChartPoint
-	for k, v := range m {
-		pts = append(pts, ChartPoint{Date: k, Value: v})
-	}
-	sort.Slice(pts, func(i, j int) bool {
-		return pts[i].Date < pts[j].Date
-	})
-	return pts
+	return pts, nil
 }
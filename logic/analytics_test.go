@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestChartsAggregatePerDay(t *testing.T) {
+	repo := data.NewMemoryStore()
+	for _, e := range []*data.Entry{
+		{Exercise: "Squat", Weight: 100, Reps: 5, Sets: 3, Date: "2024-01-02"},
+		{Exercise: "Squat", Weight: 110, Reps: 3, Sets: 1, Date: "2024-01-02"},
+		{Exercise: "Squat", Weight: 90, Reps: 8, Sets: 2, Date: "2024-01-01"},
+		{Exercise: "Bench", Weight: 200, Reps: 1, Sets: 1, Date: "2024-01-01"},
+	} {
+		if err := repo.Save(t.Context(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := NewAnalytics(repo)
+	weight, err := a.WeightOverTime(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume, err := a.VolumeOverTime(t.Context(), "Squat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(weight, volume)
+	want := "[{2024-01-01 90} {2024-01-02 110}] [{2024-01-01 1440} {2024-01-02 1830}]"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,408 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"progresstracker/data"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	SourceStrong   = "strong"
+	SourceHevy     = "hevy"
+	SourceFitNotes = "fitnotes"
+
+	maxSuggestions = 5
+)
+
+var AppSources = []string{SourceStrong, SourceHevy, SourceFitNotes}
+
+// appSet is one set read from another app's export, before exercise names
+// are mapped. Numbers stay as text so they go through parseEntry.
+type appSet struct {
+	line     int
+	date     string
+	name     string
+	weight   string
+	reps     string
+	rpe      string
+	notes    string
+	unit     string
+	skipped  bool // warm-up sets, which we do not track
+	parseErr string
+}
+
+type UnknownExercise struct {
+	Name        string
+	Sets        int
+	Suggestions []string
+}
+
+// AppImport is a parsed export waiting for its exercise names to be mapped.
+// Call MapExercise for every Unknown name, then ImportApp.
+type AppImport struct {
+	Source  string
+	Unknown []UnknownExercise
+	sets    []appSet
+}
+
+type appFormat struct {
+	// columns maps our fields to the header names used by the app; the first
+	// one present wins
+	columns   map[string][]string
+	dates     []string
+	weightLbs []string // weight headers that imply pounds
+}
+
+var appFormats = map[string]appFormat{
+	SourceStrong: {
+		columns: map[string][]string{
+			"date":   {"Date"},
+			"name":   {"Exercise Name"},
+			"weight": {"Weight"},
+			"reps":   {"Reps"},
+			"rpe":    {"RPE"},
+			"notes":  {"Notes"},
+			"type":   {"Set Order"}, // "W" marks warm-ups
+		},
+		dates: []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"},
+	},
+	SourceHevy: {
+		columns: map[string][]string{
+			"date":   {"start_time"},
+			"name":   {"exercise_title"},
+			"weight": {"weight_kg", "weight_lbs"},
+			"reps":   {"reps"},
+			"rpe":    {"rpe"},
+			"notes":  {"exercise_notes"},
+			"type":   {"set_type"},
+		},
+		dates:     []string{"2 Jan 2006, 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00"},
+		weightLbs: []string{"weight_lbs"},
+	},
+	SourceFitNotes: {
+		columns: map[string][]string{
+			"date":   {"Date"},
+			"name":   {"Exercise"},
+			"weight": {"Weight (kgs)", "Weight (kg)", "Weight (lbs)", "Weight"},
+			"reps":   {"Reps"},
+			"notes":  {"Comment"},
+		},
+		dates:     []string{"2006-01-02"},
+		weightLbs: []string{"Weight (lbs)"},
+	},
+}
+
+// ReadAppExport parses a CSV export from Strong, Hevy or FitNotes. unit is the
+// weight unit for formats that do not record it (Strong); "" means kg.
+// Names that neither match one of our exercises nor have a remembered
+// mapping are listed in Unknown with suggestions.
+func (t *Tracker) ReadAppExport(ctx context.Context, r io.Reader, source, unit string) (*AppImport, error) {
+	format, ok := appFormats[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source %q, want one of %s", source, strings.Join(AppSources, ", "))
+	}
+	defUnit, err := normalizeUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(bytes.NewReader(raw))
+	cr.FieldsPerRecord = -1
+	// Strong writes semicolons in locales that use a decimal comma
+	if first, _, _ := bytes.Cut(raw, []byte("\n")); bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		cr.Comma = ';'
+	}
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty export file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols, weightHeader := appColumns(header, format)
+	for _, required := range []string{"date", "name", "weight", "reps"} {
+		if cols[required] < 0 {
+			return nil, fmt.Errorf("this does not look like a %s export: no %s column", source, required)
+		}
+	}
+	for _, h := range format.weightLbs {
+		if h == weightHeader {
+			defUnit = UnitLb
+		}
+	}
+
+	imp := &AppImport{Source: source}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		get := func(field string) string {
+			i := cols[field]
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		s := appSet{
+			line:   line,
+			name:   get("name"),
+			weight: strings.Replace(get("weight"), ",", ".", 1),
+			reps:   get("reps"),
+			rpe:    get("rpe"),
+			notes:  get("notes"),
+			unit:   defUnit,
+		}
+		if s.weight == "" {
+			s.weight = "0" // bodyweight movements
+		}
+		if typ := strings.ToLower(get("type")); typ == "warmup" || typ == "w" {
+			s.skipped = true
+		}
+		if d, ok := parseAppDate(get("date"), format.dates); ok {
+			s.date = d
+		} else {
+			s.parseErr = fmt.Sprintf("unrecognised date %q", get("date"))
+		}
+		imp.sets = append(imp.sets, s)
+	}
+	if err := t.resolveUnknown(ctx, imp); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+func appColumns(header []string, format appFormat) (map[string]int, string) {
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")] = i
+	}
+	cols := map[string]int{"date": -1, "name": -1, "weight": -1, "reps": -1, "rpe": -1, "notes": -1, "type": -1}
+	var weightHeader string
+	for field, names := range format.columns {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				cols[field] = i
+				if field == "weight" {
+					weightHeader = name
+				}
+				break
+			}
+		}
+	}
+	return cols, weightHeader
+}
+
+func parseAppDate(s string, layouts []string) (string, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(data.DateLayout), true
+		}
+	}
+	return "", false
+}
+
+// resolveUnknown rebuilds imp.Unknown from the names that still have no
+// mapping.
+func (t *Tracker) resolveUnknown(ctx context.Context, imp *AppImport) error {
+	aliases, err := t.repo.Aliases(ctx, imp.Source)
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, s := range imp.sets {
+		if _, ok := matchExercise(s.name, aliases); !ok && s.name != "" {
+			counts[s.name]++
+		}
+	}
+	imp.Unknown = imp.Unknown[:0]
+	for name, n := range counts {
+		imp.Unknown = append(imp.Unknown, UnknownExercise{Name: name, Sets: n, Suggestions: SuggestExercises(name)})
+	}
+	sort.Slice(imp.Unknown, func(i, j int) bool { return imp.Unknown[i].Name < imp.Unknown[j].Name })
+	return nil
+}
+
+// MapExercise remembers that name in exports from source means exercise.
+// An empty exercise ignores the name in this and future imports.
+func (t *Tracker) MapExercise(ctx context.Context, source, name, exercise string) error {
+	if exercise != "" && !IsKnownExercise(exercise) {
+		return data.Invalid("exercise", fmt.Sprintf("unknown exercise %q", exercise))
+	}
+	return t.repo.SetAlias(ctx, source, name, exercise)
+}
+
+// ImportApp converts the parsed sets into entries. Consecutive sets of the
+// same exercise with the same weight and reps on the same day become one
+// entry with a set count. Each entry goes through the same validation as
+// AddEntry and the same duplicate check as ImportCSV.
+func (t *Tracker) ImportApp(ctx context.Context, imp *AppImport, dryRun bool) (*ImportResult, error) {
+	if err := t.resolveUnknown(ctx, imp); err != nil {
+		return nil, err
+	}
+	aliases, err := t.repo.Aliases(ctx, imp.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		first appSet
+		ex    string
+		sets  int
+	}
+	var groups []*group
+	res := &ImportResult{Rows: len(imp.sets)}
+	for _, s := range imp.sets {
+		if s.skipped {
+			res.Ignored++
+			continue
+		}
+		if s.parseErr != "" {
+			res.Invalid = append(res.Invalid, ImportError{Line: s.line, Err: s.parseErr})
+			continue
+		}
+		ex, ok := matchExercise(s.name, aliases)
+		if !ok {
+			res.Invalid = append(res.Invalid, ImportError{Line: s.line, Err: fmt.Sprintf("unmapped exercise %q", s.name)})
+			continue
+		}
+		if ex == "" {
+			res.Ignored++
+			continue
+		}
+		if n := len(groups); n > 0 {
+			g := groups[n-1]
+			if g.ex == ex && g.first.date == s.date && g.first.weight == s.weight && g.first.reps == s.reps && g.first.rpe == s.rpe && g.first.unit == s.unit {
+				g.sets++
+				continue
+			}
+		}
+		groups = append(groups, &group{first: s, ex: ex, sets: 1})
+	}
+
+	seen, err := t.existingKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		s := g.first
+		e, err := parseEntry(g.ex, s.weight, s.reps, strconv.Itoa(g.sets), s.rpe, s.notes, s.date, s.unit)
+		if err != nil {
+			res.Invalid = append(res.Invalid, ImportError{Line: s.line, Err: err.Error()})
+			continue
+		}
+		key := entryKey(e)
+		if seen[key] > 0 {
+			seen[key]--
+			res.Duplicates++
+			continue
+		}
+		res.Entries = append(res.Entries, e)
+	}
+	sort.SliceStable(res.Invalid, func(i, j int) bool { return res.Invalid[i].Line < res.Invalid[j].Line })
+	if dryRun || len(res.Entries) == 0 {
+		return res, nil
+	}
+	if err := t.repo.SaveAll(ctx, res.Entries); err != nil {
+		return nil, err
+	}
+	res.Imported = len(res.Entries)
+	return res, nil
+}
+
+// matchExercise maps an app's exercise name to ours: a remembered alias
+// first, then a name with the same words as one of our exercises.
+func matchExercise(name string, aliases map[string]string) (string, bool) {
+	if ex, ok := aliases[name]; ok {
+		return ex, true
+	}
+	key := strings.Join(sortedWords(name), " ")
+	for _, ex := range data.AllExercises() {
+		if strings.Join(sortedWords(ex), " ") == key {
+			return ex, true
+		}
+	}
+	return "", false
+}
+
+// IsKnownExercise reports whether name is an exercise in the program.
+func IsKnownExercise(name string) bool {
+	for _, ex := range data.AllExercises() {
+		if ex == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestExercises ranks our exercises by how many words they share with
+// name and returns the best few.
+func SuggestExercises(name string) []string {
+	words := map[string]bool{}
+	for _, w := range sortedWords(name) {
+		words[w] = true
+	}
+	type scored struct {
+		ex    string
+		score float64
+	}
+	var all []scored
+	for _, ex := range data.AllExercises() {
+		exWords := sortedWords(ex)
+		shared := 0
+		for _, w := range exWords {
+			if words[w] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		// Jaccard similarity of the two word sets
+		all = append(all, scored{ex, float64(shared) / float64(len(words)+len(exWords)-shared)})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+	var out []string
+	for i := 0; i < len(all) && i < maxSuggestions; i++ {
+		out = append(out, all[i].ex)
+	}
+	return out
+}
+
+// sortedWords lowercases name, drops punctuation and plural s, and returns
+// the distinct words sorted, so "Squat (Barbell)" and "Barbell Squats"
+// compare equal.
+func sortedWords(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range fields {
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		if !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func describeEntries(entries []*data.Entry) string {
+	var parts []string
+	for _, e := range entries {
+		p := fmt.Sprintf("%s %s %g×%d×%d", e.Date, e.Exercise, e.Weight, e.Reps, e.Sets)
+		if e.RPE > 0 {
+			p += fmt.Sprintf(" @%g", e.RPE)
+		}
+		if e.Notes != "" {
+			p += fmt.Sprintf(" %q", e.Notes)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestAppImport(t *testing.T) {
+	for _, tc := range []struct {
+		source, unit string
+		// names without a match, and what they are mapped to; a name
+		// missing from mapping stays unmapped
+		unknown []string
+		mapping map[string]string
+		entries string
+		invalid []int // lines
+		ignored int
+	}{
+		{
+			source:  SourceStrong,
+			unit:    "lb",
+			unknown: []string{"Leg Press Machine"},
+			mapping: map[string]string{"Leg Press Machine": "Leg Press"},
+			entries: `2024-01-15 Barbell Squats 102.06×5×2 @8; 2024-01-15 Barbell Squats 102.06×4×1 @9; ` +
+				`2024-01-15 Hanging Leg Raises 0×12×1; 2024-01-15 Leg Press 163.29×10×1 "felt heavy"`,
+			invalid: []int{6}, // a timed plank has no reps
+			ignored: 1,        // the warm-up set
+		},
+		{
+			source:  SourceHevy,
+			unknown: []string{"Bench Press (Barbell)", "Treadmill"},
+			mapping: map[string]string{"Bench Press (Barbell)": "Flat Bench Barbell Chest Press", "Treadmill": ""},
+			entries: `2024-01-15 Flat Bench Barbell Chest Press 80×5×2 @8.5 "pause"; 2024-01-15 Dumbbell Lateral Raises 10×12×1`,
+			invalid: []int{7}, // no date
+			ignored: 2,        // the warm-up set and the treadmill
+		},
+		{
+			source:  SourceFitNotes,
+			unknown: []string{"Deadlift", "Running (Treadmill)"},
+			mapping: map[string]string{"Running (Treadmill)": ""},
+			entries: `2024-01-16 Romanian Deadlifts 102.06×8×3 "Grip failing"; 2024-01-17 Leg Raises 0×8×1`,
+			invalid: []int{2}, // Deadlift is left unmapped
+			ignored: 1,
+		},
+	} {
+		t.Run(tc.source, func(t *testing.T) {
+			ctx := t.Context()
+			raw, err := os.ReadFile(filepath.Join("testdata", tc.source+".csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := NewTracker(data.NewMemoryStore())
+			imp, err := tr.ReadAppExport(ctx, bytes.NewReader(raw), tc.source, tc.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var unknown []string
+			for _, u := range imp.Unknown {
+				unknown = append(unknown, u.Name)
+				if ex := tc.mapping[u.Name]; ex != "" && !slices.Contains(u.Suggestions, ex) {
+					t.Errorf("suggestions for %q = %v, want %q among them", u.Name, u.Suggestions, ex)
+				}
+			}
+			if !slices.Equal(unknown, tc.unknown) {
+				t.Errorf("unknown names %v, want %v", unknown, tc.unknown)
+			}
+			for name, ex := range tc.mapping {
+				if err := tr.MapExercise(ctx, tc.source, name, ex); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := tr.ImportApp(ctx, imp, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var invalid []int
+			for _, ie := range res.Invalid {
+				invalid = append(invalid, ie.Line)
+			}
+			if got := describeEntries(res.Entries); got != tc.entries {
+				t.Errorf("entries:\n got %s\nwant %s", got, tc.entries)
+			}
+			if !slices.Equal(invalid, tc.invalid) || res.Ignored != tc.ignored || res.Imported != len(res.Entries) {
+				t.Errorf("invalid lines %v, ignored %d, imported %d; want %v, %d, %d",
+					invalid, res.Ignored, res.Imported, tc.invalid, tc.ignored, len(res.Entries))
+			}
+
+			// mappings are remembered, and a second import only finds duplicates
+			imp, err = tr.ReadAppExport(ctx, bytes.NewReader(raw), tc.source, tc.unit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imp.Unknown) != len(tc.unknown)-len(tc.mapping) {
+				t.Errorf("unknown names on a second read: %+v", imp.Unknown)
+			}
+			imported := res.Imported
+			if res, err = tr.ImportApp(ctx, imp, false); err != nil || res.Imported != 0 || res.Duplicates != imported {
+				t.Errorf("second import = %+v, %v; want %d duplicates", res, err, imported)
+			}
+		})
+	}
+}
+
+func TestReadAppExportRejects(t *testing.T) {
+	tr := NewTracker(data.NewMemoryStore())
+	for _, tc := range []struct{ source, unit, csv string }{
+		{"gymbook", "", "Date,Exercise\n"},
+		{SourceStrong, "stone", "Date,Exercise Name,Weight,Reps\n"},
+		{SourceStrong, "", ""},
+		{SourceHevy, "", "Date,Exercise Name,Weight,Reps\n"},
+	} {
+		if _, err := tr.ReadAppExport(t.Context(), strings.NewReader(tc.csv), tc.source, tc.unit); err == nil {
+			t.Errorf("ReadAppExport(%s, %q) succeeded", tc.source, tc.csv)
+		}
+	}
+}
+
+func TestStrongSemicolons(t *testing.T) {
+	// Strong in a decimal comma locale
+	const export = "Date;Exercise Name;Set Order;Weight;Reps\n2024-01-15 18:30:00;Squat (Barbell);1;102,5;5\n"
+	tr := NewTracker(data.NewMemoryStore())
+	imp, err := tr.ReadAppExport(t.Context(), strings.NewReader(export), SourceStrong, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := tr.ImportApp(t.Context(), imp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describeEntries(res.Entries); got != "2024-01-15 Barbell Squats 102.5×5×1" || res.Imported != 0 {
+		t.Errorf("dry run of a semicolon export = %s, imported %d", got, res.Imported)
+	}
+}
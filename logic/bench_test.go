@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+const benchEntries = 100_000
+
+var bench struct {
+	once     sync.Once
+	dir      string
+	repo     *data.Repository
+	exercise string
+	err      error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if bench.dir != "" {
+		os.RemoveAll(bench.dir)
+	}
+	os.Exit(code)
+}
+
+// benchRepo returns a repository over a synthetic database of benchEntries
+// entries spread across every exercise in the program and about five years
+// of training days. It is built once, on first use, and shared.
+func benchRepo(b *testing.B) *data.Repository {
+	bench.once.Do(func() {
+		bench.dir, bench.err = os.MkdirTemp("", "progresstracker-bench")
+		if bench.err != nil {
+			return
+		}
+		db, err := data.NewDB(filepath.Join(bench.dir, "bench.db"))
+		if err != nil {
+			bench.err = err
+			return
+		}
+		var exercises []string
+		for _, day := range data.DayOrder {
+			exercises = append(exercises, data.WorkoutDays(day, 1)...)
+			exercises = append(exercises, data.WorkoutDays(day, 2)...)
+		}
+		bench.exercise = exercises[0]
+
+		rng := rand.New(rand.NewSource(1))
+		start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		entries := make([]*data.Entry, benchEntries)
+		for i := range entries {
+			entries[i] = &data.Entry{
+				Exercise: exercises[rng.Intn(len(exercises))],
+				Weight:   float64(20 + rng.Intn(120)),
+				Reps:     5 + rng.Intn(8),
+				Sets:     3,
+				Date:     data.DateOf(start.AddDate(0, 0, rng.Intn(5*365))),
+			}
+		}
+		bench.err = db.InsertEntries(context.Background(), entries)
+		bench.repo = data.NewRepository(db)
+	})
+	if bench.err != nil {
+		b.Fatal(bench.err)
+	}
+	b.ResetTimer()
+	return bench.repo
+}
+
+func BenchmarkHistory(b *testing.B) {
+	t := NewTracker(benchRepo(b))
+	for b.Loop() {
+		if _, err := t.GetHistory(b.Context(), bench.exercise); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPersonalBest(b *testing.B) {
+	t := NewTracker(benchRepo(b))
+	for b.Loop() {
+		if _, err := t.GetPersonalBest(b.Context(), bench.exercise); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWeightOverTime(b *testing.B) {
+	a := NewAnalytics(benchRepo(b))
+	for b.Loop() {
+		if _, err := a.WeightOverTime(b.Context(), bench.exercise); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVolumeOverTime(b *testing.B) {
+	a := NewAnalytics(benchRepo(b))
+	for b.Loop() {
+		if _, err := a.VolumeOverTime(b.Context(), bench.exercise); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWeightOverTimeInGo is the chart query as it used to be done:
+// load the full history and aggregate per day in Go. It is kept for
+// comparison with BenchmarkWeightOverTime.
+func BenchmarkWeightOverTimeInGo(b *testing.B) {
+	repo := benchRepo(b)
+	for b.Loop() {
+		entries, err := repo.HistoryFor(b.Context(), bench.exercise)
+		if err != nil {
+			b.Fatal(err)
+		}
+		byDate := map[data.Date]float64{}
+		for _, e := range entries {
+			byDate[e.Date] = max(byDate[e.Date], e.Weight)
+		}
+		if len(byDate) == 0 {
+			b.Fatal("no data")
+		}
+	}
+}
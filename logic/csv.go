@@ -0,0 +1,340 @@
+package logic
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"progresstracker/data"
+	"strconv"
+	"strings"
+)
+
+const (
+	UnitKg = "kg"
+	UnitLb = "lb"
+
+	kgPerLb = 0.45359237
+)
+
+var csvHeader = []string{"date", "exercise", "weight_kg", "reps", "sets", "volume", "notes", "warmup", "rpe"}
+
+// importFields are the entry fields an import can fill, with the header names
+// recognised for each when no explicit mapping is given.
+var importFields = map[string][]string{
+	"date":     {"date", "day", "workout date"},
+	"exercise": {"exercise", "exercise name", "exercise_name", "lift"},
+	"weight":   {"weight", "weight_kg", "weight (kg)", "kg", "weight_lb", "weight (lb)", "weight (lbs)", "lb", "lbs"},
+	"reps":     {"reps", "rep", "repetitions"},
+	"sets":     {"sets", "set count"},
+	"notes":    {"notes", "note", "comment", "comments"},
+	"unit":     {"unit", "units", "weight unit"},
+	"warmup":   {"warmup", "warm-up", "warm up"},
+	"rpe":      {"rpe", "effort"},
+}
+
+type ExportFilter struct {
+	Exercise string
+	From     data.Date // inclusive
+	To       data.Date // inclusive
+}
+
+func (f ExportFilter) match(e *data.Entry) bool {
+	if f.Exercise != "" && e.Exercise != f.Exercise {
+		return false
+	}
+	if !f.From.IsZero() && e.Date < f.From {
+		return false
+	}
+	if !f.To.IsZero() && e.Date > f.To {
+		return false
+	}
+	return true
+}
+
+// ExportEntries returns every entry matching f, oldest first.
+func (t *Tracker) ExportEntries(ctx context.Context, f ExportFilter) ([]*data.Entry, error) {
+	entries, err := t.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*data.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if f.match(entries[i]) {
+			out = append(out, entries[i])
+		}
+	}
+	return out, nil
+}
+
+// ExportCSV writes every entry matching f, oldest first, and returns how
+// many rows were written. Weights are always exported in kg.
+func (t *Tracker) ExportCSV(ctx context.Context, w io.Writer, f ExportFilter) (int, error) {
+	entries, err := t.ExportEntries(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range entries {
+		rpe := ""
+		if e.RPE > 0 {
+			rpe = strconv.FormatFloat(e.RPE, 'f', -1, 64)
+		}
+		err := cw.Write([]string{
+			e.Date.String(),
+			e.Exercise,
+			strconv.FormatFloat(e.Weight, 'f', -1, 64),
+			strconv.Itoa(e.Reps),
+			strconv.Itoa(e.Sets),
+			strconv.FormatFloat(e.Volume, 'f', -1, 64),
+			e.Notes,
+			csvBool(e.Warmup),
+			rpe,
+		})
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	cw.Flush()
+	return n, cw.Error()
+}
+
+type ImportOptions struct {
+	// Mapping maps entry fields (date, exercise, weight, reps, sets, notes,
+	// unit, warmup, rpe) to column headers. Unmapped fields are matched by common names.
+	Mapping map[string]string
+	// Unit is the unit of the weight column when the file has no unit
+	// column. Empty means kg unless the weight header says otherwise.
+	Unit   string
+	DryRun bool
+}
+
+type ImportError struct {
+	Line int
+	Err  string
+}
+
+func (e ImportError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+type ImportResult struct {
+	Rows       int
+	Imported   int
+	Duplicates int
+	// Ignored counts rows left out on purpose, such as warm-up sets or
+	// exercises mapped to nothing.
+	Ignored int
+	Invalid []ImportError
+	// Entries holds the entries that were (or, on a dry run, would be)
+	// imported, in file order.
+	Entries []*data.Entry
+}
+
+// ParseColumnMapping parses "field=Column,field=Column" as used on the
+// command line and in the settings screen.
+func ParseColumnMapping(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, col, ok := strings.Cut(part, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || strings.TrimSpace(col) == "" {
+			return nil, fmt.Errorf("invalid mapping %q, want field=Column", part)
+		}
+		if _, known := importFields[field]; !known {
+			return nil, fmt.Errorf("unknown field %q in mapping", field)
+		}
+		m[field] = strings.TrimSpace(col)
+	}
+	return m, nil
+}
+
+func normalizeUnit(u string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(u)) {
+	case "", "kg", "kgs", "kilograms":
+		return UnitKg, nil
+	case "lb", "lbs", "pounds":
+		return UnitLb, nil
+	}
+	return "", fmt.Errorf("unknown unit %q", u)
+}
+
+// ImportCSV reads entries from a CSV file with a header row. Every row goes
+// through the same validation as AddEntry; rows that fail are reported and
+// skipped. Rows matching an entry stored before the import on date,
+// exercise, weight, reps and sets are counted as duplicates and skipped, one
+// row per stored entry, so repeated identical sets in the file are kept.
+// Nothing is written when opts.DryRun is set.
+func (t *Tracker) ImportCSV(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	defUnit, err := normalizeUnit(opts.Unit)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols, err := resolveColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Unit == "" && cols["unit"] < 0 && strings.Contains(strings.ToLower(header[cols["weight"]]), "lb") {
+		defUnit = UnitLb
+	}
+
+	seen, err := t.existingKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &ImportResult{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		res.Rows++
+		get := func(field string) string {
+			i := cols[field]
+			if i < 0 || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		e, err := importRow(get, defUnit)
+		if err != nil {
+			res.Invalid = append(res.Invalid, ImportError{Line: line, Err: err.Error()})
+			continue
+		}
+		key := entryKey(e)
+		if seen[key] > 0 {
+			seen[key]--
+			res.Duplicates++
+			continue
+		}
+		res.Entries = append(res.Entries, e)
+	}
+	if opts.DryRun || len(res.Entries) == 0 {
+		return res, nil
+	}
+	if err := t.repo.SaveAll(ctx, res.Entries); err != nil {
+		return nil, err
+	}
+	res.Imported = len(res.Entries)
+	return res, nil
+}
+
+func importRow(get func(string) string, defUnit string) (*data.Entry, error) {
+	exercise, date := get("exercise"), get("date")
+	if exercise == "" {
+		return nil, errors.New("missing exercise")
+	}
+	if date == "" {
+		return nil, errors.New("missing date")
+	}
+	sets := get("sets")
+	if sets == "" {
+		sets = "1"
+	}
+	unit := defUnit
+	if u := get("unit"); u != "" {
+		var err error
+		if unit, err = normalizeUnit(u); err != nil {
+			return nil, err
+		}
+	}
+	e, err := parseEntry(exercise, get("weight"), get("reps"), sets, get("rpe"), get("notes"), date, unit)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(get("warmup")) {
+	case "", "0", "false", "no":
+	case "1", "true", "yes", "w":
+		e.Warmup = true
+	default:
+		return nil, fmt.Errorf("invalid warmup %q, want 1 or 0", get("warmup"))
+	}
+	return e, nil
+}
+
+func csvBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func resolveColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := map[string]int{}
+	for i, h := range header {
+		h = strings.TrimPrefix(h, "\ufeff")
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	cols := map[string]int{}
+	for field, aliases := range importFields {
+		cols[field] = -1
+		if col, ok := mapping[field]; ok {
+			i, found := index[strings.ToLower(col)]
+			if !found {
+				return nil, fmt.Errorf("column %q mapped to %s not found in header", col, field)
+			}
+			cols[field] = i
+			continue
+		}
+		for _, alias := range aliases {
+			if i, found := index[alias]; found {
+				cols[field] = i
+				break
+			}
+		}
+	}
+	for _, required := range []string{"date", "exercise", "weight", "reps"} {
+		if cols[required] < 0 {
+			return nil, fmt.Errorf("no column for %s; map one with %s=Column", required, required)
+		}
+	}
+	return cols, nil
+}
+
+// existingKeys counts the stored entries by entryKey. Each stored entry
+// matches at most one imported row.
+func (t *Tracker) existingKeys(ctx context.Context) (map[string]int, error) {
+	entries, err := t.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]int, len(entries))
+	for _, e := range entries {
+		keys[entryKey(e)]++
+	}
+	return keys, nil
+}
+
+func entryKey(e *data.Entry) string {
+	return fmt.Sprintf("%s|%s|%.2f|%d|%d|%t", e.Date, e.Exercise, e.Weight, e.Reps, e.Sets, e.Warmup)
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
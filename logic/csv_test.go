@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestCSVRoundTripKeepsRepeatedSets(t *testing.T) {
+	ctx := t.Context()
+	src := NewTracker(data.NewMemoryStore())
+	// a session saves each set as its own entry
+	for range 3 {
+		if _, err := src.AddEntry(ctx, "Barbell Squats", "80", "8", "1", "", "", "2025-03-01"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.AddEntry(ctx, "Barbell Squats", "60", "10", "1", "", "", "2025-02-22"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if n, err := src.ExportCSV(ctx, &buf, ExportFilter{}); err != nil || n != 4 {
+		t.Fatalf("ExportCSV = %d, %v", n, err)
+	}
+	csv := buf.String()
+
+	dst := NewTracker(data.NewMemoryStore())
+	res, err := dst.ImportCSV(ctx, bytes.NewBufferString(csv), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 4 || res.Imported != 4 || res.Duplicates != 0 {
+		t.Errorf("import into an empty store = %+v, want all 4 rows imported", res)
+	}
+	// importing the same file again finds every row, repeats included
+	res, err = dst.ImportCSV(ctx, bytes.NewBufferString(csv), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 0 || res.Duplicates != 4 {
+		t.Errorf("second import = %+v, want 4 duplicates", res)
+	}
+	if all, _ := dst.GetAllEntries(ctx); len(all) != 4 {
+		t.Errorf("store has %d entries, want 4", len(all))
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	ctx := t.Context()
+	for _, tc := range []struct {
+		name    string
+		csv     string
+		opts    ImportOptions
+		weights []float64
+		invalid []int // lines
+	}{
+		{
+			name:    "common headers",
+			csv:     "Date,Exercise,Weight,Reps,Sets\n2025-03-01,Barbell Squats,100,5,3\n",
+			weights: []float64{100},
+		},
+		{
+			name:    "mapped columns",
+			csv:     "Day,Lift,Load,Count\n2025-03-01,Barbell Squats,100,5\n",
+			opts:    ImportOptions{Mapping: map[string]string{"exercise": "Lift", "weight": "load", "reps": "Count"}},
+			weights: []float64{100},
+		},
+		{
+			name:    "pounds from the header",
+			csv:     "date,exercise,weight (lbs),reps\n2025-03-01,Barbell Squats,225,5\n",
+			weights: []float64{102.06},
+		},
+		{
+			name:    "pounds from the option",
+			csv:     "date,exercise,weight,reps\n2025-03-01,Barbell Squats,100,5\n",
+			opts:    ImportOptions{Unit: "lbs"},
+			weights: []float64{45.36},
+		},
+		{
+			name:    "unit column per row",
+			csv:     "date,exercise,weight,reps,unit\n2025-03-01,Barbell Squats,100,5,lb\n2025-03-01,Barbell Squats,100,5,kg\n",
+			weights: []float64{45.36, 100},
+		},
+		{
+			name:    "invalid rows",
+			csv:     "date,exercise,weight,reps,warmup\n2025-03-01,Barbell Squats,heavy,5,\n2025-03-01,,100,5,\n2025-03-01,Barbell Squats,100,5,maybe\n2025-03-01,Barbell Squats,100,5,1\n",
+			weights: []float64{100},
+			invalid: []int{2, 3, 4},
+		},
+	} {
+		res, err := NewTracker(data.NewMemoryStore()).ImportCSV(ctx, bytes.NewBufferString(tc.csv), tc.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var weights []float64
+		for _, e := range res.Entries {
+			weights = append(weights, e.Weight)
+		}
+		var lines []int
+		for _, ie := range res.Invalid {
+			lines = append(lines, ie.Line)
+		}
+		if !slices.Equal(weights, tc.weights) || !slices.Equal(lines, tc.invalid) || res.Imported != len(tc.weights) {
+			t.Errorf("%s: imported %v (%d), invalid lines %v; want %v, invalid %v", tc.name, weights, res.Imported, lines, tc.weights, tc.invalid)
+		}
+	}
+}
+
+func TestImportCSVRejectsHeader(t *testing.T) {
+	tr := NewTracker(data.NewMemoryStore())
+	for _, tc := range []struct {
+		csv  string
+		opts ImportOptions
+	}{
+		{"", ImportOptions{}},
+		{"date,exercise,weight\n", ImportOptions{}},
+		{"date,exercise,weight,reps\n", ImportOptions{Mapping: map[string]string{"reps": "Count"}}},
+		{"date,exercise,weight,reps\n", ImportOptions{Unit: "stone"}},
+	} {
+		if _, err := tr.ImportCSV(t.Context(), bytes.NewBufferString(tc.csv), tc.opts); err == nil {
+			t.Errorf("ImportCSV(%q, %+v) succeeded", tc.csv, tc.opts)
+		}
+	}
+}
+
+func TestImportCSVDryRunAndDuplicates(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	if _, err := tr.AddEntry(ctx, "Barbell Squats", "100", "5", "1", "", "", "2025-03-01"); err != nil {
+		t.Fatal(err)
+	}
+	csv := "date,exercise,weight,reps\n2025-03-01,Barbell Squats,100,5\n2025-03-01,Barbell Squats,100,5\n2025-03-02,Barbell Squats,105,5\n"
+
+	res, err := tr.ImportCSV(ctx, bytes.NewBufferString(csv), ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rows != 3 || res.Duplicates != 1 || len(res.Entries) != 2 || res.Imported != 0 {
+		t.Errorf("dry run = %+v, want 1 duplicate and 2 entries to import", res)
+	}
+	if all, _ := tr.GetAllEntries(ctx); len(all) != 1 {
+		t.Errorf("dry run saved entries: %d in store", len(all))
+	}
+
+	if res, err = tr.ImportCSV(ctx, bytes.NewBufferString(csv), ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if res.Duplicates != 1 || res.Imported != 2 {
+		t.Errorf("import = %+v, want 1 duplicate and 2 imported", res)
+	}
+	if all, _ := tr.GetAllEntries(ctx); len(all) != 3 {
+		t.Errorf("store has %d entries, want 3", len(all))
+	}
+}
+
+func TestParseColumnMapping(t *testing.T) {
+	m, err := ParseColumnMapping(" date=Day , Weight=Load (lbs),")
+	if err != nil || len(m) != 2 || m["date"] != "Day" || m["weight"] != "Load (lbs)" {
+		t.Errorf("ParseColumnMapping = %v, %v", m, err)
+	}
+	for _, s := range []string{"date", "date=", "height=Tall"} {
+		if _, err := ParseColumnMapping(s); err == nil {
+			t.Errorf("ParseColumnMapping(%q) succeeded", s)
+		}
+	}
+}
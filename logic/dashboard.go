@@ -0,0 +1,277 @@
+package logic
+
+import (
+	"context"
+	"progresstracker/data"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	recentPRDays   = 30
+	maxRecentPRs   = 5
+	staleAfterDays = 21
+	stallSessions  = 3
+)
+
+type SessionSummary struct {
+	Date      data.Date
+	Exercises int
+	Sets      int
+	Volume    float64
+	Entries   []*data.Entry
+}
+
+type PR struct {
+	Exercise string
+	Weight   float64
+	Previous float64
+	Date     data.Date
+}
+
+type Attention struct {
+	Exercise string
+	Reason   string
+	LastDate data.Date
+}
+
+type DashboardSummary struct {
+	Today          data.Date
+	Day            string // empty on rest days
+	Week           int
+	Exercises      []string
+	LastSession    *SessionSummary
+	RecentPRs      []PR
+	WeekVolume     float64
+	LastWeekVolume float64
+	Streak         int
+	NeedsAttention []Attention
+	Fatigue        *FatigueReport
+	Goals          []*GoalProgress // open ones and those reached in the last 30 days
+}
+
+// Dashboard computes everything shown on the home screen in one pass over
+// the stored entries, relative to now.
+func (a *Analytics) Dashboard(ctx context.Context, now time.Time) (*DashboardSummary, error) {
+	entries, err := a.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries = workingSets(entries)
+	week, err := a.repo.GetCurrentWeek(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deloads, err := deloadWeeks(ctx, a.repo)
+	if err != nil {
+		return nil, err
+	}
+	today := dateOnly(now)
+	s := &DashboardSummary{
+		Today: data.DateOf(today),
+		Week:  week,
+	}
+	if day := today.Weekday().String(); isTrainingDay(day) {
+		s.Day = day
+		s.Exercises = data.WorkoutDays(day, s.Week)
+	}
+
+	// entries come back newest first; walk them oldest first
+	chrono := make([]*data.Entry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		chrono = append(chrono, entries[i])
+	}
+
+	s.LastSession = lastSession(chrono)
+	s.RecentPRs = recentPRs(chrono, today)
+	s.WeekVolume, s.LastWeekVolume = weeklyVolumes(chrono, today)
+	s.Streak = streak(chrono, today)
+	s.NeedsAttention = needsAttention(chrono, today, deloads)
+	s.Fatigue = fatigue(chrono, deloads, today)
+
+	goals, err := a.Goals(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range goals {
+		if g.Achieved == "" || s.Today.DaysSince(g.Achieved) <= recentPRDays {
+			s.Goals = append(s.Goals, g)
+		}
+	}
+	return s, nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func isTrainingDay(day string) bool {
+	_, ok := data.WorkoutPlans[day]
+	return ok
+}
+
+func lastSession(chrono []*data.Entry) *SessionSummary {
+	if len(chrono) == 0 {
+		return nil
+	}
+	date := chrono[len(chrono)-1].Date
+	s := &SessionSummary{Date: date}
+	seen := map[string]bool{}
+	for _, e := range chrono {
+		if e.Date != date {
+			continue
+		}
+		s.Entries = append(s.Entries, e)
+		s.Sets += e.Sets
+		s.Volume += e.Volume
+		if !seen[e.Exercise] {
+			seen[e.Exercise] = true
+			s.Exercises++
+		}
+	}
+	return s
+}
+
+// recentPRs lists weight PRs set within the last recentPRDays, newest first.
+// An exercise's first ever entry is not counted as a PR.
+func recentPRs(chrono []*data.Entry, today time.Time) []PR {
+	cutoff := data.DateOf(today.AddDate(0, 0, -recentPRDays))
+	best := map[string]float64{}
+	var prs []PR
+	for _, e := range chrono {
+		prev, ok := best[e.Exercise]
+		if ok && e.Weight > prev && e.Date >= cutoff {
+			prs = append(prs, PR{Exercise: e.Exercise, Weight: e.Weight, Previous: prev, Date: e.Date})
+		}
+		if !ok || e.Weight > prev {
+			best[e.Exercise] = e.Weight
+		}
+	}
+	sort.SliceStable(prs, func(i, j int) bool { return prs[i].Date > prs[j].Date })
+	if len(prs) > maxRecentPRs {
+		prs = prs[:maxRecentPRs]
+	}
+	return prs
+}
+
+// weekStart returns the Monday of the week containing t.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func weeklyVolumes(chrono []*data.Entry, today time.Time) (this, last float64) {
+	thisStart := data.DateOf(weekStart(today))
+	lastStart := data.DateOf(weekStart(today).AddDate(0, 0, -7))
+	for _, e := range chrono {
+		switch {
+		case e.Date >= thisStart:
+			this += e.Volume
+		case e.Date >= lastStart:
+			last += e.Volume
+		}
+	}
+	return this, last
+}
+
+// streak counts consecutive training days (days in the plan) with at least
+// one entry. Today only breaks the streak once it is over.
+func streak(chrono []*data.Entry, today time.Time) int {
+	trained := map[data.Date]bool{}
+	for _, e := range chrono {
+		trained[e.Date] = true
+	}
+	cursor := today
+	if !trained[data.DateOf(cursor)] {
+		cursor = prevTrainingDay(cursor)
+	}
+	n := 0
+	for trained[data.DateOf(cursor)] {
+		n++
+		cursor = prevTrainingDay(cursor)
+	}
+	return n
+}
+
+func prevTrainingDay(t time.Time) time.Time {
+	for i := 0; i < 7; i++ {
+		t = t.AddDate(0, 0, -1)
+		if isTrainingDay(t.Weekday().String()) {
+			break
+		}
+	}
+	return t
+}
+
+// needsAttention flags lifts that have not been trained for a while or whose
+// top weight has not moved over the last few sessions. Sessions in deload
+// weeks are light on purpose and do not count toward a stall.
+func needsAttention(chrono []*data.Entry, today time.Time, deloads []data.Date) []Attention {
+	type session struct {
+		date data.Date
+		top  float64
+	}
+	byEx := map[string][]session{}
+	lastDate := map[string]data.Date{}
+	for _, e := range chrono {
+		lastDate[e.Exercise] = e.Date
+		if inDeload(deloads, e.Date) {
+			continue
+		}
+		ss := byEx[e.Exercise]
+		if n := len(ss); n > 0 && ss[n-1].date == e.Date {
+			if e.Weight > ss[n-1].top {
+				ss[n-1].top = e.Weight
+			}
+			continue
+		}
+		byEx[e.Exercise] = append(ss, session{date: e.Date, top: e.Weight})
+	}
+
+	staleCutoff := data.DateOf(today.AddDate(0, 0, -staleAfterDays))
+	var out []Attention
+	for ex, lastDay := range lastDate {
+		if lastDay < staleCutoff {
+			days := data.DateOf(today).DaysSince(lastDay)
+			out = append(out, Attention{Exercise: ex, LastDate: lastDay, Reason: plural(days, "day") + " since last session"})
+			continue
+		}
+		ss := byEx[ex]
+		if len(ss) <= stallSessions {
+			continue
+		}
+		before := 0.0
+		for _, s := range ss[:len(ss)-stallSessions] {
+			if s.top > before {
+				before = s.top
+			}
+		}
+		stalled := true
+		for _, s := range ss[len(ss)-stallSessions:] {
+			if s.top > before {
+				stalled = false
+				break
+			}
+		}
+		if stalled {
+			out = append(out, Attention{Exercise: ex, LastDate: lastDay, Reason: "no weight increase in " + plural(stallSessions, "session")})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].LastDate != out[j].LastDate {
+			return out[i].LastDate < out[j].LastDate
+		}
+		return out[i].Exercise < out[j].Exercise
+	})
+	return out
+}
+
+func plural(n int, word string) string {
+	s := strconv.Itoa(n) + " " + word
+	if n != 1 {
+		s += "s"
+	}
+	return s
+}
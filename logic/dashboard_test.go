@@ -0,0 +1,208 @@
+package logic
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+// wednesday is the fixed "now" of the dashboard tests: Wednesday 5 March
+// 2025, in the week starting Monday 3 March.
+var wednesday = time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+
+func day(s string) time.Time {
+	return data.Date(s).Time()
+}
+
+// trained builds oldest-first entries, one per "date exercise weight".
+func trained(rows ...string) []*data.Entry {
+	var chrono []*data.Entry
+	for _, r := range rows {
+		f := strings.Fields(r)
+		w, _ := strconv.ParseFloat(f[len(f)-1], 64)
+		ex := strings.Join(f[1:len(f)-1], " ")
+		chrono = append(chrono, &data.Entry{Date: data.Date(f[0]), Exercise: ex, Weight: w, Reps: 1, Sets: 1, Volume: w})
+	}
+	return chrono
+}
+
+func TestWeekStart(t *testing.T) {
+	for in, want := range map[string]data.Date{
+		"2025-03-03": "2025-03-03", // Monday
+		"2025-03-05": "2025-03-03",
+		"2025-03-08": "2025-03-03", // Saturday
+		"2025-03-09": "2025-03-03", // Sunday ends the week
+		"2025-03-10": "2025-03-10",
+		"2025-01-01": "2024-12-30", // across a year
+	} {
+		if got := data.DateOf(weekStart(day(in))); got != want {
+			t.Errorf("weekStart(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestWeeklyVolumes(t *testing.T) {
+	chrono := trained(
+		"2025-02-23 Squat 1000", // Sunday, two weeks ago
+		"2025-02-24 Squat 200",  // Monday of last week
+		"2025-03-02 Squat 30",   // Sunday of last week
+		"2025-03-03 Squat 4",    // Monday of this week
+		"2025-03-05 Squat 5",
+	)
+	for _, tc := range []struct {
+		now        string
+		this, last float64
+	}{
+		{"2025-03-05", 9, 230},
+		{"2025-03-09", 9, 230}, // still the same week on Sunday
+		{"2025-03-10", 0, 9},
+	} {
+		this, last := weeklyVolumes(chrono, day(tc.now))
+		if this != tc.this || last != tc.last {
+			t.Errorf("weeklyVolumes on %s = %g, %g; want %g, %g", tc.now, this, last, tc.this, tc.last)
+		}
+	}
+}
+
+func TestStreak(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		now   time.Time
+		dates []string
+		want  int
+	}{
+		{"nothing logged", wednesday, nil, 0},
+		{"through today", wednesday, []string{"2025-03-03", "2025-03-04", "2025-03-05"}, 3},
+		{"today not over yet", wednesday, []string{"2025-03-03", "2025-03-04"}, 2},
+		{"a missed day", wednesday, []string{"2025-03-03", "2025-03-05"}, 1},
+		{"missed yesterday and today", wednesday, []string{"2025-03-03"}, 0},
+		{"the weekend is rest", wednesday, []string{"2025-02-27", "2025-02-28", "2025-03-03", "2025-03-04"}, 4},
+		{"a weekend session does not cover a missed Monday", wednesday, []string{"2025-02-28", "2025-03-01", "2025-03-04"}, 1},
+		{"on a rest day", day("2025-03-09"), []string{"2025-03-06", "2025-03-07"}, 2},
+	} {
+		var rows []string
+		for _, d := range tc.dates {
+			rows = append(rows, d+" Squat 100")
+		}
+		if got := streak(trained(rows...), tc.now); got != tc.want {
+			t.Errorf("%s: streak = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRecentPRs(t *testing.T) {
+	chrono := trained(
+		"2025-01-01 Squat 100",
+		"2025-01-10 Squat 110", // older than 30 days
+		"2025-02-10 Bench 60",  // first entry, not a PR
+		"2025-02-10 Squat 105",
+		"2025-02-17 Squat 115",
+		"2025-02-24 Bench 65",
+		"2025-03-03 Squat 115", // equal is not a PR
+		"2025-03-04 Bench 70",
+	)
+	var got []string
+	for _, pr := range recentPRs(chrono, wednesday) {
+		got = append(got, fmt.Sprintf("%s %s %g>%g", pr.Date, pr.Exercise, pr.Weight, pr.Previous))
+	}
+	want := []string{"2025-03-04 Bench 70>65", "2025-02-24 Bench 65>60", "2025-02-17 Squat 115>110"}
+	if !slices.Equal(got, want) {
+		t.Errorf("recentPRs = %v, want %v", got, want)
+	}
+
+	// only the newest few are kept
+	var rows []string
+	for i := range 8 {
+		rows = append(rows, fmt.Sprintf("2025-03-0%d Squat %d", i+1, 100+i))
+	}
+	if prs := recentPRs(trained(rows...), day("2025-03-08")); len(prs) != maxRecentPRs || prs[0].Weight != 107 {
+		t.Errorf("recentPRs kept %d, newest %+v", len(prs), prs[0])
+	}
+}
+
+func TestNeedsAttention(t *testing.T) {
+	chrono := trained(
+		"2025-01-20 Curl 20",
+		"2025-02-11 Curl 20", // 22 days before today: stale
+		// top weight stuck for the last three sessions
+		"2025-02-03 Squat 100",
+		"2025-02-10 Squat 100",
+		"2025-02-24 Squat 90",
+		"2025-02-24 Squat 100",
+		"2025-03-03 Squat 95",
+		// still moving up
+		"2025-02-03 Bench 60",
+		"2025-02-10 Bench 60",
+		"2025-02-24 Bench 60",
+		"2025-03-03 Bench 62.5",
+		// too few sessions to tell
+		"2025-02-17 Row 50",
+		"2025-02-24 Row 50",
+		"2025-03-03 Row 50",
+		// a stall with a light deload week in the middle is only three
+		// sessions once the deload is left out
+		"2025-02-03 Press 40",
+		"2025-02-10 Press 40",
+		"2025-02-17 Press 30",
+		"2025-02-24 Press 40",
+	)
+	slices.SortStableFunc(chrono, func(a, b *data.Entry) int { return strings.Compare(string(a.Date), string(b.Date)) })
+	deloads := []data.Date{"2025-02-17"}
+
+	var got []string
+	for _, a := range needsAttention(chrono, wednesday, deloads) {
+		got = append(got, fmt.Sprintf("%s %s: %s", a.LastDate, a.Exercise, a.Reason))
+	}
+	want := []string{
+		"2025-02-11 Curl: 22 days since last session",
+		"2025-03-03 Squat: no weight increase in 3 sessions",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("needsAttention =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// without the deload, the press has stalled too
+	if n := len(needsAttention(chrono, wednesday, nil)); n != 3 {
+		t.Errorf("needsAttention without deloads flags %d lifts, want 3", n)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	ctx := t.Context()
+	repo := data.NewMemoryStore()
+	tr := NewTracker(repo)
+	for _, e := range [][2]string{
+		{"2025-03-03", "100"},
+		{"2025-03-04", "105"},
+		{"2025-03-05", "110"},
+	} {
+		if _, err := tr.AddEntry(ctx, "Barbell Squats", e[1], "5", "1", "", "", e[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// warm-ups stay out of every figure
+	if err := repo.Save(ctx, &data.Entry{Exercise: "Barbell Squats", Weight: 200, Reps: 1, Sets: 1, Volume: 200, Date: "2025-03-05", Warmup: true}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewAnalytics(repo).Dashboard(ctx, wednesday.Add(20*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Today != "2025-03-05" || s.Day != "Wednesday" || s.Week != 1 || len(s.Exercises) == 0 {
+		t.Errorf("today %s (%s, week %d, %d exercises)", s.Today, s.Day, s.Week, len(s.Exercises))
+	}
+	if s.Streak != 3 || s.WeekVolume != 1575 || s.LastWeekVolume != 0 {
+		t.Errorf("streak %d, volume %g this week and %g last", s.Streak, s.WeekVolume, s.LastWeekVolume)
+	}
+	if s.LastSession == nil || s.LastSession.Date != "2025-03-05" || s.LastSession.Sets != 1 || s.LastSession.Volume != 550 {
+		t.Errorf("last session %+v", s.LastSession)
+	}
+	if len(s.RecentPRs) != 2 || s.RecentPRs[0].Weight != 110 {
+		t.Errorf("recent PRs %+v", s.RecentPRs)
+	}
+}
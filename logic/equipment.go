@@ -0,0 +1,372 @@
+package logic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"progresstracker/data"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Settings keys for the equipment profile.
+const (
+	platesSetting       = "equipment_plates"
+	barsSetting         = "equipment_bars"
+	dumbbellStepSetting = "equipment_dumbbell_step"
+	machineStepSetting  = "equipment_machine_step"
+)
+
+// Plate is a plate weight in kg and how many pairs of it there are; 0
+// pairs means as many as needed.
+type Plate struct {
+	Weight float64
+	Pairs  int
+}
+
+// Equipment is what the gym has, used to turn a weight into plates and to
+// keep suggested weights loadable.
+type Equipment struct {
+	Plates       []Plate            // heaviest first
+	Bars         map[string]float64 // bar weight by data.Barbell, data.EZBar and so on
+	DumbbellStep float64
+	MachineStep  float64
+}
+
+func DefaultEquipment() Equipment {
+	return Equipment{
+		Plates: []Plate{{25, 0}, {20, 0}, {15, 0}, {10, 0}, {5, 0}, {2.5, 0}, {1.25, 0}},
+		Bars: map[string]float64{
+			data.Barbell: 20,
+			data.EZBar:   10,
+			data.Smith:   15,
+			data.Sled:    0,
+		},
+		DumbbellStep: 2.5,
+		MachineStep:  5,
+	}
+}
+
+// PlatesString formats the plates the way ParsePlates reads them.
+func (eq Equipment) PlatesString() string {
+	parts := make([]string, len(eq.Plates))
+	for i, p := range eq.Plates {
+		parts[i] = strconv.FormatFloat(p.Weight, 'f', -1, 64)
+		if p.Pairs > 0 {
+			parts[i] += fmt.Sprintf("×%d", p.Pairs)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BarsString formats the bars the way ParseBars reads them.
+func (eq Equipment) BarsString() string {
+	var parts []string
+	for _, kind := range barKinds(eq.Bars) {
+		parts = append(parts, kind+"="+strconv.FormatFloat(eq.Bars[kind], 'f', -1, 64))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// barKinds returns the bar types in a fixed order, the common ones first.
+func barKinds(bars map[string]float64) []string {
+	order := []string{data.Barbell, data.EZBar, data.Smith, data.Sled}
+	var kinds []string
+	for _, k := range order {
+		if _, ok := bars[k]; ok {
+			kinds = append(kinds, k)
+		}
+	}
+	var rest []string
+	for k := range bars {
+		if !slices.Contains(order, k) {
+			rest = append(rest, k)
+		}
+	}
+	slices.Sort(rest)
+	return append(kinds, rest...)
+}
+
+// ParsePlates reads plates such as "25, 20, 15×2, 10, 5, 2.5, 1.25", where
+// ×n (or xn) limits a plate to n pairs.
+func ParsePlates(s string) ([]Plate, error) {
+	var plates []Plate
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		w, pairs, limited := strings.Cut(strings.ReplaceAll(part, "×", "x"), "x")
+		var p Plate
+		var err error
+		if p.Weight, err = strconv.ParseFloat(strings.TrimSpace(w), 64); err != nil || p.Weight <= 0 || p.Weight > MaxWeight/2 {
+			return nil, fmt.Errorf("invalid plate %q", part)
+		}
+		if limited {
+			if p.Pairs, err = strconv.Atoi(strings.TrimSpace(pairs)); err != nil || p.Pairs <= 0 {
+				return nil, fmt.Errorf("invalid number of pairs in %q", part)
+			}
+		}
+		if slices.ContainsFunc(plates, func(q Plate) bool { return q.Weight == p.Weight }) {
+			return nil, fmt.Errorf("plate %g kg listed twice", p.Weight)
+		}
+		plates = append(plates, p)
+	}
+	if len(plates) == 0 {
+		return nil, fmt.Errorf("no plates")
+	}
+	slices.SortFunc(plates, func(a, b Plate) int { return cmp.Compare(b.Weight, a.Weight) })
+	return plates, nil
+}
+
+// ParseBars reads bar weights such as "barbell=20, ez-bar=10".
+func ParseBars(s string) (map[string]float64, error) {
+	bars := map[string]float64{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		kind, w, ok := strings.Cut(part, "=")
+		kind = strings.TrimSpace(kind)
+		if !ok || kind == "" {
+			return nil, fmt.Errorf("invalid bar %q, want type=kg", part)
+		}
+		kg, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+		if err != nil || kg < 0 || kg > MaxWeight {
+			return nil, fmt.Errorf("invalid weight for bar %q", kind)
+		}
+		bars[kind] = kg
+	}
+	return bars, nil
+}
+
+// LoadPlates works out the plates for each side of a bar weighing bar to
+// make total, heaviest first. When the plates cannot make it exactly, it
+// gets as close as they can from below and returns the difference as rest,
+// in kg per side.
+func (eq Equipment) LoadPlates(total, bar float64) (side []float64, rest float64) {
+	target := grams((total - bar) / 2)
+	if target <= 0 {
+		return nil, 0
+	}
+	// a search over how many of each plate to use, in grams so sums are
+	// exact; left(i, r) is the least that plates i onwards leave of r
+	memo := map[[2]int]int{}
+	var left func(i, r int) int
+	left = func(i, r int) int {
+		if r == 0 || i == len(eq.Plates) {
+			return r
+		}
+		key := [2]int{i, r}
+		if v, ok := memo[key]; ok {
+			return v
+		}
+		best, w := r, grams(eq.Plates[i].Weight)
+		for n := eq.maxPairs(i, r); n >= 0 && best > 0; n-- {
+			best = min(best, left(i+1, r-n*w))
+		}
+		memo[key] = best
+		return best
+	}
+	want := left(0, target)
+	// take as many of each plate as still gets there, heaviest first
+	r := target
+	for i, p := range eq.Plates {
+		w := grams(p.Weight)
+		for n := eq.maxPairs(i, r); n >= 0; n-- {
+			if left(i+1, r-n*w) == want {
+				for range n {
+					side = append(side, p.Weight)
+				}
+				r -= n * w
+				break
+			}
+		}
+	}
+	return side, float64(want) / 1000
+}
+
+// maxPairs is how many of plate i fit in r grams per side.
+func (eq Equipment) maxPairs(i, r int) int {
+	n := r / grams(eq.Plates[i].Weight)
+	if p := eq.Plates[i].Pairs; p > 0 {
+		n = min(n, p)
+	}
+	return n
+}
+
+func grams(kg float64) int {
+	return int(math.Round(kg * 1000))
+}
+
+// Bar returns the weight of the bar exercise is loaded on, and whether it
+// is loaded with plates at all.
+func (eq Equipment) Bar(exercise string) (float64, bool) {
+	bar, ok := eq.Bars[data.ExerciseEquipment[exercise]]
+	return bar, ok
+}
+
+// loadable reports whether exercise can be set up at w exactly.
+func (eq Equipment) loadable(exercise string, w float64) bool {
+	step := 0.0
+	switch kind := data.ExerciseEquipment[exercise]; kind {
+	case data.Dumbbell:
+		step = eq.DumbbellStep
+	case data.Machine:
+		step = eq.MachineStep
+	default:
+		bar, ok := eq.Bars[kind]
+		if !ok {
+			return true
+		}
+		if w < bar {
+			return false
+		}
+		_, rest := eq.LoadPlates(w, bar)
+		return rest == 0
+	}
+	if step <= 0 {
+		return true
+	}
+	n := w / step
+	return math.Abs(n-math.Round(n)) < 1e-9
+}
+
+// loadStep is the smallest change in weight worth trying for exercise.
+func (eq Equipment) loadStep(exercise string) float64 {
+	switch data.ExerciseEquipment[exercise] {
+	case data.Dumbbell:
+		return eq.DumbbellStep
+	case data.Machine:
+		return eq.MachineStep
+	}
+	small := 0.0
+	for _, p := range eq.Plates {
+		if small == 0 || p.Weight < small {
+			small = p.Weight
+		}
+	}
+	return 2 * small
+}
+
+// Round returns the loadable weight for exercise nearest to w, preferring
+// the lighter one on a tie. Exercises without equipment, and weights the
+// equipment cannot get near, are returned as they are.
+func (eq Equipment) Round(exercise string, w float64) float64 {
+	if _, ok := data.ExerciseEquipment[exercise]; !ok {
+		return w
+	}
+	step := eq.loadStep(exercise)
+	if step <= 0 {
+		return w
+	}
+	base, onBar := eq.Bar(exercise)
+	if onBar {
+		w = max(w, base)
+	}
+	// the nearest loadable weight is within a few steps either way, unless
+	// the plates run out
+	const tries = 8
+	for k := 0; k <= tries; k++ {
+		lo := base + math.Floor((w-base)/step)*step - float64(k)*step
+		hi := base + math.Ceil((w-base)/step)*step + float64(k)*step
+		loOK := lo >= 0 && eq.loadable(exercise, lo)
+		hiOK := eq.loadable(exercise, hi)
+		switch {
+		case loOK && (!hiOK || w-lo <= hi-w):
+			return roundTo(lo, 3)
+		case hiOK:
+			return roundTo(hi, 3)
+		}
+	}
+	return w
+}
+
+// NextLoad returns the lightest loadable weight for exercise above w, or w
+// plus the usual weightStep for exercises without equipment.
+func (eq Equipment) NextLoad(exercise string, w float64) float64 {
+	if _, ok := data.ExerciseEquipment[exercise]; !ok {
+		return w + weightStep
+	}
+	step := eq.loadStep(exercise)
+	if step <= 0 {
+		return w + weightStep
+	}
+	for next := w + step/2; next <= MaxWeight; next += step / 2 {
+		if r := eq.Round(exercise, next); r > w {
+			return r
+		}
+	}
+	return w + weightStep
+}
+
+// Equipment returns the saved equipment profile, with the defaults for
+// anything not saved.
+func (t *Tracker) Equipment(ctx context.Context) (Equipment, error) {
+	eq := DefaultEquipment()
+	saved := map[string]string{}
+	for _, key := range []string{platesSetting, barsSetting, dumbbellStepSetting, machineStepSetting} {
+		v, ok, err := t.repo.Setting(ctx, key)
+		if err != nil {
+			return eq, err
+		}
+		if ok {
+			saved[key] = v
+		}
+	}
+	// a value that no longer parses falls back to the default
+	if plates, err := ParsePlates(saved[platesSetting]); err == nil {
+		eq.Plates = plates
+	}
+	if bars, err := ParseBars(saved[barsSetting]); err == nil && len(bars) > 0 {
+		eq.Bars = bars
+	}
+	if step, err := strconv.ParseFloat(saved[dumbbellStepSetting], 64); err == nil {
+		eq.DumbbellStep = step
+	}
+	if step, err := strconv.ParseFloat(saved[machineStepSetting], 64); err == nil {
+		eq.MachineStep = step
+	}
+	return eq, nil
+}
+
+// SetEquipment validates and saves the equipment profile from form values,
+// reporting bad ones as a *data.ValidationError for the fields "plates",
+// "bars", "dumbbell_step" and "machine_step".
+func (t *Tracker) SetEquipment(ctx context.Context, plates, bars, dumbbellStep, machineStep string) (Equipment, error) {
+	var verr data.ValidationError
+	var eq Equipment
+	var err error
+	if eq.Plates, err = ParsePlates(plates); err != nil {
+		verr.Add("plates", err.Error())
+	}
+	if eq.Bars, err = ParseBars(bars); err != nil {
+		verr.Add("bars", err.Error())
+	}
+	step := func(field, s string) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || v <= 0 || v > MaxWeight || math.IsNaN(v) {
+			verr.Add(field, "invalid step")
+		}
+		return v
+	}
+	eq.DumbbellStep = step("dumbbell_step", dumbbellStep)
+	eq.MachineStep = step("machine_step", machineStep)
+	if err := verr.Err(); err != nil {
+		return Equipment{}, err
+	}
+	for key, v := range map[string]string{
+		platesSetting:       eq.PlatesString(),
+		barsSetting:         eq.BarsString(),
+		dumbbellStepSetting: strconv.FormatFloat(eq.DumbbellStep, 'f', -1, 64),
+		machineStepSetting:  strconv.FormatFloat(eq.MachineStep, 'f', -1, 64),
+	} {
+		if err := t.repo.SetSetting(ctx, key, v); err != nil {
+			return Equipment{}, err
+		}
+	}
+	return eq, nil
+}
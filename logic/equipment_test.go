@@ -0,0 +1,152 @@
+package logic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestLoadPlates(t *testing.T) {
+	eq := DefaultEquipment()
+	side, rest := eq.LoadPlates(87.5, 20)
+	if want := []float64{25, 5, 2.5, 1.25}; !slices.Equal(side, want) || rest != 0 {
+		t.Errorf("87.5 kg on a 20 kg bar: %v per side, %g left; want %v", side, rest, want)
+	}
+	if side, rest := eq.LoadPlates(20, 20); len(side) != 0 || rest != 0 {
+		t.Errorf("the empty bar needs %v, %g left", side, rest)
+	}
+
+	// only one pair of 25s
+	eq.Plates = []Plate{{25, 1}, {10, 0}, {1.25, 0}}
+	side, rest = eq.LoadPlates(130, 20)
+	if want := []float64{25, 10, 10, 10}; !slices.Equal(side, want) || rest != 0 {
+		t.Errorf("130 kg with one pair of 25s: %v per side, %g left; want %v", side, rest, want)
+	}
+	if _, rest := eq.LoadPlates(21, 20); rest != 0.5 {
+		t.Errorf("21 kg leaves %g per side, want 0.5", rest)
+	}
+
+	// the heaviest plate that fits is not always part of the answer
+	eq.Plates = []Plate{{25, 0}, {15, 0}}
+	side, rest = eq.LoadPlates(80, 20)
+	if want := []float64{15, 15}; !slices.Equal(side, want) || rest != 0 {
+		t.Errorf("80 kg with 25s and 15s: %v per side, %g left; want %v", side, rest, want)
+	}
+	if got := eq.Round("Barbell Squats", 80); got != 80 {
+		t.Errorf("Round(80) with 25s and 15s = %g, want 80", got)
+	}
+	eq.Plates = []Plate{{20, 1}, {15, 2}, {10, 1}}
+	side, rest = eq.LoadPlates(120, 20)
+	if want := []float64{20, 15, 15}; !slices.Equal(side, want) || rest != 0 {
+		t.Errorf("120 kg with limited pairs: %v per side, %g left; want %v", side, rest, want)
+	}
+	side, rest = eq.LoadPlates(85, 20)
+	if want := []float64{20, 10}; !slices.Equal(side, want) || rest != 2.5 {
+		t.Errorf("85 kg with no small plates: %v per side, %g left; want %v and 2.5", side, rest, want)
+	}
+}
+
+func TestEquipmentRound(t *testing.T) {
+	eq := DefaultEquipment()
+	for _, tc := range []struct {
+		exercise string
+		w, want  float64
+	}{
+		{"Barbell Squats", 87.5, 87.5},
+		{"Barbell Squats", 88, 87.5},
+		{"Barbell Squats", 10, 20},
+		{"Preacher Curl", 11, 10},
+		{"Dumbbell Rowing", 23, 22.5},
+		{"Leg Extensions", 62.5, 60},
+		{"Leg Extensions", 63, 65},
+		{"Plank", 7.3, 7.3},
+	} {
+		if got := eq.Round(tc.exercise, tc.w); got != tc.want {
+			t.Errorf("Round(%s, %g) = %g, want %g", tc.exercise, tc.w, got, tc.want)
+		}
+	}
+	for _, tc := range []struct {
+		exercise string
+		w, want  float64
+	}{
+		{"Barbell Squats", 100, 102.5},
+		{"Leg Extensions", 60, 65},
+		{"Dumbbell Rowing", 22.5, 25},
+		{"Plank", 0, weightStep},
+	} {
+		if got := eq.NextLoad(tc.exercise, tc.w); got != tc.want {
+			t.Errorf("NextLoad(%s, %g) = %g, want %g", tc.exercise, tc.w, got, tc.want)
+		}
+	}
+
+	// without 1.25s the bar goes up 5 kg at a time
+	eq.Plates = []Plate{{20, 0}, {10, 0}, {5, 0}, {2.5, 0}}
+	if got := eq.NextLoad("Barbell Squats", 100); got != 105 {
+		t.Errorf("NextLoad without 1.25 kg plates = %g, want 105", got)
+	}
+}
+
+func TestParseEquipment(t *testing.T) {
+	plates, err := ParsePlates("10, 25 x 2, 2.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Plate{{25, 2}, {10, 0}, {2.5, 0}}; !slices.Equal(plates, want) {
+		t.Errorf("plates %v, want %v heaviest first", plates, want)
+	}
+	if got := (Equipment{Plates: plates}).PlatesString(); got != "25×2, 10, 2.5" {
+		t.Errorf("PlatesString() = %q", got)
+	}
+	for _, bad := range []string{"", "0", "abc", "20x0", "20, 20"} {
+		if _, err := ParsePlates(bad); err == nil {
+			t.Errorf("ParsePlates(%q) accepted", bad)
+		}
+	}
+	bars, err := ParseBars("Trap=25, barbell=20")
+	if err != nil || bars["trap"] != 25 || bars[data.Barbell] != 20 {
+		t.Errorf("ParseBars = %v, %v", bars, err)
+	}
+	if got := (Equipment{Bars: bars}).BarsString(); got != "barbell=20, trap=25" {
+		t.Errorf("BarsString() = %q", got)
+	}
+	if _, err := ParseBars("barbell"); err == nil {
+		t.Error("ParseBars accepted a bar without a weight")
+	}
+}
+
+func TestEquipmentSettings(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	if eq, err := tr.Equipment(ctx); err != nil || eq.PlatesString() != DefaultEquipment().PlatesString() {
+		t.Fatalf("Equipment = %v, %v; want the defaults", eq, err)
+	}
+	if _, err := tr.SetEquipment(ctx, "20, 10, 5, 2.5", "barbell=15", "2", "7.5"); err != nil {
+		t.Fatal(err)
+	}
+	eq, _ := tr.Equipment(ctx)
+	if eq.Bars[data.Barbell] != 15 || eq.DumbbellStep != 2 || eq.MachineStep != 7.5 || len(eq.Plates) != 4 {
+		t.Errorf("saved equipment read back as %+v", eq)
+	}
+	_, err := tr.SetEquipment(ctx, "", "barbell=x", "0", "5")
+	var verr *data.ValidationError
+	if !errors.As(err, &verr) || verr.Reason("plates") == "" || verr.Reason("bars") == "" || verr.Reason("dumbbell_step") == "" || verr.Reason("machine_step") != "" {
+		t.Errorf("SetEquipment with bad values = %v", err)
+	}
+
+	// session targets move up to a loadable weight
+	date := data.Today().AddDays(-7).String()
+	for range 2 {
+		if _, err := tr.AddEntry(ctx, "Barbell Squats", "100", "12", "1", "", "", date); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := tr.StartSession(ctx, "Friday", 2, data.Today().Time())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.CurrentExercise().Target; got != (Target{105, repRangeLow, 2}) {
+		t.Errorf("squat target %+v, want 105 kg with no 1.25 kg plates", got)
+	}
+}
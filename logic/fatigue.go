@@ -0,0 +1,274 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"progresstracker/data"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deloadSetting is the settings key for the weeks marked as deloads, stored
+// as their Mondays separated by commas.
+const deloadSetting = "deload_weeks"
+
+// Fatigue model thresholds. The acute:chronic workload ratio compares the
+// last 7 days of volume with the weekly average of the last 28.
+const (
+	acwrHigh        = 1.3 // a fatigue signal
+	acwrSpike       = 1.5 // a deload on its own
+	rpeRise         = 0.5 // average RPE up this much on the two weeks before
+	e1rmDrop        = 0.05
+	dropLookback    = 3 // sessions the latest one is compared with
+	deloadAfter     = 8 // weeks of training before one signal is enough
+	chronicDays     = 28
+	rpeTrendDays    = 14
+	minChronicWeeks = 2 // of volume before the ratio means anything
+)
+
+// deloadSets is what program lifts do in a deload week, as in 5/3/1.
+var deloadSets = []PrescribedSet{{5, 40, false}, {5, 50, false}, {5, 60, false}}
+
+// weekOf returns the Monday of the week d falls in.
+func weekOf(d data.Date) data.Date {
+	return data.DateOf(weekStart(d.Time()))
+}
+
+// deloadWeeks reads the weeks marked as deloads, oldest first.
+func deloadWeeks(ctx context.Context, repo data.Store) ([]data.Date, error) {
+	v, ok, err := repo.Setting(ctx, deloadSetting)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var weeks []data.Date
+	for _, s := range strings.Split(v, ",") {
+		// a week that no longer reads is dropped
+		if d, err := data.ParseDate(strings.TrimSpace(s)); err == nil {
+			weeks = append(weeks, weekOf(d))
+		}
+	}
+	slices.Sort(weeks)
+	return slices.Compact(weeks), nil
+}
+
+// inDeload reports whether d falls in one of weeks.
+func inDeload(weeks []data.Date, d data.Date) bool {
+	_, ok := slices.BinarySearch(weeks, weekOf(d))
+	return ok
+}
+
+// DeloadWeeks returns the Mondays of the weeks marked as deloads, oldest
+// first.
+func (t *Tracker) DeloadWeeks(ctx context.Context) ([]data.Date, error) {
+	return deloadWeeks(ctx, t.repo)
+}
+
+// SetDeload marks the week day falls in as a deload, or unmarks it. In a
+// deload week program lifts get light sets and their cycles wait, and the
+// week's sessions are left out of stall detection.
+func (t *Tracker) SetDeload(ctx context.Context, day data.Date, deload bool) error {
+	weeks, err := t.DeloadWeeks(ctx)
+	if err != nil {
+		return err
+	}
+	week := weekOf(day)
+	weeks = slices.DeleteFunc(weeks, func(w data.Date) bool { return w == week })
+	if deload {
+		weeks = append(weeks, week)
+		slices.Sort(weeks)
+	}
+	parts := make([]string, len(weeks))
+	for i, w := range weeks {
+		parts[i] = w.String()
+	}
+	return t.repo.SetSetting(ctx, deloadSetting, strings.Join(parts, ","))
+}
+
+// PerformanceDrop is a lift whose latest session estimated a lower 1RM
+// than its best of the sessions before.
+type PerformanceDrop struct {
+	Exercise string
+	Date     data.Date
+	E1RM     float64
+	Best     float64
+}
+
+// Drop is the fall from Best as a fraction.
+func (d PerformanceDrop) Drop() float64 {
+	return 1 - d.E1RM/d.Best
+}
+
+// FatigueReport sums up how tired training looks and whether to deload.
+type FatigueReport struct {
+	Week             data.Date // Monday of the current week
+	AcuteVolume      float64   // last 7 days
+	ChronicVolume    float64   // weekly average of the last 28 days, deloads left out
+	ACWR             float64   // 0 without enough history
+	RPE              float64   // average of the last 14 days, 0 if none recorded
+	PrevRPE          float64   // of the 14 days before
+	Drops            []PerformanceDrop
+	WeeksSinceDeload int // -1 if there has never been one
+	Signals          []string
+	Deload           bool // a deload is recommended
+	Deloading        bool // the current week is marked as a deload
+}
+
+// Fatigue builds the fatigue report as of now.
+func (a *Analytics) Fatigue(ctx context.Context, now time.Time) (*FatigueReport, error) {
+	entries, err := a.repo.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deloads, err := deloadWeeks(ctx, a.repo)
+	if err != nil {
+		return nil, err
+	}
+	entries = workingSets(entries)
+	// entries come back newest first; walk them oldest first
+	slices.Reverse(entries)
+	return fatigue(entries, deloads, dateOnly(now)), nil
+}
+
+// fatigue works out the report from chrono, working sets oldest first.
+func fatigue(chrono []*data.Entry, deloads []data.Date, today time.Time) *FatigueReport {
+	day := data.DateOf(today)
+	r := &FatigueReport{
+		Week:             weekOf(day),
+		Deloading:        inDeload(deloads, day),
+		WeeksSinceDeload: -1,
+	}
+
+	acuteFrom := day.AddDays(-6)
+	chronicFrom := day.AddDays(-chronicDays + 1)
+	var rpeSum, prevSum float64
+	var rpeN, prevN int
+	first := data.Date("")
+	for _, e := range chrono {
+		if first == "" {
+			first = e.Date
+		}
+		if e.Date > day {
+			continue
+		}
+		if e.Date >= acuteFrom {
+			r.AcuteVolume += e.Volume
+		}
+		if e.Date >= chronicFrom && !inDeload(deloads, e.Date) {
+			r.ChronicVolume += e.Volume
+		}
+		if e.RPE > 0 {
+			switch {
+			case e.Date > day.AddDays(-rpeTrendDays):
+				rpeSum += e.RPE
+				rpeN++
+			case e.Date > day.AddDays(-2*rpeTrendDays):
+				prevSum += e.RPE
+				prevN++
+			}
+		}
+	}
+
+	// the chronic average only counts the days of the window that were
+	// trainable: after the first entry, and outside deload weeks
+	days := 0
+	for d := chronicFrom; d <= day; d = d.AddDays(1) {
+		if first != "" && d >= first && !inDeload(deloads, d) {
+			days++
+		}
+	}
+	if weeks := float64(days) / 7; weeks >= minChronicWeeks {
+		r.ChronicVolume /= weeks
+		if r.ChronicVolume > 0 {
+			r.ACWR = roundTo(r.AcuteVolume/r.ChronicVolume, 2)
+		}
+	} else {
+		r.ChronicVolume = 0
+	}
+	if rpeN > 0 {
+		r.RPE = roundTo(rpeSum/float64(rpeN), 1)
+	}
+	if prevN > 0 {
+		r.PrevRPE = roundTo(prevSum/float64(prevN), 1)
+	}
+	r.Drops = performanceDrops(chrono, deloads, day)
+
+	if n := len(deloads); n > 0 {
+		if last := deloads[n-1]; last <= r.Week {
+			r.WeeksSinceDeload = r.Week.DaysSince(last) / 7
+		}
+	}
+
+	if r.Deloading {
+		return r
+	}
+	signals := 0
+	switch {
+	case r.ACWR > acwrSpike:
+		r.Signals = append(r.Signals, fmt.Sprintf("volume spiked: %.2f× the 4-week average", r.ACWR))
+		r.Deload = true
+	case r.ACWR > acwrHigh:
+		r.Signals = append(r.Signals, fmt.Sprintf("volume up: %.2f× the 4-week average", r.ACWR))
+		signals++
+	}
+	if r.RPE > 0 && r.PrevRPE > 0 && r.RPE-r.PrevRPE >= rpeRise {
+		r.Signals = append(r.Signals, fmt.Sprintf("sets feel harder: RPE %g, up from %g", r.RPE, r.PrevRPE))
+		signals++
+	}
+	if len(r.Drops) >= 2 {
+		names := make([]string, len(r.Drops))
+		for i, d := range r.Drops {
+			names[i] = d.Exercise
+		}
+		r.Signals = append(r.Signals, "estimated 1RM down on "+strings.Join(names, ", "))
+		signals++
+	}
+	long := r.WeeksSinceDeload >= deloadAfter || (r.WeeksSinceDeload < 0 && first != "" && day.DaysSince(first) >= deloadAfter*7)
+	if long {
+		r.Signals = append(r.Signals, fmt.Sprintf("no deload in %d weeks or more", deloadAfter))
+	}
+	if signals >= 2 || (long && signals >= 1) {
+		r.Deload = true
+	}
+	return r
+}
+
+// performanceDrops finds lifts whose last session, within the last two
+// weeks, estimated a 1RM well under their best of the sessions before it.
+// Sessions in deload weeks are light on purpose and are left out.
+func performanceDrops(chrono []*data.Entry, deloads []data.Date, day data.Date) []PerformanceDrop {
+	type session struct {
+		date data.Date
+		e1rm float64
+	}
+	byEx := map[string][]session{}
+	for _, e := range chrono {
+		if e.Date > day || inDeload(deloads, e.Date) {
+			continue
+		}
+		v := EffortE1RM(e.Weight, e.Reps, e.RPE)
+		ss := byEx[e.Exercise]
+		if n := len(ss); n > 0 && ss[n-1].date == e.Date {
+			ss[n-1].e1rm = max(ss[n-1].e1rm, v)
+			continue
+		}
+		byEx[e.Exercise] = append(ss, session{e.Date, v})
+	}
+	var drops []PerformanceDrop
+	for ex, ss := range byEx {
+		n := len(ss)
+		if n <= dropLookback || ss[n-1].date <= day.AddDays(-rpeTrendDays) {
+			continue
+		}
+		best := 0.0
+		for _, s := range ss[n-1-dropLookback : n-1] {
+			best = max(best, s.e1rm)
+		}
+		if last := ss[n-1]; last.e1rm < best*(1-e1rmDrop) {
+			drops = append(drops, PerformanceDrop{Exercise: ex, Date: last.date, E1RM: roundTo(last.e1rm, 1), Best: roundTo(best, 1)})
+		}
+	}
+	sort.Slice(drops, func(i, j int) bool { return drops[i].Exercise < drops[j].Exercise })
+	return drops
+}
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func fatigueEntry(exercise string, weight float64, reps int, rpe float64, date data.Date) *data.Entry {
+	return &data.Entry{Exercise: exercise, Weight: weight, Reps: reps, Sets: 1, Volume: weight * float64(reps), RPE: rpe, Date: date}
+}
+
+func TestSetDeload(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	// any day marks its whole week, held as the Monday
+	for _, d := range []data.Date{"2025-03-13", "2025-03-05", "2025-03-07"} {
+		if err := tr.SetDeload(ctx, d, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	weeks, err := tr.DeloadWeeks(ctx)
+	if want := []data.Date{"2025-03-03", "2025-03-10"}; err != nil || !slices.Equal(weeks, want) {
+		t.Fatalf("DeloadWeeks = %v, %v; want %v", weeks, err, want)
+	}
+	if err := tr.SetDeload(ctx, "2025-03-09", false); err != nil {
+		t.Fatal(err)
+	}
+	if weeks, _ := tr.DeloadWeeks(ctx); !slices.Equal(weeks, []data.Date{"2025-03-10"}) {
+		t.Errorf("after unmarking, DeloadWeeks = %v", weeks)
+	}
+}
+
+func TestFatigueACWR(t *testing.T) {
+	today := time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)
+	var chrono []*data.Entry
+	for d := data.Date("2025-02-03"); d <= "2025-03-17"; d = d.AddDays(7) {
+		chrono = append(chrono, fatigueEntry("Leg Press", 100, 10, 0, d))
+	}
+	chrono = append(chrono, fatigueEntry("Leg Press", 200, 10, 0, "2025-03-24"))
+
+	r := fatigue(chrono, nil, today)
+	if r.AcuteVolume != 2000 || r.ChronicVolume != 1250 || r.ACWR != 1.6 || !r.Deload {
+		t.Errorf("spike: acute %g, chronic %g, ACWR %g, deload %v; want 2000, 1250, 1.6 and a deload", r.AcuteVolume, r.ChronicVolume, r.ACWR, r.Deload)
+	}
+
+	// a deload week is left out of the chronic average
+	chrono[5] = fatigueEntry("Leg Press", 50, 10, 0, "2025-03-10")
+	r = fatigue(chrono, []data.Date{"2025-03-10"}, today)
+	if r.ACWR != 1.5 || r.Deload || len(r.Signals) != 1 {
+		t.Errorf("with a deload week: ACWR %g, deload %v, signals %q; want 1.5 and one signal", r.ACWR, r.Deload, r.Signals)
+	}
+	if r.WeeksSinceDeload != 2 {
+		t.Errorf("WeeksSinceDeload = %d, want 2", r.WeeksSinceDeload)
+	}
+
+	// too little history for a ratio
+	if r := fatigue(chrono[len(chrono)-1:], nil, today); r.ACWR != 0 || r.Deload {
+		t.Errorf("one session: ACWR %g, deload %v; want neither", r.ACWR, r.Deload)
+	}
+	if r := fatigue(chrono, []data.Date{"2025-03-24"}, today); !r.Deloading || r.Deload {
+		t.Errorf("in a deload week: deloading %v, deload %v", r.Deloading, r.Deload)
+	}
+}
+
+func TestFatigueRPEAndDrops(t *testing.T) {
+	today := time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)
+	var chrono []*data.Entry
+	for _, d := range []data.Date{"2025-03-03", "2025-03-10", "2025-03-17"} {
+		chrono = append(chrono,
+			fatigueEntry("Barbell Squats", 100, 5, 7, d),
+			fatigueEntry("Flat Bench Barbell Chest Press", 80, 5, 7, d))
+	}
+	chrono = append(chrono,
+		fatigueEntry("Barbell Squats", 90, 5, 9, "2025-03-24"),
+		fatigueEntry("Flat Bench Barbell Chest Press", 70, 5, 9, "2025-03-24"))
+
+	r := fatigue(chrono, nil, today)
+	if r.RPE != 8 || r.PrevRPE != 7 {
+		t.Errorf("RPE %g, previous %g; want 8 and 7", r.RPE, r.PrevRPE)
+	}
+	if len(r.Drops) != 2 || r.Drops[0].Exercise != "Barbell Squats" || r.Drops[0].Drop() < 0.1 {
+		t.Errorf("drops = %+v", r.Drops)
+	}
+	if !r.Deload || len(r.Signals) != 2 {
+		t.Errorf("deload %v, signals %q; want a deload for two signals", r.Deload, r.Signals)
+	}
+
+	// the light sessions of a deload week are no drop
+	if r := fatigue(chrono, []data.Date{"2025-03-24"}, today); len(r.Drops) != 0 {
+		t.Errorf("drops in a deload week = %+v", r.Drops)
+	}
+}
+
+func TestStallSkipsDeloadWeeks(t *testing.T) {
+	today := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	var chrono []*data.Entry
+	for _, s := range []struct {
+		date   data.Date
+		weight float64
+	}{{"2025-02-17", 100}, {"2025-02-24", 105}, {"2025-03-03", 60}, {"2025-03-10", 60}, {"2025-03-17", 105}} {
+		chrono = append(chrono, fatigueEntry("Barbell Squats", s.weight, 5, 0, s.date))
+	}
+	if got := needsAttention(chrono, today, nil); len(got) != 1 {
+		t.Fatalf("without deloads, attention = %v; want a stall", got)
+	}
+	if got := needsAttention(chrono, today, []data.Date{"2025-03-03", "2025-03-10"}); len(got) != 0 {
+		t.Errorf("with the light weeks as deloads, attention = %v; want none", got)
+	}
+}
+
+func TestDeloadWeekPausesProgram(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	if _, err := tr.SetProgram(ctx, "531", "Barbell Squats=100", "2025-03-03"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.SetDeload(ctx, "2025-03-10", true); err != nil {
+		t.Fatal(err)
+	}
+	prs, err := tr.Prescriptions(ctx, "2025-03-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr := prs[0]; !pr.Deload || pr.Week != 1 || pr.Sets[2].Weight != 60 {
+		t.Errorf("deload week prescription = %+v", pr)
+	}
+	if _, err := tr.AddEntry(ctx, "Barbell Squats", "60", "5", "3", "", "", "2025-03-12"); err != nil {
+		t.Fatal(err)
+	}
+	prs, _ = tr.Prescriptions(ctx, "2025-03-17")
+	if pr := prs[0]; pr.Deload || pr.Week != 1 {
+		t.Errorf("after the deload week = %+v, want week 1 again", pr)
+	}
+}
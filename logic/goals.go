@@ -0,0 +1,371 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"progresstracker/data"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Goal forecasting fits a least-squares line through the values of the
+// last trendDays and needs minTrendPoints of them.
+const (
+	trendDays       = 56
+	minTrendPoints  = 3
+	maxForecastDays = 730
+	MinBodyweight   = 20
+	MaxBodyweight   = 400
+)
+
+// GoalProgress is a goal with where it stands and where it is heading.
+type GoalProgress struct {
+	*data.Goal
+	Title    string    `json:"title"`
+	Current  float64   `json:"current"`  // latest value, 0 if none
+	Progress float64   `json:"progress"` // share of the way from Start to Target, 0 to 1
+	PerWeek  float64   `json:"per_week"` // trend in kg a week, 0 without one
+	Forecast data.Date `json:"forecast,omitempty"`
+	OnTrack  bool      `json:"on_track"`
+	Status   string    `json:"status"`
+}
+
+// GoalTitle reads like the goal was typed: "Barbell Squats 140 kg by
+// 2027-03-01".
+func GoalTitle(g *data.Goal) string {
+	var s string
+	switch g.Metric {
+	case data.GoalBodyweight:
+		s = fmt.Sprintf("Bodyweight %g kg", g.Target)
+	case data.GoalE1RM:
+		s = fmt.Sprintf("%s e1RM %g kg", g.Exercise, g.Target)
+	default:
+		s = fmt.Sprintf("%s %g kg", g.Exercise, g.Target)
+	}
+	if g.By != "" {
+		s += " by " + g.By.String()
+	}
+	return s
+}
+
+// rising reports whether g is reached by going up, which every lift goal
+// is; a bodyweight goal goes whichever way its target lies from the start.
+func rising(g *data.Goal) bool {
+	return g.Metric != data.GoalBodyweight || g.Target >= g.Start
+}
+
+func reaches(g *data.Goal, v float64) bool {
+	if rising(g) {
+		return v >= g.Target
+	}
+	return v <= g.Target
+}
+
+// BodyweightOverTime returns the bodyweight readings, oldest first.
+func (a *Analytics) BodyweightOverTime(ctx context.Context) ([]ChartPoint, error) {
+	readings, err := a.repo.Bodyweights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pts := make([]ChartPoint, len(readings))
+	for i, b := range readings {
+		pts[i] = ChartPoint{Date: b.Date, Value: b.Weight}
+	}
+	return pts, nil
+}
+
+// goalSeries returns the values g is measured against, oldest first.
+func (a *Analytics) goalSeries(ctx context.Context, g *data.Goal) ([]ChartPoint, error) {
+	switch g.Metric {
+	case data.GoalBodyweight:
+		return a.BodyweightOverTime(ctx)
+	case data.GoalE1RM:
+		return a.E1RMOverTime(ctx, g.Exercise)
+	}
+	return a.WeightOverTime(ctx, g.Exercise)
+}
+
+// Goals returns every goal with its progress and forecast as of now, the
+// open ones first.
+func (a *Analytics) Goals(ctx context.Context, now time.Time) ([]*GoalProgress, error) {
+	goals, err := a.repo.Goals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := data.DateOf(dateOnly(now))
+	var out []*GoalProgress
+	for _, g := range goals {
+		pts, err := a.goalSeries(ctx, g)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, goalProgress(g, pts, today))
+	}
+	slices.SortStableFunc(out, func(x, y *GoalProgress) int {
+		return boolCmp(x.Achieved != "", y.Achieved != "")
+	})
+	return out, nil
+}
+
+func boolCmp(x, y bool) int {
+	switch {
+	case x == y:
+		return 0
+	case x:
+		return 1
+	}
+	return -1
+}
+
+// goalProgress works out where g stands from its series pts.
+func goalProgress(g *data.Goal, pts []ChartPoint, today data.Date) *GoalProgress {
+	c := *g
+	p := &GoalProgress{Goal: &c, Title: GoalTitle(g)}
+	if n := len(pts); n > 0 {
+		p.Current = pts[n-1].Value
+	}
+	if p.Achieved == "" {
+		p.Achieved = reachedOn(g, pts)
+	}
+	if p.Achieved != "" {
+		p.Progress, p.OnTrack = 1, true
+		p.Status = "reached on " + p.Achieved.String()
+		return p
+	}
+	if span := g.Target - g.Start; span != 0 && p.Current != 0 {
+		p.Progress = roundTo(min(max((p.Current-g.Start)/span, 0), 1), 2)
+	}
+
+	slope, fitted, ok := trend(pts, today)
+	if !ok {
+		p.Status = "not enough recent data for a forecast"
+		return p
+	}
+	p.PerWeek = roundTo(slope*7, 2)
+	if slope == 0 || (slope > 0) != rising(g) {
+		p.Status = "not moving toward the target"
+		return p
+	}
+	days := max(int(math.Ceil((g.Target-fitted)/slope)), 0)
+	if days > maxForecastDays {
+		p.Status = "more than two years away at this rate"
+		return p
+	}
+	p.Forecast = today.AddDays(days)
+	p.OnTrack = g.By == "" || p.Forecast <= g.By
+	switch {
+	case g.By != "" && today > g.By:
+		p.Status = fmt.Sprintf("past its date; at this rate %s", p.Forecast)
+	case p.OnTrack:
+		p.Status = fmt.Sprintf("on track: about %s", p.Forecast)
+	default:
+		p.Status = fmt.Sprintf("behind: about %s, %s late", p.Forecast, plural(p.Forecast.DaysSince(g.By), "day"))
+	}
+	return p
+}
+
+// reachedOn returns the first day since g was set whose value reached the
+// target, or "" if none has.
+func reachedOn(g *data.Goal, pts []ChartPoint) data.Date {
+	for _, pt := range pts {
+		if pt.Date >= g.SetOn && reaches(g, pt.Value) {
+			return pt.Date
+		}
+	}
+	return ""
+}
+
+// trend fits a line through the points of the last trendDays up to today
+// and returns its slope per day and its value today.
+func trend(pts []ChartPoint, today data.Date) (slope, fitted float64, ok bool) {
+	from := today.AddDays(-trendDays)
+	var xs, ys []float64
+	for _, pt := range pts {
+		if pt.Date >= from && pt.Date <= today {
+			xs = append(xs, float64(pt.Date.DaysSince(from)))
+			ys = append(ys, pt.Value)
+		}
+	}
+	n := float64(len(xs))
+	if len(xs) < minTrendPoints {
+		return 0, 0, false
+	}
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sxy - sx*sy) / d
+	fitted = (sy-slope*sx)/n + slope*trendDays
+	return slope, fitted, true
+}
+
+// ParseGoal splits a goal typed as "Barbell Squats 140 kg by 2027-03-01",
+// "Barbell Squats e1rm 150" or "bodyweight 80" into the arguments of
+// AddGoal. Whatever it cannot make sense of is left for AddGoal to reject.
+func ParseGoal(s string) (metric, exercise, target, by string) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(strings.ToLower(s), " by "); i >= 0 {
+		s, by = strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+4:])
+	}
+	fields := strings.Fields(s)
+	if n := len(fields); n > 0 && strings.EqualFold(fields[n-1], "kg") {
+		fields = fields[:n-1]
+	}
+	if n := len(fields); n > 0 {
+		target, fields = fields[n-1], fields[:n-1]
+		if v, ok := strings.CutSuffix(strings.ToLower(target), "kg"); ok && v != "" {
+			target = v
+		}
+	}
+	metric = data.GoalWeight
+	if n := len(fields); n > 0 && strings.EqualFold(fields[n-1], data.GoalE1RM) {
+		metric, fields = data.GoalE1RM, fields[:n-1]
+	}
+	exercise = strings.Join(fields, " ")
+	if strings.EqualFold(exercise, data.GoalBodyweight) {
+		metric, exercise = data.GoalBodyweight, ""
+	}
+	return metric, exercise, target, by
+}
+
+// AddGoal validates and saves a goal set on today. metric is "weight",
+// "e1rm" or "bodyweight"; exercise is matched without regard to case and
+// left empty for bodyweight. by is optional. Bad values are reported as a
+// *data.ValidationError for the fields "metric", "exercise", "target" and
+// "by".
+func (t *Tracker) AddGoal(ctx context.Context, metric, exercise, target, by string, today data.Date) (*data.Goal, error) {
+	var verr data.ValidationError
+	g := &data.Goal{Metric: strings.ToLower(strings.TrimSpace(metric)), SetOn: today}
+	if g.Metric == "" {
+		g.Metric = data.GoalWeight
+	}
+	switch g.Metric {
+	case data.GoalWeight, data.GoalE1RM:
+		name := strings.TrimSpace(exercise)
+		i := slices.IndexFunc(data.AllExercises(), func(ex string) bool { return strings.EqualFold(ex, name) })
+		if i < 0 {
+			verr.Add("exercise", fmt.Sprintf("unknown exercise %q", name))
+		} else {
+			g.Exercise = data.AllExercises()[i]
+		}
+	case data.GoalBodyweight:
+		if strings.TrimSpace(exercise) != "" {
+			verr.Add("exercise", "a bodyweight goal has no exercise")
+		}
+	default:
+		verr.Add("metric", "metric must be weight, e1rm or bodyweight")
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(target), 64)
+	switch {
+	case g.Metric == data.GoalBodyweight:
+		if err != nil || math.IsNaN(v) || v < MinBodyweight || v > MaxBodyweight {
+			verr.Add("target", fmt.Sprintf("bodyweight must be %d to %d kg", MinBodyweight, MaxBodyweight))
+		}
+	case err != nil || v <= 0 || v > MaxWeight || math.IsNaN(v):
+		verr.Add("target", "invalid target weight")
+	}
+	g.Target = v
+	if by = strings.TrimSpace(by); by != "" {
+		d, err := data.ResolveDate(by, today)
+		switch {
+		case err != nil:
+			verr.Add("by", "invalid date, want YYYY-MM-DD")
+		case d <= today:
+			verr.Add("by", "target date is not in the future")
+		}
+		g.By = d
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	pts, err := NewAnalytics(t.repo).goalSeries(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(pts); n > 0 {
+		g.Start = pts[n-1].Value
+	}
+	switch {
+	case g.Metric == data.GoalBodyweight && g.Start == 0:
+		verr.Add("metric", "log your bodyweight before setting a goal for it")
+	case g.Start == g.Target || (g.Metric != data.GoalBodyweight && g.Start > g.Target):
+		verr.Add("target", fmt.Sprintf("already at %g kg", g.Start))
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	if err := t.repo.SaveGoal(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// DeleteGoal removes a goal, failing with data.ErrNotFound if there is none
+// with id.
+func (t *Tracker) DeleteGoal(ctx context.Context, id int64) error {
+	return t.repo.DeleteGoal(ctx, id)
+}
+
+// CheckGoals records the day each open goal was reached and returns the
+// ones reached since the last check, for a notification.
+func (t *Tracker) CheckGoals(ctx context.Context) ([]*data.Goal, error) {
+	goals, err := t.repo.Goals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	anal := NewAnalytics(t.repo)
+	var reached []*data.Goal
+	for _, g := range goals {
+		if g.Achieved != "" {
+			continue
+		}
+		pts, err := anal.goalSeries(ctx, g)
+		if err != nil {
+			return nil, err
+		}
+		if g.Achieved = reachedOn(g, pts); g.Achieved == "" {
+			continue
+		}
+		if err := t.repo.UpdateGoal(ctx, g); err != nil {
+			return nil, err
+		}
+		reached = append(reached, g)
+	}
+	return reached, nil
+}
+
+// LogBodyweight validates and records the bodyweight of date, replacing any
+// reading of that day. An empty date is today. Bad values are reported as a
+// *data.ValidationError for the fields "weight" and "date".
+func (t *Tracker) LogBodyweight(ctx context.Context, weight, date string) (data.Bodyweight, error) {
+	var verr data.ValidationError
+	var b data.Bodyweight
+	var err error
+	b.Weight, err = strconv.ParseFloat(strings.TrimSpace(weight), 64)
+	if err != nil || math.IsNaN(b.Weight) || b.Weight < MinBodyweight || b.Weight > MaxBodyweight {
+		verr.Add("weight", fmt.Sprintf("bodyweight must be %d to %d kg", MinBodyweight, MaxBodyweight))
+	}
+	today := data.Today()
+	if date == "" {
+		b.Date = today
+	} else if b.Date, err = data.ResolveDate(date, today); err != nil {
+		verr.Add("date", "invalid date, want YYYY-MM-DD")
+	} else if b.Date > today {
+		verr.Add("date", "date is in the future")
+	}
+	if err := verr.Err(); err != nil {
+		return data.Bodyweight{}, err
+	}
+	return b, t.repo.SetBodyweight(ctx, b)
+}
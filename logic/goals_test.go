@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestParseGoal(t *testing.T) {
+	for _, tc := range []struct {
+		in                           string
+		metric, exercise, target, by string
+	}{
+		{"Barbell Squats 140 kg by 2027-03-01", "weight", "Barbell Squats", "140", "2027-03-01"},
+		{"barbell squats 140kg", "weight", "barbell squats", "140", ""},
+		{"Flat Bench Barbell Chest Press e1rm 120 BY +8w", "e1rm", "Flat Bench Barbell Chest Press", "120", "+8w"},
+		{"bodyweight 80", "bodyweight", "", "80", ""},
+		{"", "weight", "", "", ""},
+	} {
+		m, ex, tg, by := ParseGoal(tc.in)
+		if m != tc.metric || ex != tc.exercise || tg != tc.target || by != tc.by {
+			t.Errorf("ParseGoal(%q) = %q, %q, %q, %q", tc.in, m, ex, tg, by)
+		}
+	}
+}
+
+func TestAddGoal(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	today := data.Date("2025-03-03")
+
+	_, err := tr.AddGoal(ctx, "weight", "Leg Day", "-1", "2025-01-01", today)
+	var ve *data.ValidationError
+	if !errors.As(err, &ve) || ve.Reason("exercise") == "" || ve.Reason("target") == "" || ve.Reason("by") == "" {
+		t.Fatalf("AddGoal with bad values = %v, want exercise, target and by rejected", err)
+	}
+	if _, err := tr.AddGoal(ctx, "bodyweight", "", "80", "", today); !errors.As(err, &ve) || ve.Reason("metric") == "" {
+		t.Errorf("bodyweight goal without a reading = %v, want it rejected", err)
+	}
+	for _, target := range []string{"500", "10"} {
+		if _, err := tr.AddGoal(ctx, "bodyweight", "", target, "", today); !errors.As(err, &ve) || ve.Reason("target") == "" {
+			t.Errorf("bodyweight goal of %s kg = %v, want the target rejected", target, err)
+		}
+	}
+
+	if _, err := tr.AddEntry(ctx, "Barbell Squats", "120", "5", "3", "", "", "2025-03-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.AddGoal(ctx, "", "barbell squats", "110", "", today); !errors.As(err, &ve) || ve.Reason("target") == "" {
+		t.Errorf("goal under the current weight = %v, want it rejected", err)
+	}
+	g, err := tr.AddGoal(ctx, "", "barbell squats", "140", "+12w", today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Exercise != "Barbell Squats" || g.Metric != "weight" || g.Start != 120 || g.By != "2025-05-26" || g.SetOn != today {
+		t.Errorf("goal = %+v", g)
+	}
+	if got := GoalTitle(g); got != "Barbell Squats 140 kg by 2025-05-26" {
+		t.Errorf("GoalTitle = %q", got)
+	}
+}
+
+func TestGoalForecast(t *testing.T) {
+	today := data.Date("2025-03-03")
+	// 2.5 kg a week for eight weeks, up to 120 today
+	var pts []ChartPoint
+	for i := 8; i >= 0; i-- {
+		pts = append(pts, ChartPoint{Date: today.AddDays(-7 * i), Value: 120 - 2.5*float64(i)})
+	}
+	g := &data.Goal{Metric: data.GoalWeight, Exercise: "Barbell Squats", Target: 130, Start: 100, SetOn: "2025-01-06"}
+
+	p := goalProgress(g, pts, today)
+	if p.Current != 120 || p.Progress != 0.67 || p.PerWeek != 2.5 || p.Forecast != "2025-03-31" || !p.OnTrack {
+		t.Errorf("open goal = %+v", p)
+	}
+	g.By = "2025-03-24"
+	if p := goalProgress(g, pts, today); p.OnTrack || p.Status != "behind: about 2025-03-31, 7 days late" {
+		t.Errorf("goal due too soon = %+v", p)
+	}
+
+	// a bodyweight goal going down while the trend goes up
+	bw := &data.Goal{Metric: data.GoalBodyweight, Target: 110, Start: 120, SetOn: today}
+	if p := goalProgress(bw, pts, today); p.Forecast != "" || p.Status != "not moving toward the target" {
+		t.Errorf("goal against the trend = %+v", p)
+	}
+	if p := goalProgress(g, pts[len(pts)-2:], today); p.Forecast != "" || p.PerWeek != 0 {
+		t.Errorf("goal with two points = %+v, want no forecast", p)
+	}
+	g.Target = 115
+	if p := goalProgress(g, pts, today); p.Achieved != "2025-02-17" || p.Progress != 1 {
+		t.Errorf("reached goal = %+v", p)
+	}
+}
+
+func TestCheckGoals(t *testing.T) {
+	ctx := t.Context()
+	repo := data.NewMemoryStore()
+	tr := NewTracker(repo)
+	if _, err := tr.LogBodyweight(ctx, "84", "2025-03-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.LogBodyweight(ctx, "500", "2025-03-01"); err == nil {
+		t.Error("LogBodyweight took 500 kg")
+	}
+	bw, err := tr.AddGoal(ctx, "bodyweight", "", "82", "", "2025-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reached, err := tr.CheckGoals(ctx); err != nil || len(reached) != 0 {
+		t.Fatalf("CheckGoals before any progress = %v, %v", reached, err)
+	}
+	if _, err := tr.LogBodyweight(ctx, "81.8", "2025-03-09"); err != nil {
+		t.Fatal(err)
+	}
+	reached, err := tr.CheckGoals(ctx)
+	if err != nil || len(reached) != 1 || reached[0].ID != bw.ID || reached[0].Achieved != "2025-03-09" {
+		t.Fatalf("CheckGoals = %v, %v; want the bodyweight goal reached on 2025-03-09", reached, err)
+	}
+	// a goal is only reported once
+	if reached, _ := tr.CheckGoals(ctx); len(reached) != 0 {
+		t.Errorf("second CheckGoals = %v, want none", reached)
+	}
+	goals, err := NewAnalytics(repo).Goals(ctx, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(goals) != 1 || goals[0].Status != "reached on 2025-03-09" {
+		t.Errorf("Goals = %+v, %v", goals, err)
+	}
+}
@@ -0,0 +1,100 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"progresstracker/data"
+)
+
+// An entry looks like a typo when its weight or estimated one-rep max is
+// more than outlierJump above the best of the outlierWindow entries logged
+// before it. Only jumps up are flagged: a low entry does not touch the PB.
+const (
+	outlierJump   = 0.4
+	outlierWindow = 5
+)
+
+// Outlier says why an entry stands out from recent history.
+type Outlier struct {
+	Measure  string  // "weight" or "e1RM"
+	Value    float64 // kg
+	Baseline float64 // best of the recent entries, kg
+}
+
+// Change is the jump over the baseline as a fraction, e.g. 0.5 for +50%.
+func (o *Outlier) Change() float64 {
+	return o.Value/o.Baseline - 1
+}
+
+func (o *Outlier) String() string {
+	return fmt.Sprintf("%s of %.1f kg is %.0f%% above the recent best of %.1f kg",
+		o.Measure, o.Value, o.Change()*100, o.Baseline)
+}
+
+// E1RM estimates a one-rep max with the Epley formula.
+func E1RM(weight float64, reps int) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// checkOutlier compares e against earlier entries of the same exercise,
+// newest first. It returns nil when e is in line with them or there is
+// nothing to compare with.
+func checkOutlier(e *data.Entry, earlier []*data.Entry) *Outlier {
+	if len(earlier) > outlierWindow {
+		earlier = earlier[:outlierWindow]
+	}
+	var bestWeight, bestE1RM float64
+	for _, p := range earlier {
+		bestWeight = max(bestWeight, p.Weight)
+		bestE1RM = max(bestE1RM, E1RM(p.Weight, p.Reps))
+	}
+	if bestWeight > 0 && e.Weight > bestWeight*(1+outlierJump) {
+		return &Outlier{Measure: "weight", Value: e.Weight, Baseline: bestWeight}
+	}
+	if v := E1RM(e.Weight, e.Reps); bestE1RM > 0 && v > bestE1RM*(1+outlierJump) {
+		return &Outlier{Measure: "e1RM", Value: v, Baseline: bestE1RM}
+	}
+	return nil
+}
+
+// FlagOutliers checks every entry of one exercise's history, newest first as
+// returned by GetHistory, against the entries before it. The result is keyed
+// by entry ID and only holds suspicious entries. Warm-ups are neither
+// checked nor used as a baseline.
+func FlagOutliers(history []*data.Entry) map[int64]*Outlier {
+	flags := map[int64]*Outlier{}
+	history = workingSets(history)
+	for i, e := range history {
+		if o := checkOutlier(e, history[i+1:]); o != nil {
+			flags[e.ID] = o
+		}
+	}
+	return flags
+}
+
+// CheckEntry validates form values like AddEntry without saving them, and
+// reports whether the entry would stand out from the exercise's recent
+// history. The UI asks for confirmation before saving an outlier.
+func (t *Tracker) CheckEntry(ctx context.Context, exercise, weightStr, repsStr, setsStr, effort, notes, date string) (*Outlier, error) {
+	if date == "" {
+		date = data.Today().String()
+	}
+	e, err := parseEntry(exercise, weightStr, repsStr, setsStr, effort, notes, date, UnitKg)
+	if err != nil {
+		return nil, err
+	}
+	history, err := t.repo.HistoryFor(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+	history = workingSets(history)
+	// a new entry goes after everything already logged on its date
+	i := 0
+	for i < len(history) && history[i].Date > e.Date {
+		i++
+	}
+	return checkOutlier(e, history[i:]), nil
+}
@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestCheckEntryFlagsJumps(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	for i, w := range []string{"75", "80", "80"} {
+		if _, err := tr.AddEntry(ctx, "Squat", w, "5", "3", "", "", fmt.Sprintf("2024-01-%02d", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		weight, reps, date string
+		measure            string
+	}{
+		{"85", "5", "2024-01-04", ""},
+		{"110", "5", "2024-01-04", ""},
+		{"800", "5", "2024-01-04", "weight"},
+		{"80", "50", "2024-01-04", "e1RM"},
+		{"800", "5", "2023-12-01", ""}, // nothing logged before it
+	} {
+		o, err := tr.CheckEntry(ctx, "Squat", tc.weight, tc.reps, "3", "", "", tc.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if o != nil {
+			got = o.Measure
+		}
+		if got != tc.measure {
+			t.Errorf("CheckEntry(%s × %s on %s) flagged %q (%v), want %q", tc.weight, tc.reps, tc.date, got, o, tc.measure)
+		}
+	}
+	if all, _ := tr.GetAllEntries(ctx); len(all) != 3 {
+		t.Errorf("CheckEntry saved entries: %d stored, want 3", len(all))
+	}
+}
+
+func TestFlagOutliers(t *testing.T) {
+	// newest first, like GetHistory
+	history := []*data.Entry{
+		{ID: 5, Weight: 82, Reps: 5},
+		{ID: 4, Weight: 800, Reps: 5},
+		{ID: 3, Weight: 80, Reps: 5},
+		{ID: 2, Weight: 80, Reps: 5},
+		{ID: 1, Weight: 75, Reps: 5},
+	}
+	flags := FlagOutliers(history)
+	if len(flags) != 1 || flags[4] == nil {
+		t.Fatalf("flags = %v, want only entry 4", flags)
+	}
+	if o := flags[4]; o.Measure != "weight" || o.Baseline != 80 || o.Change() != 9 {
+		t.Errorf("entry 4 flagged as %+v", o)
+	}
+}
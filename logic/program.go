@@ -0,0 +1,407 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"progresstracker/data"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// programSetting is the settings key for the periodized program, stored as
+// JSON.
+const programSetting = "program"
+
+// Training max increments per cycle: more for lower body lifts.
+const (
+	upperIncrement = 2.5
+	lowerIncrement = 5
+)
+
+// PrescribedSet is one set of a program week: reps at a percentage of the
+// training max. An AMRAP set is as many reps as possible, at least Reps.
+type PrescribedSet struct {
+	Reps    int     `json:"reps"`
+	Percent float64 `json:"percent"`
+	AMRAP   bool    `json:"amrap,omitempty"`
+}
+
+// Template generates a program. Every lift goes through the same weeks, and
+// its training max goes up by its increment at the end of each cycle.
+type Template struct {
+	Name  string // as given to SetProgram
+	Title string
+	Weeks [][]PrescribedSet
+}
+
+// straight is n sets of reps at pct of the training max.
+func straight(n, reps int, pct float64) []PrescribedSet {
+	s := make([]PrescribedSet, n)
+	for i := range s {
+		s[i] = PrescribedSet{Reps: reps, Percent: pct}
+	}
+	return s
+}
+
+var Templates = []Template{
+	{"linear", "Linear progression", [][]PrescribedSet{
+		straight(3, 5, 90),
+	}},
+	{"531", "5/3/1", [][]PrescribedSet{
+		{{5, 65, false}, {5, 75, false}, {5, 85, true}},
+		{{3, 70, false}, {3, 80, false}, {3, 90, true}},
+		{{5, 75, false}, {3, 85, false}, {1, 95, true}},
+		{{5, 40, false}, {5, 50, false}, {5, 60, false}},
+	}},
+	{"dup", "Daily undulating", [][]PrescribedSet{
+		straight(3, 10, 65),
+		straight(4, 6, 75),
+		straight(5, 3, 85),
+	}},
+}
+
+// TemplateNamed returns the template called name.
+func TemplateNamed(name string) (Template, bool) {
+	i := slices.IndexFunc(Templates, func(t Template) bool { return t.Name == name })
+	if i < 0 {
+		return Template{}, false
+	}
+	return Templates[i], true
+}
+
+// ProgramLift is a lift the program prescribes, with its own cycle. A
+// lift's week moves on each time it is trained, so lifts on the alternating
+// Week 1/Week 2 plan still go through every week of the template.
+type ProgramLift struct {
+	Exercise    string    `json:"exercise"`
+	TrainingMax float64   `json:"training_max"`
+	Increment   float64   `json:"increment"` // added to the training max after each cycle
+	Cycle       int       `json:"cycle"`     // from 1
+	Start       data.Date `json:"start"`     // first day of the current cycle
+}
+
+// Program is a generated periodized program: the week-by-week sets of its
+// template and the lifts they apply to.
+type Program struct {
+	Template string            `json:"template"`
+	Weeks    [][]PrescribedSet `json:"weeks"`
+	Lifts    []ProgramLift     `json:"lifts"`
+}
+
+// Title names the program's template.
+func (p *Program) Title() string {
+	if t, ok := TemplateNamed(p.Template); ok {
+		return t.Title
+	}
+	return p.Template
+}
+
+// LiftsString formats the lifts the way SetProgram reads them.
+func (p *Program) LiftsString() string {
+	parts := make([]string, len(p.Lifts))
+	for i, l := range p.Lifts {
+		parts[i] = l.Exercise + "=" + strconv.FormatFloat(l.TrainingMax, 'f', -1, 64)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// liftOf returns the program's lift for exercise, or nil, also for a nil
+// program.
+func (p *Program) liftOf(exercise string) *ProgramLift {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Lifts {
+		if p.Lifts[i].Exercise == exercise {
+			return &p.Lifts[i]
+		}
+	}
+	return nil
+}
+
+// PrescribedLoad is a prescribed set worked out in kg for a lift.
+type PrescribedLoad struct {
+	PrescribedSet
+	Weight float64 `json:"weight"`
+}
+
+// String shows the set as weight×reps, with a + for an AMRAP set.
+func (s PrescribedLoad) String() string {
+	str := fmt.Sprintf("%g×%d", s.Weight, s.Reps)
+	if s.AMRAP {
+		str += "+"
+	}
+	return str
+}
+
+// Prescription is what the program prescribes for a lift's next session.
+type Prescription struct {
+	Exercise    string           `json:"exercise"`
+	Template    string           `json:"template"` // title
+	Week        int              `json:"week"`     // from 1
+	Weeks       int              `json:"weeks"`
+	Cycle       int              `json:"cycle"`
+	TrainingMax float64          `json:"training_max"`
+	Deload      bool             `json:"deload,omitempty"` // a deload week; the cycle waits
+	Sets        []PrescribedLoad `json:"sets"`
+}
+
+func (p *Prescription) String() string {
+	parts := make([]string, len(p.Sets))
+	for i, s := range p.Sets {
+		parts[i] = s.String()
+	}
+	if p.Deload {
+		return fmt.Sprintf("%s deload, week %d of %d waits: %s", p.Template, p.Week, p.Weeks, strings.Join(parts, ", "))
+	}
+	return fmt.Sprintf("%s week %d of %d: %s", p.Template, p.Week, p.Weeks, strings.Join(parts, ", "))
+}
+
+// prescribe works out week (from 1) of lift, or the deload sets in a
+// deload week, with loads rounded to what the equipment can make.
+func (p *Program) prescribe(l *ProgramLift, week int, deload bool, eq Equipment) *Prescription {
+	pr := &Prescription{
+		Exercise:    l.Exercise,
+		Template:    p.Title(),
+		Week:        week,
+		Weeks:       len(p.Weeks),
+		Cycle:       l.Cycle,
+		TrainingMax: l.TrainingMax,
+		Deload:      deload,
+	}
+	sets := p.Weeks[week-1]
+	if deload {
+		sets = deloadSets
+	}
+	for _, s := range sets {
+		w := eq.Round(l.Exercise, l.TrainingMax*s.Percent/100)
+		pr.Sets = append(pr.Sets, PrescribedLoad{PrescribedSet: s, Weight: math.Round(w*100) / 100})
+	}
+	return pr
+}
+
+// trainedDays returns the days from start up to but not including today
+// that history, newest first, has working sets on, oldest first. Days in
+// deload weeks do not move a lift's cycle on and are left out.
+func trainedDays(history []*data.Entry, start, today data.Date, deloads []data.Date) []data.Date {
+	var days []data.Date
+	for _, e := range workingSets(history) {
+		if e.Date < start || e.Date >= today || inDeload(deloads, e.Date) {
+			continue
+		}
+		if n := len(days); n == 0 || days[n-1] != e.Date {
+			days = append(days, e.Date)
+		}
+	}
+	slices.Reverse(days)
+	return days
+}
+
+// advance moves lift through the cycles it finished training on days, the
+// days trained since its cycle started, raising the training max at the end
+// of each. It returns the current week, from 1, and whether lift changed.
+func (l *ProgramLift) advance(days []data.Date, weeks int) (int, bool) {
+	changed := false
+	for len(days) >= weeks {
+		l.TrainingMax += l.Increment
+		l.Cycle++
+		l.Start = days[weeks-1].AddDays(1)
+		days = days[weeks:]
+		changed = true
+	}
+	return len(days) + 1, changed
+}
+
+// defaultIncrement is how much the training max of exercise goes up each
+// cycle: 5 kg for leg day lifts and 2.5 kg for the rest.
+func defaultIncrement(exercise string) float64 {
+	for _, week := range []int{1, 2} {
+		if slices.Contains(data.WorkoutDays("Friday", week), exercise) {
+			return lowerIncrement
+		}
+	}
+	return upperIncrement
+}
+
+// Program returns the saved program, or nil if there is none. Lifts that
+// finished a cycle before today are returned in the next one with a higher
+// training max; only logging a set saves that, through advanceProgram.
+func (t *Tracker) Program(ctx context.Context, today data.Date) (*Program, error) {
+	p, _, err := t.program(ctx, today)
+	return p, err
+}
+
+// program reads the saved program and moves its lifts into their current
+// cycles, reporting whether any of them moved.
+func (t *Tracker) program(ctx context.Context, today data.Date) (*Program, bool, error) {
+	v, ok, err := t.repo.Setting(ctx, programSetting)
+	if err != nil || !ok || v == "" {
+		return nil, false, err
+	}
+	var p Program
+	if err := json.Unmarshal([]byte(v), &p); err != nil || len(p.Weeks) == 0 {
+		// a program that no longer reads is as good as none
+		return nil, false, nil
+	}
+	deloads, err := t.DeloadWeeks(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	changed := false
+	for i := range p.Lifts {
+		l := &p.Lifts[i]
+		history, err := t.repo.HistoryFor(ctx, l.Exercise)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, c := l.advance(trainedDays(history, l.Start, today, deloads), len(p.Weeks)); c {
+			changed = true
+		}
+	}
+	return &p, changed, nil
+}
+
+// advanceProgram saves the program with the cycles its lifts finished
+// before today, so a later change to that history no longer moves them.
+func (t *Tracker) advanceProgram(ctx context.Context, today data.Date) error {
+	p, changed, err := t.program(ctx, today)
+	if err != nil || !changed {
+		return err
+	}
+	return t.saveProgram(ctx, p)
+}
+
+// Prescriptions returns what the program prescribes for the next session
+// of each of its lifts, in the program's order, or nil without a program.
+func (t *Tracker) Prescriptions(ctx context.Context, today data.Date) ([]*Prescription, error) {
+	p, err := t.Program(ctx, today)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	eq, err := t.Equipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deloads, err := t.DeloadWeeks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Prescription
+	for i := range p.Lifts {
+		pr, err := t.prescription(ctx, p, &p.Lifts[i], today, deloads, eq)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pr)
+	}
+	return out, nil
+}
+
+// prescription works out the current week of lift, which Program has
+// already moved into its current cycle.
+func (t *Tracker) prescription(ctx context.Context, p *Program, l *ProgramLift, today data.Date, deloads []data.Date, eq Equipment) (*Prescription, error) {
+	history, err := t.repo.HistoryFor(ctx, l.Exercise)
+	if err != nil {
+		return nil, err
+	}
+	return p.current(l, history, today, deloads, eq), nil
+}
+
+// current works out what lift, with history newest first, does today.
+func (p *Program) current(l *ProgramLift, history []*data.Entry, today data.Date, deloads []data.Date, eq Equipment) *Prescription {
+	week, _ := l.advance(trainedDays(history, l.Start, today, deloads), len(p.Weeks))
+	return p.prescribe(l, week, inDeload(deloads, today), eq)
+}
+
+// parseLifts reads lifts such as "Barbell Squats=140, Flat Bench Barbell
+// Chest Press=100" as exercise and training max in kg. Exercise names are
+// matched without regard to case. A lift without a training max gets 90%
+// of the estimate from tm.
+func parseLifts(s string, tm func(exercise string) (float64, error)) ([]ProgramLift, error) {
+	var lifts []ProgramLift
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, kg, hasKg := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(data.AllExercises(), func(ex string) bool { return strings.EqualFold(ex, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown exercise %q", name)
+		}
+		l := ProgramLift{Exercise: data.AllExercises()[i], Cycle: 1}
+		l.Increment = defaultIncrement(l.Exercise)
+		if slices.ContainsFunc(lifts, func(o ProgramLift) bool { return o.Exercise == l.Exercise }) {
+			return nil, fmt.Errorf("%s listed twice", l.Exercise)
+		}
+		if hasKg {
+			v, err := strconv.ParseFloat(strings.TrimSpace(kg), 64)
+			if err != nil || v <= 0 || v > MaxWeight {
+				return nil, fmt.Errorf("invalid training max for %s", l.Exercise)
+			}
+			l.TrainingMax = v
+		} else {
+			e1rm, err := tm(l.Exercise)
+			if err != nil {
+				return nil, err
+			}
+			if e1rm <= 0 {
+				return nil, fmt.Errorf("no history for %s; give a training max", l.Exercise)
+			}
+			l.TrainingMax = roundTo(e1rm*0.9, 1)
+		}
+		lifts = append(lifts, l)
+	}
+	if len(lifts) == 0 {
+		return nil, fmt.Errorf("no lifts")
+	}
+	return lifts, nil
+}
+
+// SetProgram generates a program from template for lifts, read by
+// parseLifts, starting on today, and saves it in place of any other. Bad
+// values are reported as a *data.ValidationError for the fields "template"
+// and "lifts".
+func (t *Tracker) SetProgram(ctx context.Context, template, lifts string, today data.Date) (*Program, error) {
+	var verr data.ValidationError
+	tmpl, ok := TemplateNamed(strings.ToLower(strings.TrimSpace(template)))
+	if !ok {
+		names := make([]string, len(Templates))
+		for i, t := range Templates {
+			names[i] = t.Name
+		}
+		verr.Add("template", "template must be one of "+strings.Join(names, ", "))
+	}
+	parsed, err := parseLifts(lifts, func(ex string) (float64, error) { return t.RecentE1RM(ctx, ex) })
+	if err != nil {
+		verr.Add("lifts", err.Error())
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+	for i := range parsed {
+		parsed[i].Start = today
+	}
+	p := &Program{Template: tmpl.Name, Weeks: tmpl.Weeks, Lifts: parsed}
+	if err := t.saveProgram(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ClearProgram drops the program; sessions go back to double progression.
+func (t *Tracker) ClearProgram(ctx context.Context) error {
+	return t.repo.SetSetting(ctx, programSetting, "")
+}
+
+func (t *Tracker) saveProgram(ctx context.Context, p *Program) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return t.repo.SetSetting(ctx, programSetting, string(b))
+}
@@ -0,0 +1,194 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestSetProgram(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	today := data.Date("2025-03-03")
+	if p, err := tr.Program(ctx, today); err != nil || p != nil {
+		t.Fatalf("Program before any = %v, %v; want none", p, err)
+	}
+
+	_, err := tr.SetProgram(ctx, "conjugate", "Barbell Squats, Leg Day=100", today)
+	var ve *data.ValidationError
+	if !errors.As(err, &ve) || ve.Reason("template") == "" || ve.Reason("lifts") == "" {
+		t.Fatalf("SetProgram with bad values = %v, want template and lifts rejected", err)
+	}
+	// without history, a lift needs a training max
+	if _, err := tr.SetProgram(ctx, "531", "Barbell Squats", today); !errors.As(err, &ve) {
+		t.Fatalf("SetProgram without history = %v, want a validation error", err)
+	}
+
+	if _, err := tr.AddEntry(ctx, "Flat Bench Barbell Chest Press", "100", "5", "1", "8", "", "2025-03-01"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := tr.SetProgram(ctx, "531", "barbell squats=140, Flat Bench Barbell Chest Press", today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Weeks) != 4 || len(p.Lifts) != 2 {
+		t.Fatalf("program = %+v", p)
+	}
+	squat, bench := p.Lifts[0], p.Lifts[1]
+	if squat.Exercise != "Barbell Squats" || squat.TrainingMax != 140 || squat.Increment != 5 || squat.Cycle != 1 {
+		t.Errorf("squat = %+v", squat)
+	}
+	// 90% of an e1RM of 123.3
+	if bench.TrainingMax != 111 || bench.Increment != 2.5 {
+		t.Errorf("bench = %+v, want a training max of 111 going up 2.5", bench)
+	}
+	if got := p.LiftsString(); got != "Barbell Squats=140, Flat Bench Barbell Chest Press=111" {
+		t.Errorf("LiftsString() = %q", got)
+	}
+
+	if err := tr.ClearProgram(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := tr.Program(ctx, today); err != nil || p != nil {
+		t.Errorf("Program after clearing = %v, %v; want none", p, err)
+	}
+}
+
+func TestProgramCycles(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	start := data.Date("2025-03-03")
+	if _, err := tr.SetProgram(ctx, "531", "Barbell Squats=100", start); err != nil {
+		t.Fatal(err)
+	}
+	week := func(today data.Date) *Prescription {
+		t.Helper()
+		prs, err := tr.Prescriptions(ctx, today)
+		if err != nil || len(prs) != 1 {
+			t.Fatalf("Prescriptions = %v, %v", prs, err)
+		}
+		return prs[0]
+	}
+	pr := week(start)
+	if pr.Week != 1 || pr.Cycle != 1 || len(pr.Sets) != 3 {
+		t.Fatalf("first prescription = %+v", pr)
+	}
+	if got := pr.String(); got != "5/3/1 week 1 of 4: 65×5, 75×5, 85×5+" {
+		t.Errorf("String() = %q", got)
+	}
+
+	// a warm-up does not count as training the lift
+	if _, err := tr.AddWarmup(ctx, "Barbell Squats", WarmupSet{Weight: 60, Reps: 5}, "2025-03-03"); err != nil {
+		t.Fatal(err)
+	}
+	if pr := week("2025-03-10"); pr.Week != 1 {
+		t.Errorf("after a warm-up only, week %d, want 1", pr.Week)
+	}
+	// the week moves on each time the lift is trained, not with the calendar
+	for i, date := range []string{"2025-03-03", "2025-03-17", "2025-03-31"} {
+		if _, err := tr.AddEntry(ctx, "Barbell Squats", "80", "5", "3", "", "", date); err != nil {
+			t.Fatal(err)
+		}
+		if pr := week(data.Date(date)); pr.Week != i+1 {
+			t.Errorf("on %s, week %d, want %d while training", date, pr.Week, i+1)
+		}
+	}
+	if pr := week("2025-04-10"); pr.Week != 4 || pr.Sets[0].Weight != 40 {
+		t.Errorf("deload week = %+v", pr)
+	}
+
+	// the fourth session ends the cycle, and the training max goes up
+	if _, err := tr.AddEntry(ctx, "Barbell Squats", "50", "5", "3", "", "", "2025-04-14"); err != nil {
+		t.Fatal(err)
+	}
+	pr = week("2025-04-28")
+	if pr.Week != 1 || pr.Cycle != 2 || pr.TrainingMax != 105 {
+		t.Errorf("after the cycle = %+v, want week 1 of cycle 2 at 105", pr)
+	}
+	p, err := tr.Program(ctx, "2025-04-28")
+	if err != nil || p.Lifts[0].TrainingMax != 105 || p.Lifts[0].Start != "2025-04-15" {
+		t.Errorf("lift = %+v, %v", p.Lifts[0], err)
+	}
+
+	// reading the program leaves it as saved; the next set logged saves
+	// the new cycle
+	saved := func() ProgramLift {
+		t.Helper()
+		v, _, err := tr.repo.Setting(ctx, programSetting)
+		var p Program
+		if err == nil {
+			err = json.Unmarshal([]byte(v), &p)
+		}
+		if err != nil || len(p.Lifts) != 1 {
+			t.Fatalf("saved program %q, %v", v, err)
+		}
+		return p.Lifts[0]
+	}
+	if l := saved(); l.Cycle != 1 || l.TrainingMax != 100 {
+		t.Errorf("saved lift after reads = %+v, want cycle 1 still", l)
+	}
+	// nor does a set that fails to save
+	store := tr.repo
+	tr.repo = failingSave{store}
+	if _, err := tr.AddEntry(ctx, "Flat Bench Barbell Chest Press", "60", "5", "3", "", "", "2025-04-28"); err == nil {
+		t.Fatal("AddEntry with a failing store succeeded")
+	}
+	tr.repo = store
+	if l := saved(); l.Cycle != 1 {
+		t.Errorf("saved lift after a failed save = %+v, want cycle 1 still", l)
+	}
+	if _, err := tr.AddEntry(ctx, "Flat Bench Barbell Chest Press", "60", "5", "3", "", "", "2025-04-28"); err != nil {
+		t.Fatal(err)
+	}
+	if l := saved(); l.Cycle != 2 || l.TrainingMax != 105 || l.Start != "2025-04-15" {
+		t.Errorf("saved lift after logging = %+v, want cycle 2 at 105", l)
+	}
+}
+
+func TestSessionPrescription(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	if _, err := tr.SetProgram(ctx, "dup", "Barbell Squats=100", "2025-03-03"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 3, 7, 18, 0, 0, 0, time.Local)
+	s, err := tr.StartSession(ctx, "Friday", 2, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := s.Exercises[0]
+	if ex.Name != "Barbell Squats" || ex.Prescription == nil {
+		t.Fatalf("first exercise %s has no prescription", ex.Name)
+	}
+	if ex.Target != (Target{Weight: 65, Reps: 10, Sets: 3}) {
+		t.Errorf("target = %+v, want 3 sets of 10 at 65", ex.Target)
+	}
+	if s.Exercises[1].Prescription != nil {
+		t.Errorf("%s is not a program lift", s.Exercises[1].Name)
+	}
+	for range 3 {
+		set, ok := ex.NextPrescribed()
+		if !ok || set.Weight != 65 || set.Reps != 10 {
+			t.Fatalf("NextPrescribed = %+v, %v", set, ok)
+		}
+		if _, err := tr.LogSet(ctx, s, "65", "10", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := ex.NextPrescribed(); ok || !ex.Done() {
+		t.Error("exercise should be done after the prescribed sets")
+	}
+}
+
+// failingSave is a store that cannot save entries.
+type failingSave struct {
+	data.Store
+}
+
+func (failingSave) Save(context.Context, *data.Entry) error {
+	return errors.New("disk full")
+}
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"progresstracker/data"
+	"strconv"
+	"time"
+)
+
+// Rest times outside these bounds are rejected.
+const (
+	MinRest = 15 * time.Second
+	MaxRest = 10 * time.Minute
+)
+
+// restSetting is the settings key for a rest time chosen for exercise,
+// stored in whole seconds.
+func restSetting(exercise string) string {
+	return "rest:" + exercise
+}
+
+// RestDuration returns the rest between sets of exercise: the one saved
+// with SetRestDuration, or else the program's.
+func (t *Tracker) RestDuration(ctx context.Context, exercise string) (time.Duration, error) {
+	v, ok, err := t.repo.Setting(ctx, restSetting(exercise))
+	if err != nil || !ok {
+		return data.RestFor(exercise), err
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil {
+		return data.RestFor(exercise), nil
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+// SetRestDuration saves d as the rest between sets of exercise, rounded to
+// the second.
+func (t *Tracker) SetRestDuration(ctx context.Context, exercise string, d time.Duration) error {
+	if d < MinRest || d > MaxRest {
+		return data.Invalid("rest", fmt.Sprintf("rest must be between %v and %v", MinRest, MaxRest))
+	}
+	return t.repo.SetSetting(ctx, restSetting(exercise), strconv.Itoa(int(d.Round(time.Second).Seconds())))
+}
+
+// GroupRest returns the rest after a round of exercises done together, a
+// superset or circuit: the longest of their rests.
+func (t *Tracker) GroupRest(ctx context.Context, exercises []string) (time.Duration, error) {
+	var longest time.Duration
+	for _, ex := range exercises {
+		d, err := t.RestDuration(ctx, ex)
+		if err != nil {
+			return d, err
+		}
+		longest = max(longest, d)
+	}
+	return longest, nil
+}
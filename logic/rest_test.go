@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestRestDuration(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	for ex, want := range map[string]time.Duration{
+		"Barbell Squats": 3 * time.Minute,
+		"Plank":          45 * time.Second,
+		"Barbell Curl":   data.DefaultRest,
+	} {
+		if got, err := tr.RestDuration(ctx, ex); err != nil || got != want {
+			t.Errorf("RestDuration(%s) = %v, %v; want %v from the program", ex, got, err, want)
+		}
+	}
+
+	if err := tr.SetRestDuration(ctx, "Barbell Curl", 75*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tr.RestDuration(ctx, "Barbell Curl"); got != 75*time.Second {
+		t.Errorf("RestDuration after saving 75s = %v", got)
+	}
+	if got, _ := tr.RestDuration(ctx, "Preacher Curl"); got != data.DefaultRest {
+		t.Errorf("saving one exercise's rest changed another's: %v", got)
+	}
+	if got, _ := tr.GroupRest(ctx, []string{"Plank", "Barbell Curl"}); got != 75*time.Second {
+		t.Errorf("GroupRest of plank and curl = %v, want the curl's 75s", got)
+	}
+	for _, d := range []time.Duration{5 * time.Second, time.Hour} {
+		if err := tr.SetRestDuration(ctx, "Barbell Curl", d); !errors.Is(err, data.ErrValidation) {
+			t.Errorf("SetRestDuration(%v) = %v, want a validation error", d, err)
+		}
+	}
+}
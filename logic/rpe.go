@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"math"
+	"progresstracker/data"
+	"strconv"
+	"strings"
+)
+
+// RPE bounds, as on the chart. RIR, reps in reserve, is 10 minus RPE.
+const (
+	MinRPE = 6
+	MaxRPE = 10
+	// ChartReps is the most reps the chart covers.
+	ChartReps = 12
+)
+
+// rpeChart is the reference RPE chart as percentages of 1RM. A set's
+// percentage only depends on its reps plus reps in reserve, so the chart is
+// kept as one column indexed by that total, from 1 in half steps: 5 reps at
+// RPE 8 reads the same as 7 reps at RPE 10.
+var rpeChart = [...]float64{
+	100, 97.8, 95.5, 93.9, 92.2, 90.7, 89.2, 87.8, 86.3, 85.0,
+	83.7, 82.4, 81.1, 79.9, 78.6, 77.4, 76.2, 75.1, 73.9, 72.3,
+	70.7, 69.4, 68.0, 66.7, 65.3, 64.0, 62.6, 61.3, 59.9, 58.6,
+	57.4,
+}
+
+// RPEPercent returns the fraction of 1RM that reps at rpe take, from the
+// chart. It reports false outside the chart: more than ChartReps reps, or
+// an RPE outside MinRPE to MaxRPE.
+func RPEPercent(reps int, rpe float64) (float64, bool) {
+	if reps < 1 || reps > ChartReps || rpe < MinRPE || rpe > MaxRPE {
+		return 0, false
+	}
+	i := int(math.Round((float64(reps) + MaxRPE - rpe - 1) * 2))
+	return rpeChart[i] / 100, true
+}
+
+// RPEChartRow is one row of the reference chart: an RPE and the percentage
+// of 1RM for 1 to ChartReps reps.
+type RPEChartRow struct {
+	RPE     float64
+	Percent [ChartReps]float64
+}
+
+// RPEChart returns the reference chart, hardest first.
+func RPEChart() []RPEChartRow {
+	var rows []RPEChartRow
+	for rpe := float64(MaxRPE); rpe >= MinRPE; rpe -= 0.5 {
+		row := RPEChartRow{RPE: rpe}
+		for reps := 1; reps <= ChartReps; reps++ {
+			p, _ := RPEPercent(reps, rpe)
+			row.Percent[reps-1] = p * 100
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ParseEffort reads how hard a set was, as an RPE ("8", "8.5", "@8") or as
+// reps in reserve ("2 rir", "rir 1"), and returns the RPE. An empty string
+// means not recorded and gives 0.
+func ParseEffort(s string) (float64, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return 0, nil
+	}
+	rir := strings.Contains(s, "rir")
+	s = strings.TrimSpace(strings.NewReplacer("rir", "", "rpe", "", "@", "").Replace(s))
+	v, err := strconv.ParseFloat(s, 64)
+	if rir {
+		v = MaxRPE - v
+	}
+	if err != nil || v < MinRPE || v > MaxRPE || v*2 != math.Trunc(v*2) {
+		if rir {
+			return 0, errors.New("RIR must be 0 to 4, in half steps")
+		}
+		return 0, errors.New("RPE must be 6 to 10, in half steps")
+	}
+	return v, nil
+}
+
+// FormatEffort shows an RPE as "@8", or "" when none was recorded.
+func FormatEffort(rpe float64) string {
+	if rpe == 0 {
+		return ""
+	}
+	return "@" + strconv.FormatFloat(rpe, 'f', -1, 64)
+}
+
+// EffortE1RM estimates a one-rep max from a set, taking its RPE into
+// account: from the chart where it covers the set, or else with E1RM as if
+// the set was taken to failure.
+func EffortE1RM(weight float64, reps int, rpe float64) float64 {
+	if p, ok := RPEPercent(reps, rpe); ok {
+		return weight / p
+	}
+	return E1RM(weight, reps)
+}
+
+// SuggestLoad returns the weight for reps at a target rpe from an estimated
+// 1RM. It reports false when the chart does not cover reps and rpe.
+func SuggestLoad(e1rm float64, reps int, rpe float64) (float64, bool) {
+	p, ok := RPEPercent(reps, rpe)
+	if !ok || e1rm <= 0 {
+		return 0, false
+	}
+	return e1rm * p, true
+}
+
+// recentE1RM is the best RPE-adjusted e1RM of the newest session in
+// history, which is newest first, or 0 if there is none.
+func recentE1RM(history []*data.Entry) float64 {
+	best := 0.0
+	for _, e := range history {
+		if e.Date != history[0].Date {
+			break
+		}
+		best = max(best, EffortE1RM(e.Weight, e.Reps, e.RPE))
+	}
+	return best
+}
+
+// RecentE1RM returns the RPE-adjusted e1RM of the last session of
+// exercise, the basis for load suggestions, or 0 if it was never logged.
+func (t *Tracker) RecentE1RM(ctx context.Context, exercise string) (float64, error) {
+	history, err := t.repo.HistoryFor(ctx, exercise)
+	if err != nil {
+		return 0, err
+	}
+	return recentE1RM(workingSets(history)), nil
+}
+
+// E1RMOverTime returns the best RPE-adjusted e1RM of each training day.
+func (a *Analytics) E1RMOverTime(ctx context.Context, exercise string) ([]ChartPoint, error) {
+	history, err := a.repo.HistoryFor(ctx, exercise)
+	if err != nil {
+		return nil, err
+	}
+	history = workingSets(history)
+	var pts []ChartPoint
+	// history is newest first; the chart runs oldest first
+	for i := len(history) - 1; i >= 0; i-- {
+		e := history[i]
+		v := roundTo(EffortE1RM(e.Weight, e.Reps, e.RPE), 1)
+		if n := len(pts); n > 0 && pts[n-1].Date == e.Date {
+			pts[n-1].Value = max(pts[n-1].Value, v)
+		} else {
+			pts = append(pts, ChartPoint{Date: e.Date, Value: v})
+		}
+	}
+	return pts, nil
+}
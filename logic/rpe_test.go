@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"math"
+	"testing"
+
+	"progresstracker/data"
+)
+
+func TestRPEPercent(t *testing.T) {
+	for _, tc := range []struct {
+		reps int
+		rpe  float64
+		want float64
+		ok   bool
+	}{
+		{1, 10, 1, true},
+		{5, 8, 0.811, true},
+		// the same reps plus reps in reserve read the same
+		{7, 10, 0.811, true},
+		{3, 9.5, 0.907, true},
+		{12, 6, 0.574, true},
+		{13, 10, 0, false},
+		{5, 5.5, 0, false},
+		{0, 8, 0, false},
+	} {
+		got, ok := RPEPercent(tc.reps, tc.rpe)
+		if ok != tc.ok || math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("RPEPercent(%d, %g) = %g, %v; want %g, %v", tc.reps, tc.rpe, got, ok, tc.want, tc.ok)
+		}
+	}
+	rows := RPEChart()
+	if len(rows) != 9 || rows[0].RPE != 10 || rows[8].RPE != 6 || rows[0].Percent[0] != 100 {
+		t.Errorf("RPEChart() = %v", rows)
+	}
+}
+
+func TestParseEffort(t *testing.T) {
+	for in, want := range map[string]float64{
+		"":       0,
+		"8":      8,
+		"@8.5":   8.5,
+		"RPE 7":  7,
+		"2 rir":  8,
+		"RIR 0":  10,
+		"rir1.5": 8.5,
+	} {
+		if got, err := ParseEffort(in); err != nil || got != want {
+			t.Errorf("ParseEffort(%q) = %g, %v; want %g", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"5", "11", "8.3", "5 rir", "hard"} {
+		if _, err := ParseEffort(bad); err == nil {
+			t.Errorf("ParseEffort(%q) accepted", bad)
+		}
+	}
+	if FormatEffort(8.5) != "@8.5" || FormatEffort(0) != "" {
+		t.Errorf("FormatEffort = %q, %q", FormatEffort(8.5), FormatEffort(0))
+	}
+}
+
+func TestSuggestLoad(t *testing.T) {
+	// 100 kg for 5 at RPE 8 is 81.1% of about 123.3 kg
+	e1rm := EffortE1RM(100, 5, 8)
+	if math.Abs(e1rm-123.3) > 0.05 {
+		t.Errorf("EffortE1RM = %g, want about 123.3", e1rm)
+	}
+	// without an RPE the set counts as taken to failure
+	if EffortE1RM(100, 5, 0) != E1RM(100, 5) {
+		t.Errorf("EffortE1RM without RPE = %g, want E1RM", EffortE1RM(100, 5, 0))
+	}
+	if w, ok := SuggestLoad(e1rm, 5, 8); !ok || math.Abs(w-100) > 1e-9 {
+		t.Errorf("SuggestLoad back to 5 @8 = %g, %v; want 100", w, ok)
+	}
+	if w, ok := SuggestLoad(e1rm, 3, 9); !ok || w <= 100 {
+		t.Errorf("SuggestLoad(3 @9) = %g, %v; want heavier than 5 @8", w, ok)
+	}
+	if _, ok := SuggestLoad(e1rm, 15, 8); ok {
+		t.Error("SuggestLoad off the chart should fail")
+	}
+	if _, ok := SuggestLoad(0, 5, 8); ok {
+		t.Error("SuggestLoad without an e1RM should fail")
+	}
+}
+
+func TestE1RMOverTime(t *testing.T) {
+	ctx := t.Context()
+	repo := data.NewMemoryStore()
+	tr := NewTracker(repo)
+	a := NewAnalytics(repo)
+	for _, e := range []struct{ w, reps, rpe, date string }{
+		{"100", "5", "8", "2025-01-06"},
+		{"90", "5", "", "2025-01-06"},
+		{"105", "3", "2 rir", "2025-01-09"},
+	} {
+		if _, err := tr.AddEntry(ctx, "Barbell Squats", e.w, e.reps, "1", e.rpe, "", e.date); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tr.AddWarmup(ctx, "Barbell Squats", WarmupSet{Weight: 120, Reps: 1}, "2025-01-09"); err != nil {
+		t.Fatal(err)
+	}
+	pts, err := a.E1RMOverTime(ctx, "Barbell Squats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 2 || pts[0].Date.String() != "2025-01-06" || pts[0].Value != 123.3 || pts[1].Value != 121.7 {
+		t.Errorf("E1RMOverTime = %v, want 123.3 then 121.7", pts)
+	}
+	if got, err := tr.RecentE1RM(ctx, "Barbell Squats"); err != nil || math.Abs(got-121.7) > 0.05 {
+		t.Errorf("RecentE1RM = %g, %v; want about 121.7, ignoring the warm-up", got, err)
+	}
+}
@@ -0,0 +1,367 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"progresstracker/data"
+	"slices"
+	"time"
+)
+
+// Targets use double progression: the reps go up by one each session until
+// the top of the range, then the weight goes up and the reps start again
+// from the bottom.
+const (
+	targetSets   = 3
+	repRangeLow  = 8
+	repRangeHigh = 12
+	weightStep   = 2.5
+)
+
+// Target is what a session aims for on one exercise. Weight and Reps are
+// zero for an exercise that has never been logged.
+type Target struct {
+	Weight float64
+	Reps   int
+	Sets   int
+}
+
+func (t Target) String() string {
+	if t.Reps == 0 {
+		return fmt.Sprintf("%d sets", t.Sets)
+	}
+	return fmt.Sprintf("%.1f kg × %d × %d sets", t.Weight, t.Reps, t.Sets)
+}
+
+// nextTarget works out the target from the previous session's sets of an
+// exercise; up gives the weight to move up to once the reps top out.
+func nextTarget(last []*data.Entry, up func(kg float64) float64) Target {
+	if len(last) == 0 {
+		return Target{Sets: targetSets}
+	}
+	t := Target{}
+	for i, e := range last {
+		t.Sets += e.Sets
+		switch {
+		case i == 0 || e.Weight > t.Weight:
+			t.Weight, t.Reps = e.Weight, e.Reps
+		case e.Weight == t.Weight:
+			t.Reps = min(t.Reps, e.Reps)
+		}
+	}
+	if t.Reps >= repRangeHigh {
+		t.Weight = up(t.Weight)
+		t.Reps = repRangeLow
+	} else {
+		t.Reps++
+	}
+	return t
+}
+
+// SessionExercise is one exercise of a Session.
+type SessionExercise struct {
+	Name    string
+	Target  Target
+	Last    []*data.Entry // the previous session's sets, in the order done
+	Best    float64       // heaviest weight before this session
+	E1RM    float64       // RPE-adjusted e1RM of the previous session
+	Sets    []*data.Entry // sets logged in this session
+	Warmups []*data.Entry // warm-up sets logged in this session
+	Skipped bool
+	Group   int // plan block it belongs to; a block's exercises sit together
+
+	Prescription *Prescription // set when the exercise is a program lift
+}
+
+// Done reports whether the exercise needs no more sets.
+func (e *SessionExercise) Done() bool {
+	return e.Skipped || len(e.Sets) >= e.Target.Sets
+}
+
+// NextPrescribed returns the program's set for the next set logged, if the
+// exercise is a program lift with sets left to do.
+func (e *SessionExercise) NextPrescribed() (PrescribedLoad, bool) {
+	if e.Prescription == nil || len(e.Sets) >= len(e.Prescription.Sets) {
+		return PrescribedLoad{}, false
+	}
+	return e.Prescription.Sets[len(e.Sets)], true
+}
+
+// prescribedTarget sums up a prescription as a Target: its heaviest set,
+// and how many sets there are.
+func prescribedTarget(p *Prescription) Target {
+	t := Target{Sets: len(p.Sets)}
+	for _, s := range p.Sets {
+		if s.Weight >= t.Weight {
+			t.Weight, t.Reps = s.Weight, s.Reps
+		}
+	}
+	return t
+}
+
+// Session is a workout in progress, walking through a day's plan. Each set
+// is saved as its own entry as soon as it is logged, so nothing is lost if
+// the session is never finished. The exercises of a superset or circuit are
+// done in rounds, one set of each, with the rest after the round.
+type Session struct {
+	Day       string
+	Week      int
+	Date      data.Date
+	Started   time.Time
+	Exercises []*SessionExercise
+	Current   int  // index into Exercises, -1 once every exercise is done
+	RestDue   bool // the last set logged ended a round
+}
+
+// StartSession begins the workout planned for day in week, loading targets
+// and last-time numbers for each exercise. Weight increases go to the next
+// weight the equipment can load. Program lifts get the program's sets
+// instead.
+func (t *Tracker) StartSession(ctx context.Context, day string, week int, now time.Time) (*Session, error) {
+	blocks := data.WorkoutBlocks(day, week)
+	if len(blocks) == 0 {
+		return nil, data.Invalid("day", "no workout planned for "+day)
+	}
+	eq, err := t.Equipment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{Day: day, Week: week, Date: data.DateOf(now), Started: now}
+	prog, err := t.Program(ctx, s.Date)
+	if err != nil {
+		return nil, err
+	}
+	deloads, err := t.DeloadWeeks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for group, b := range blocks {
+		for _, name := range b {
+			history, err := t.repo.HistoryFor(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			history = workingSets(history)
+			ex := &SessionExercise{Name: name, Group: group}
+			// history is newest first; the previous session is its first date
+			for _, e := range history {
+				ex.Best = max(ex.Best, e.Weight)
+				if e.Date == history[0].Date {
+					ex.Last = append([]*data.Entry{e}, ex.Last...)
+				}
+			}
+			ex.E1RM = recentE1RM(history)
+			ex.Target = nextTarget(ex.Last, func(kg float64) float64 { return eq.NextLoad(name, kg) })
+			if l := prog.liftOf(name); l != nil {
+				ex.Prescription = prog.current(l, history, s.Date, deloads, eq)
+				ex.Target = prescribedTarget(ex.Prescription)
+			}
+			s.Exercises = append(s.Exercises, ex)
+		}
+	}
+	return s, nil
+}
+
+// LogSet saves one set of the current exercise, with its RPE or RIR if
+// effort is not empty. In a superset or circuit
+// it moves on to the next exercise of the round; otherwise it sets RestDue
+// and stays, until the target number of sets is reached.
+func (t *Tracker) LogSet(ctx context.Context, s *Session, weightStr, repsStr, effort string) (*data.Entry, error) {
+	ex := s.CurrentExercise()
+	if ex == nil {
+		return nil, data.Invalid("exercise", "every exercise is done")
+	}
+	e, err := t.AddEntry(ctx, ex.Name, weightStr, repsStr, "1", effort, "", s.Date.String())
+	if err != nil {
+		return nil, err
+	}
+	ex.Sets = append(ex.Sets, e)
+	ex.Skipped = false
+	s.nextInRound()
+	return e, nil
+}
+
+// LogWarmup saves a warm-up set of the current exercise. Warm-ups do not
+// count toward the target sets and do not move the session on.
+func (t *Tracker) LogWarmup(ctx context.Context, s *Session, set WarmupSet) (*data.Entry, error) {
+	ex := s.CurrentExercise()
+	if ex == nil {
+		return nil, data.Invalid("exercise", "every exercise is done")
+	}
+	e, err := t.AddWarmup(ctx, ex.Name, set, s.Date.String())
+	if err != nil {
+		return nil, err
+	}
+	ex.Warmups = append(ex.Warmups, e)
+	return e, nil
+}
+
+// nextInRound picks what follows a set of the current exercise: the next
+// exercise of its group still to do this round, or else the start of the
+// group's next round after a rest.
+func (s *Session) nextInRound() {
+	lo, hi := s.block(s.Current)
+	done := len(s.Exercises[s.Current].Sets)
+	for i := s.Current + 1; i < hi; i++ {
+		if ex := s.Exercises[i]; !ex.Done() && len(ex.Sets) < done {
+			s.Current = i
+			s.RestDue = false
+			return
+		}
+	}
+	s.RestDue = true
+	next := -1
+	for i := lo; i < hi; i++ {
+		if ex := s.Exercises[i]; !ex.Done() && (next < 0 || len(ex.Sets) < len(s.Exercises[next].Sets)) {
+			next = i
+		}
+	}
+	if next >= 0 {
+		s.Current = next
+		return
+	}
+	s.Current = hi - 1
+	s.advance()
+}
+
+// block returns the range of Exercises holding i's group.
+func (s *Session) block(i int) (lo, hi int) {
+	g := s.Exercises[i].Group
+	for lo = i; lo > 0 && s.Exercises[lo-1].Group == g; lo-- {
+	}
+	for hi = i + 1; hi < len(s.Exercises) && s.Exercises[hi].Group == g; hi++ {
+	}
+	return lo, hi
+}
+
+// CurrentGroup returns the names of the exercises done together with the
+// current one, itself included.
+func (s *Session) CurrentGroup() []string {
+	if s.CurrentExercise() == nil {
+		return nil
+	}
+	lo, hi := s.block(s.Current)
+	var names []string
+	for _, ex := range s.Exercises[lo:hi] {
+		names = append(names, ex.Name)
+	}
+	return names
+}
+
+// Labels numbers the exercises in their current order, lettering the ones
+// in a group: 1, 2, 3a, 3b.
+func (s *Session) Labels() []string {
+	var blocks []data.Block
+	for i, ex := range s.Exercises {
+		if i == 0 || ex.Group != s.Exercises[i-1].Group {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], ex.Name)
+	}
+	return data.StepLabels(blocks)
+}
+
+// CurrentExercise returns the exercise being worked on, or nil once every
+// exercise is done.
+func (s *Session) CurrentExercise() *SessionExercise {
+	if s.Current < 0 || s.Current >= len(s.Exercises) {
+		return nil
+	}
+	return s.Exercises[s.Current]
+}
+
+// Select makes exercise i the current one, done or not, so extra sets can
+// be added.
+func (s *Session) Select(i int) {
+	if i >= 0 && i < len(s.Exercises) {
+		s.Current = i
+	}
+}
+
+// Skip leaves out the current exercise and moves to the next one.
+func (s *Session) Skip() {
+	if ex := s.CurrentExercise(); ex != nil {
+		ex.Skipped = true
+		s.RestDue = false
+		s.advance()
+	}
+}
+
+// Move moves exercise i, together with the rest of its superset or
+// circuit, one step earlier (delta < 0) or later (delta > 0) in the order,
+// keeping the same exercise current.
+func (s *Session) Move(i, delta int) {
+	if i < 0 || i >= len(s.Exercises) {
+		return
+	}
+	lo, hi := s.block(i)
+	// swap the blocks [first, mid) and [mid, end)
+	var first, mid, end int
+	switch {
+	case delta < 0 && lo > 0:
+		first, _ = s.block(lo - 1)
+		mid, end = lo, hi
+	case delta > 0 && hi < len(s.Exercises):
+		first, mid = lo, hi
+		_, end = s.block(hi)
+	default:
+		return
+	}
+	cur := s.CurrentExercise()
+	swapped := append(slices.Clone(s.Exercises[mid:end]), s.Exercises[first:mid]...)
+	copy(s.Exercises[first:end], swapped)
+	if cur != nil {
+		s.Current = slices.Index(s.Exercises, cur)
+	}
+}
+
+// advance moves to the next exercise still needing sets, wrapping round to
+// ones passed over earlier.
+func (s *Session) advance() {
+	n := len(s.Exercises)
+	for k := 1; k <= n; k++ {
+		i := (s.Current + k) % n
+		if !s.Exercises[i].Done() {
+			s.Current = i
+			return
+		}
+	}
+	s.Current = -1
+}
+
+// SessionReport sums up a finished session.
+type SessionReport struct {
+	Day       string
+	Duration  time.Duration
+	Exercises int
+	Sets      int
+	Volume    float64
+	Skipped   []string
+	PRs       []PR
+}
+
+// Finish sums up the session as of end. A weight above everything logged
+// before the session counts as a PR; an exercise's first ever session does
+// not, as on the dashboard.
+func (s *Session) Finish(end time.Time) *SessionReport {
+	r := &SessionReport{Day: s.Day, Duration: end.Sub(s.Started).Round(time.Second)}
+	for _, ex := range s.Exercises {
+		if len(ex.Sets) == 0 {
+			if ex.Skipped {
+				r.Skipped = append(r.Skipped, ex.Name)
+			}
+			continue
+		}
+		r.Exercises++
+		top := 0.0
+		for _, e := range ex.Sets {
+			r.Sets += e.Sets
+			r.Volume += e.Volume
+			top = max(top, e.Weight)
+		}
+		if ex.Best > 0 && top > ex.Best {
+			r.PRs = append(r.PRs, PR{Exercise: ex.Name, Weight: top, Previous: ex.Best, Date: s.Date})
+		}
+	}
+	return r
+}
@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestNextTarget(t *testing.T) {
+	set := func(w float64, reps int) *data.Entry {
+		return &data.Entry{Weight: w, Reps: reps, Sets: 1}
+	}
+	up := func(kg float64) float64 { return kg + weightStep }
+	for _, tc := range []struct {
+		name string
+		last []*data.Entry
+		want Target
+	}{
+		{"never logged", nil, Target{Sets: 3}},
+		{"add a rep", []*data.Entry{set(60, 10), set(60, 9), set(60, 9)}, Target{60, 10, 3}},
+		{"top of range", []*data.Entry{set(60, 12), set(60, 12)}, Target{62.5, 8, 2}},
+		{"heaviest set counts", []*data.Entry{set(50, 12), set(60, 8)}, Target{60, 9, 2}},
+		{"one entry of several sets", []*data.Entry{{Weight: 40, Reps: 8, Sets: 4}}, Target{40, 9, 4}},
+		{"bodyweight", []*data.Entry{set(0, 15), set(0, 11)}, Target{0, 12, 2}},
+	} {
+		if got := nextTarget(tc.last, up); got != tc.want {
+			t.Errorf("%s: nextTarget = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	start := time.Now()
+	earlier := data.DateOf(start).AddDays(-7).String()
+	for _, e := range [][3]string{
+		{"Barbell Squats", "100", "10"},
+		{"Barbell Squats", "100", "9"},
+		{"Hack Squats", "80", "12"},
+	} {
+		if _, err := tr.AddEntry(ctx, e[0], e[1], e[2], "1", "", "", earlier); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := tr.StartSession(ctx, "Friday", 2, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Exercises) != len(data.WorkoutDays("Friday", 2)) {
+		t.Fatalf("session has %d exercises", len(s.Exercises))
+	}
+	squat := s.CurrentExercise()
+	if squat.Name != "Barbell Squats" || squat.Target != (Target{100, 10, 2}) || len(squat.Last) != 2 {
+		t.Fatalf("first exercise %s, target %+v, %d sets last time", squat.Name, squat.Target, len(squat.Last))
+	}
+
+	// move hack squats up to second
+	s.Move(3, -1)
+	s.Move(2, -1)
+	if s.Exercises[1].Name != "Hack Squats" || s.CurrentExercise() != squat {
+		t.Fatalf("after moving, order starts %s, %s", s.Exercises[0].Name, s.Exercises[1].Name)
+	}
+	for _, reps := range []string{"10", "8"} {
+		if _, err := tr.LogSet(ctx, s, "102.5", reps, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := s.CurrentExercise().Name; got != "Hack Squats" {
+		t.Fatalf("after the target sets, current is %s", got)
+	}
+	if _, err := tr.LogSet(ctx, s, "abc", "8", ""); err == nil {
+		t.Error("LogSet accepted an invalid weight")
+	}
+	if _, err := tr.LogSet(ctx, s, "80", "8", ""); err != nil {
+		t.Fatal(err)
+	}
+	// hack squats did one set last time, so one is its target
+	if got := s.CurrentExercise().Name; got != "Bulgarian Split Squats" {
+		t.Fatalf("after hack squats, current is %s", got)
+	}
+	s.Skip()
+	if got := s.CurrentExercise().Name; got != "Standing Lunges" {
+		t.Errorf("after skipping, current is %s", got)
+	}
+	s.Select(0)
+	if s.CurrentExercise() != squat {
+		t.Error("Select(0) did not go back to squats")
+	}
+
+	r := s.Finish(start.Add(45 * time.Minute))
+	if r.Duration != 45*time.Minute || r.Exercises != 2 || r.Sets != 3 || r.Volume != 102.5*18+80*8 {
+		t.Errorf("report %+v", r)
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0] != "Bulgarian Split Squats" {
+		t.Errorf("skipped %v", r.Skipped)
+	}
+	if len(r.PRs) != 1 || r.PRs[0].Exercise != "Barbell Squats" || r.PRs[0].Previous != 100 {
+		t.Errorf("PRs %+v, want only the squat", r.PRs)
+	}
+	if h, _ := tr.GetHistory(ctx, "Barbell Squats"); len(h) != 4 {
+		t.Errorf("squat history has %d entries, want each set saved", len(h))
+	}
+}
+
+func TestSessionSupersets(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	s, err := tr.StartSession(ctx, "Thursday", 1, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	curl, ext := "Standing Alternate Bicep Curl", "Seated Single Arm Tricep Extensions"
+	if got := s.CurrentGroup(); !slices.Equal(got, []string{curl, ext}) {
+		t.Fatalf("first group %v", got)
+	}
+
+	// three rounds of one set each, resting only after each round
+	for round := 1; round <= 3; round++ {
+		for _, want := range []string{curl, ext} {
+			if got := s.CurrentExercise().Name; got != want {
+				t.Fatalf("round %d: current is %s, want %s", round, got, want)
+			}
+			if _, err := tr.LogSet(ctx, s, "15", "10", ""); err != nil {
+				t.Fatal(err)
+			}
+			if rest := want == ext; s.RestDue != rest {
+				t.Errorf("round %d, after %s: RestDue = %v", round, want, s.RestDue)
+			}
+		}
+	}
+	if got := s.CurrentExercise().Name; got != "Standing Alternate Hammer Curls" {
+		t.Fatalf("after three rounds, current is %s", got)
+	}
+
+	// moving a member of the last group moves the whole superset
+	s.Move(7, -1)
+	want := []string{"1a", "1b", "2a", "2b", "3a", "3b", "4a", "4b"}
+	if got := s.Labels(); !slices.Equal(got, want) {
+		t.Errorf("labels %v, want %v", got, want)
+	}
+	if s.Exercises[4].Name != "Leg Raises" || s.Exercises[7].Name != "Tricep Cable Kickbacks" {
+		t.Errorf("after moving the abs up, 3a is %s and 4b is %s", s.Exercises[4].Name, s.Exercises[7].Name)
+	}
+	if got := s.CurrentExercise().Name; got != "Standing Alternate Hammer Curls" {
+		t.Errorf("moving changed the current exercise to %s", got)
+	}
+
+	// skipping one side leaves the other to finish on its own
+	s.Skip()
+	if got := s.CurrentExercise().Name; got != "Cable Rope Pushdown" {
+		t.Fatalf("after skipping, current is %s", got)
+	}
+	if _, err := tr.LogSet(ctx, s, "20", "12", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !s.RestDue || s.CurrentExercise().Name != "Cable Rope Pushdown" {
+		t.Errorf("with its partner skipped, the pushdown should rest and repeat; current %s, RestDue %v", s.CurrentExercise().Name, s.RestDue)
+	}
+}
@@ -1,58 +1,170 @@
 package logic
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"progresstracker/data"
 	"strconv"
-	"time"
+	"strings"
 )
 
 type Tracker struct {
-	repo *data.Repository
+	repo data.Store
 }
 
-func NewTracker(repo *data.Repository) *Tracker {
+func NewTracker(repo data.Store) *Tracker {
 	return &Tracker{repo: repo}
 }
 
-func (t *Tracker) AddEntry(exercise, weightStr, repsStr, setsStr, notes, date string) (*data.Entry, error) {
-	weight, err := strconv.ParseFloat(weightStr, 64)
-	if err != nil || weight < 0 {
-		return nil, fmt.Errorf("invalid weight")
+func (t *Tracker) AddEntry(ctx context.Context, exercise, weightStr, repsStr, setsStr, effort, notes, date string) (*data.Entry, error) {
+	if date == "" {
+		date = data.Today().String()
 	}
-	reps, err := strconv.Atoi(repsStr)
-	if err != nil || reps <= 0 {
-		return nil, fmt.Errorf("invalid reps")
+	e, err := parseEntry(exercise, weightStr, repsStr, setsStr, effort, notes, date, UnitKg)
+	if err != nil {
+		return nil, err
 	}
-	sets, err := strconv.Atoi(setsStr)
+	if err := t.repo.Save(ctx, e); err != nil {
+		return nil, err
+	}
+	// only once the set is saved: its own day is not over yet, so it does
+	// not move the program, but the days before it are settled
+	if err := t.advanceProgram(ctx, e.Date); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Bounds that reject obvious typos, in kg and reps per set.
+const (
+	MaxWeight = 1000
+	MaxReps   = 200
+)
+
+// parseEntry validates raw form values and builds an unsaved entry, with the
+// weight converted to kg from unit and the date normalized; shortcuts such
+// as "yesterday" are accepted too. It is shared by AddEntry and the
+// importers so every path applies the same rules. Every rejected value is
+// listed in the returned *data.ValidationError.
+func parseEntry(exercise, weightStr, repsStr, setsStr, effort, notes, date, unit string) (*data.Entry, error) {
+	var verr data.ValidationError
+	weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+	switch {
+	case err != nil || weight < 0 || math.IsNaN(weight):
+		verr.Add("weight", "invalid weight")
+	case unit == UnitLb:
+		weight = roundTo(weight*kgPerLb, 2)
+	}
+	if weight > MaxWeight {
+		verr.Add("weight", fmt.Sprintf("weight over %d kg", MaxWeight))
+	}
+	reps, err := strconv.Atoi(strings.TrimSpace(repsStr))
+	switch {
+	case err != nil || reps <= 0:
+		verr.Add("reps", "invalid reps")
+	case reps > MaxReps:
+		verr.Add("reps", fmt.Sprintf("more than %d reps", MaxReps))
+	}
+	sets, err := strconv.Atoi(strings.TrimSpace(setsStr))
 	if err != nil || sets <= 0 {
-		return nil, fmt.Errorf("invalid sets")
+		verr.Add("sets", "invalid sets")
 	}
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+	rpe, err := ParseEffort(effort)
+	if err != nil {
+		verr.Add("rpe", err.Error())
+	}
+	today := data.Today()
+	day, err := data.ResolveDate(date, today)
+	if err != nil {
+		verr.Add("date", "invalid date, want YYYY-MM-DD")
+	} else if day > today {
+		verr.Add("date", "date is in the future")
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
 	}
-	e := &data.Entry{
+	return &data.Entry{
 		Exercise: exercise,
 		Weight:   weight,
 		Reps:     reps,
 		Sets:     sets,
+		Volume:   weight * float64(reps) * float64(sets),
 		Notes:    notes,
-		Date:     date,
+		Date:     day,
+		RPE:      rpe,
+	}, nil
+}
+
+// GetEntry returns the entry with id, or an error wrapping data.ErrNotFound.
+func (t *Tracker) GetEntry(ctx context.Context, id int64) (*data.Entry, error) {
+	return t.repo.Get(ctx, id)
+}
+
+// UpdateEntry replaces the values of an existing entry after the same
+// validation as AddEntry. A warm-up stays a warm-up, and an empty effort
+// keeps the recorded RPE. It fails with data.ErrNotFound if there is no
+// entry with id.
+func (t *Tracker) UpdateEntry(ctx context.Context, id int64, exercise, weightStr, repsStr, setsStr, effort, notes, date string) (*data.Entry, error) {
+	e, err := parseEntry(exercise, weightStr, repsStr, setsStr, effort, notes, date, UnitKg)
+	if err != nil {
+		return nil, err
 	}
-	if err := t.repo.Save(e); err != nil {
+	old, err := t.repo.Get(ctx, id)
+	if err != nil {
 		return nil, err
 	}
-	return e, nil
+	e.ID = id
+	e.Warmup = old.Warmup
+	if strings.TrimSpace(effort) == "" {
+		e.RPE = old.RPE
+	}
+	if err := t.repo.Update(ctx, e); err != nil {
+		return nil, err
+	}
+	return t.repo.Get(ctx, id)
+}
+
+// DeleteEntry removes an entry, failing with data.ErrNotFound if there is
+// none with id.
+func (t *Tracker) DeleteEntry(ctx context.Context, id int64) error {
+	return t.repo.Delete(ctx, id)
+}
+
+func (t *Tracker) GetHistory(ctx context.Context, exercise string) ([]*data.Entry, error) {
+	return t.repo.HistoryFor(ctx, exercise)
+}
+
+func (t *Tracker) GetAllEntries(ctx context.Context) ([]*data.Entry, error) {
+	return t.repo.All(ctx)
 }
 
-func (t *Tracker) GetHistory(exercise string) ([]*data.Entry, error) {
-	return t.repo.HistoryFor(exercise)
+// GroupedSets returns the sets logged for the other exercises of
+// exercise's superset or circuit, by day and in the order done. It is empty
+// for an exercise done on its own.
+func (t *Tracker) GroupedSets(ctx context.Context, exercise string) (map[data.Date][]*data.Entry, error) {
+	byDay := map[data.Date][]*data.Entry{}
+	for _, other := range data.GroupOf(exercise) {
+		if other == exercise {
+			continue
+		}
+		history, err := t.repo.HistoryFor(ctx, other)
+		if err != nil {
+			return nil, err
+		}
+		history = workingSets(history)
+		for i := len(history) - 1; i >= 0; i-- {
+			e := history[i]
+			byDay[e.Date] = append(byDay[e.Date], e)
+		}
+	}
+	return byDay, nil
 }
 
-func (t *Tracker) GetPersonalBest(exercise string) (*data.PersonalBest, error) {
-	return t.repo.PersonalBest(exercise)
+func (t *Tracker) GetPersonalBest(ctx context.Context, exercise string) (*data.PersonalBest, error) {
+	return t.repo.PersonalBest(ctx, exercise)
 }
 
-func (t *Tracker) GetLastEntry(exercise string) (*data.Entry, error) {
-	return t.repo.LastEntry(exercise)
+func (t *Tracker) GetLastEntry(ctx context.Context, exercise string) (*data.Entry, error) {
+	return t.repo.LastEntry(ctx, exercise)
 }
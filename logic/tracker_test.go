@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestAddEntryValidates(t *testing.T) {
+	tr := NewTracker(data.NewMemoryStore())
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	for _, tc := range []struct {
+		weight, reps, sets, date string
+		fields                   []string
+	}{
+		{"abc", "5", "3", "2024-01-01", []string{"weight"}},
+		{"-1", "5", "3", "2024-01-01", []string{"weight"}},
+		{"1000.5", "5", "3", "2024-01-01", []string{"weight"}},
+		{"100", "0", "3", "2024-01-01", []string{"reps"}},
+		{"100", "201", "3", "2024-01-01", []string{"reps"}},
+		{"100", "5", "x", "2024-01-01", []string{"sets"}},
+		{"100", "5", "3", "2024-01", []string{"date"}},
+		{"100", "5", "3", "2024-02-30", []string{"date"}},
+		{"100", "5", "3", tomorrow, []string{"date"}},
+		{"", "x", "0", "someday", []string{"weight", "reps", "sets", "date"}},
+	} {
+		_, err := tr.AddEntry(t.Context(), "Squat", tc.weight, tc.reps, tc.sets, "", "", tc.date)
+		var verr *data.ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, data.ErrValidation) {
+			t.Errorf("AddEntry(%s, %s, %s, %s) error = %v, want a validation error", tc.weight, tc.reps, tc.sets, tc.date, err)
+			continue
+		}
+		var got []string
+		for _, f := range verr.Fields {
+			got = append(got, f.Field)
+		}
+		if !slices.Equal(got, tc.fields) {
+			t.Errorf("AddEntry(%s, %s, %s, %s) rejected %v, want %v", tc.weight, tc.reps, tc.sets, tc.date, got, tc.fields)
+		}
+	}
+	if all, _ := tr.GetAllEntries(t.Context()); len(all) != 0 {
+		t.Errorf("invalid entries were saved: %d", len(all))
+	}
+}
+
+func TestParseEntryConvertsBeforeBounds(t *testing.T) {
+	// 2000 lb is about 907 kg, under the limit once converted
+	e, err := parseEntry("Squat", "2000", "1", "1", "", "", "2024-01-01", UnitLb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Weight != 907.18 || e.Volume != 907.18 {
+		t.Errorf("weight %g, volume %g; want 907.18", e.Weight, e.Volume)
+	}
+	if _, err := parseEntry("Squat", "2300", "1", "1", "", "", "2024-01-01", UnitLb); !errors.Is(err, data.ErrValidation) {
+		t.Errorf("2300 lb: error = %v, want a validation error", err)
+	}
+}
+
+func TestAddEntryDefaultsToToday(t *testing.T) {
+	tr := NewTracker(data.NewMemoryStore())
+	e, err := tr.AddEntry(t.Context(), "Squat", "100", "5", "3", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if today := data.Today(); e.Date != today {
+		t.Errorf("date %s, want %s", e.Date, today)
+	}
+	if e.Volume != 1500 {
+		t.Errorf("volume %g, want 1500", e.Volume)
+	}
+}
+
+func TestAddEntryNormalizesDates(t *testing.T) {
+	tr := NewTracker(data.NewMemoryStore())
+	today := data.Today()
+	for in, want := range map[string]data.Date{
+		"2024-1-5":   "2024-01-05",
+		"05/01/2024": "2024-01-05",
+		"yesterday":  today.AddDays(-1),
+		"-2d":        today.AddDays(-2),
+	} {
+		e, err := tr.AddEntry(t.Context(), "Squat", "100", "5", "3", "", "", in)
+		if err != nil {
+			t.Errorf("AddEntry with date %q: %v", in, err)
+			continue
+		}
+		if e.Date != want {
+			t.Errorf("date %q stored as %s, want %s", in, e.Date, want)
+		}
+	}
+}
+
+func TestUpdateAndDeleteEntry(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	e, err := tr.AddEntry(ctx, "Squat", "100", "5", "3", "", "", "2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upd, err := tr.UpdateEntry(ctx, e.ID, "Squat", "105", "5", "3", "8", "heavier", "2024-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.Weight != 105 || upd.Notes != "heavier" || upd.RPE != 8 {
+		t.Fatalf("UpdateEntry returned %+v", upd)
+	}
+	if _, err := tr.UpdateEntry(ctx, e.ID+1, "Squat", "105", "5", "3", "", "", "2024-01-01"); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("UpdateEntry(missing) = %v, want ErrNotFound", err)
+	}
+	if pb, _ := tr.GetPersonalBest(ctx, "Squat"); pb.MaxWeight != 105 {
+		t.Errorf("PB %g after update, want 105", pb.MaxWeight)
+	}
+	if err := tr.DeleteEntry(ctx, e.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.DeleteEntry(ctx, e.ID); !errors.Is(err, data.ErrNotFound) {
+		t.Errorf("second DeleteEntry = %v, want ErrNotFound", err)
+	}
+	if last, _ := tr.GetLastEntry(ctx, "Squat"); last != nil {
+		t.Errorf("last entry %+v after delete", last)
+	}
+}
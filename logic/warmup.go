@@ -0,0 +1,222 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"progresstracker/data"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Settings keys for the warm-up scheme.
+const (
+	warmupSchemeSetting = "warmup_scheme"
+	warmupBarSetting    = "warmup_bar"
+)
+
+// Warm-up weights are rounded to this without an equipment profile.
+const warmupIncrement = 2.5
+
+// WarmupStep is one warm-up set of a scheme: reps at a percentage of the
+// working weight. Percent 0 is the empty bar.
+type WarmupStep struct {
+	Percent float64
+	Reps    int
+}
+
+// WarmupScheme is how to ramp up to a working weight. No set is lighter
+// than Bar. Weights are rounded to what Equipment can load, or to 2.5 kg
+// when it is nil.
+type WarmupScheme struct {
+	Steps     []WarmupStep
+	Bar       float64
+	Equipment *Equipment
+}
+
+var DefaultWarmupScheme = WarmupScheme{
+	Steps: []WarmupStep{{0, 10}, {40, 5}, {60, 3}, {80, 2}},
+	Bar:   20,
+}
+
+// String formats the steps the way ParseWarmupSteps reads them.
+func (s WarmupScheme) String() string {
+	parts := make([]string, len(s.Steps))
+	for i, st := range s.Steps {
+		if st.Percent == 0 {
+			parts[i] = fmt.Sprintf("bar×%d", st.Reps)
+		} else {
+			parts[i] = fmt.Sprintf("%g%%×%d", st.Percent, st.Reps)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseWarmupSteps reads steps such as "bar×10, 40%×5, 60%×3, 80%×2"; x
+// works in place of ×, and the % sign is optional. Percentages must go up
+// and stay under 100.
+func ParseWarmupSteps(s string) ([]WarmupStep, error) {
+	var steps []WarmupStep
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		if part == "" {
+			continue
+		}
+		pct, reps, ok := strings.Cut(strings.ReplaceAll(part, "×", "x"), "x")
+		if !ok {
+			return nil, fmt.Errorf("invalid warm-up step %q, want percent×reps", part)
+		}
+		var st WarmupStep
+		var err error
+		if pct = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(pct), "%")); pct != "bar" {
+			if st.Percent, err = strconv.ParseFloat(pct, 64); err != nil || st.Percent < 0 || st.Percent >= 100 {
+				return nil, fmt.Errorf("invalid percentage in warm-up step %q", part)
+			}
+		}
+		if st.Reps, err = strconv.Atoi(strings.TrimSpace(reps)); err != nil || st.Reps <= 0 || st.Reps > MaxReps {
+			return nil, fmt.Errorf("invalid reps in warm-up step %q", part)
+		}
+		if n := len(steps); n > 0 && st.Percent <= steps[n-1].Percent {
+			return nil, fmt.Errorf("warm-up step %q is not heavier than the one before", part)
+		}
+		steps = append(steps, st)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no warm-up steps")
+	}
+	return steps, nil
+}
+
+// WarmupSet is one generated warm-up set.
+type WarmupSet struct {
+	Weight float64
+	Reps   int
+}
+
+// Sets generates the warm-up sets for a working weight in kg, rounded to
+// 2.5 kg. Steps that come out no lighter than the working weight, or no
+// heavier than the set before once rounded, are dropped, so a light working
+// weight gets fewer warm-ups.
+func (s WarmupScheme) Sets(working float64) []WarmupSet {
+	return s.sets("", working)
+}
+
+// sets is Sets for exercise, with its weights rounded to what the equipment
+// can load for it.
+func (s WarmupScheme) sets(exercise string, working float64) []WarmupSet {
+	var sets []WarmupSet
+	for _, st := range s.Steps {
+		w := max(s.Bar, s.round(exercise, working*st.Percent/100))
+		if w >= working {
+			break
+		}
+		if n := len(sets); n > 0 && w <= sets[n-1].Weight {
+			continue
+		}
+		sets = append(sets, WarmupSet{Weight: w, Reps: st.Reps})
+	}
+	return sets
+}
+
+// round returns the warm-up weight nearest to w that exercise can be
+// loaded with.
+func (s WarmupScheme) round(exercise string, w float64) float64 {
+	if s.Equipment != nil && exercise != "" {
+		return s.Equipment.Round(exercise, w)
+	}
+	return math.Round(w/warmupIncrement) * warmupIncrement
+}
+
+// For returns the warm-up sets before working sets of exercise, or nil if
+// the exercise is not one of data.WarmupExercises.
+func (s WarmupScheme) For(exercise string, working float64) []WarmupSet {
+	if !slices.Contains(data.WarmupExercises, exercise) {
+		return nil
+	}
+	return s.sets(exercise, working)
+}
+
+// WarmupScheme returns the saved warm-up scheme, or DefaultWarmupScheme,
+// rounding to the equipment profile.
+func (t *Tracker) WarmupScheme(ctx context.Context) (WarmupScheme, error) {
+	scheme := DefaultWarmupScheme
+	eq, err := t.Equipment(ctx)
+	if err != nil {
+		return scheme, err
+	}
+	scheme.Equipment = &eq
+	v, ok, err := t.repo.Setting(ctx, warmupSchemeSetting)
+	if err != nil {
+		return scheme, err
+	}
+	if steps, err := ParseWarmupSteps(v); ok && err == nil {
+		scheme.Steps = steps
+	}
+	v, ok, err = t.repo.Setting(ctx, warmupBarSetting)
+	if err != nil {
+		return scheme, err
+	}
+	if bar, err := strconv.ParseFloat(v, 64); ok && err == nil {
+		scheme.Bar = bar
+	}
+	return scheme, nil
+}
+
+// SetWarmupScheme validates and saves the warm-up steps and bar weight in
+// kg, reporting bad values as a *data.ValidationError for the fields
+// "steps" and "bar".
+func (t *Tracker) SetWarmupScheme(ctx context.Context, steps, bar string) (WarmupScheme, error) {
+	var verr data.ValidationError
+	parsed, err := ParseWarmupSteps(steps)
+	if err != nil {
+		verr.Add("steps", err.Error())
+	}
+	barKg, err := strconv.ParseFloat(strings.TrimSpace(bar), 64)
+	if err != nil || barKg < 0 || barKg > MaxWeight || math.IsNaN(barKg) {
+		verr.Add("bar", "invalid bar weight")
+	}
+	if err := verr.Err(); err != nil {
+		return WarmupScheme{}, err
+	}
+	eq, err := t.Equipment(ctx)
+	if err != nil {
+		return WarmupScheme{}, err
+	}
+	scheme := WarmupScheme{Steps: parsed, Bar: barKg, Equipment: &eq}
+	if err := t.repo.SetSetting(ctx, warmupSchemeSetting, scheme.String()); err != nil {
+		return WarmupScheme{}, err
+	}
+	if err := t.repo.SetSetting(ctx, warmupBarSetting, strconv.FormatFloat(barKg, 'f', -1, 64)); err != nil {
+		return WarmupScheme{}, err
+	}
+	return scheme, nil
+}
+
+// AddWarmup saves a warm-up set of exercise on date. Warm-ups are stored
+// like any entry but marked, so they stay out of volume, PBs and charts.
+func (t *Tracker) AddWarmup(ctx context.Context, exercise string, set WarmupSet, date string) (*data.Entry, error) {
+	if date == "" {
+		date = data.Today().String()
+	}
+	e, err := parseEntry(exercise, strconv.FormatFloat(set.Weight, 'f', -1, 64), strconv.Itoa(set.Reps), "1", "", "", date, UnitKg)
+	if err != nil {
+		return nil, err
+	}
+	e.Warmup = true
+	if err := t.repo.Save(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// workingSets returns entries without the warm-up sets.
+func workingSets(entries []*data.Entry) []*data.Entry {
+	out := make([]*data.Entry, 0, len(entries))
+	for _, e := range entries {
+		if !e.Warmup {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"progresstracker/data"
+)
+
+func TestWarmupSets(t *testing.T) {
+	s := DefaultWarmupScheme
+	for _, tc := range []struct {
+		working float64
+		want    []WarmupSet
+	}{
+		{100, []WarmupSet{{20, 10}, {40, 5}, {60, 3}, {80, 2}}},
+		{62.5, []WarmupSet{{20, 10}, {25, 5}, {37.5, 3}, {50, 2}}},
+		// 40% of 40 kg rounds to the bar, so that step is dropped
+		{40, []WarmupSet{{20, 10}, {25, 3}, {32.5, 2}}},
+		{20, nil},
+	} {
+		if got := s.Sets(tc.working); !slices.Equal(got, tc.want) {
+			t.Errorf("Sets(%g) = %v, want %v", tc.working, got, tc.want)
+		}
+	}
+	if got := s.For("Barbell Curl", 100); got != nil {
+		t.Errorf("For(Barbell Curl) = %v, want no warm-ups", got)
+	}
+}
+
+func TestParseWarmupSteps(t *testing.T) {
+	steps, err := ParseWarmupSteps("bar x 8, 50% × 5, 75x3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []WarmupStep{{0, 8}, {50, 5}, {75, 3}}; !slices.Equal(steps, want) {
+		t.Errorf("steps %v, want %v", steps, want)
+	}
+	if got := (WarmupScheme{Steps: steps}).String(); got != "bar×8, 50%×5, 75%×3" {
+		t.Errorf("String() = %q", got)
+	}
+	for _, bad := range []string{"", "50", "50x0", "100x1", "60x3, 40x5", "abc x 5"} {
+		if _, err := ParseWarmupSteps(bad); err == nil {
+			t.Errorf("ParseWarmupSteps(%q) accepted", bad)
+		}
+	}
+}
+
+func TestWarmupScheme(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	if s, err := tr.WarmupScheme(ctx); err != nil || s.String() != DefaultWarmupScheme.String() || s.Bar != 20 {
+		t.Fatalf("WarmupScheme = %v bar %g, %v; want the default", s, s.Bar, err)
+	}
+	if _, err := tr.SetWarmupScheme(ctx, "50x5, 80x2", "15"); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := tr.WarmupScheme(ctx)
+	if got, want := s.Sets(100), []WarmupSet{{50, 5}, {80, 2}}; !slices.Equal(got, want) {
+		t.Errorf("saved scheme gives %v, want %v", got, want)
+	}
+	// with only 5 kg plates and up, warm-ups go up in 10 kg; Sets, without
+	// an exercise, still rounds to 2.5 kg
+	if _, err := tr.SetEquipment(ctx, "20, 10, 5", "barbell=20", "2", "5"); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = tr.WarmupScheme(ctx)
+	if got, want := s.For("Barbell Squats", 95), []WarmupSet{{50, 5}, {80, 2}}; !slices.Equal(got, want) {
+		t.Errorf("For(Barbell Squats, 95) = %v, want %v", got, want)
+	}
+	if got, want := s.Sets(95), []WarmupSet{{47.5, 5}, {75, 2}}; !slices.Equal(got, want) {
+		t.Errorf("Sets(95) = %v, want %v", got, want)
+	}
+	_, err := tr.SetWarmupScheme(ctx, "80x2, 50x5", "heavy")
+	var verr *data.ValidationError
+	if !errors.As(err, &verr) || verr.Reason("steps") == "" || verr.Reason("bar") == "" {
+		t.Errorf("SetWarmupScheme with bad steps and bar = %v", err)
+	}
+}
+
+func TestWarmupsLeftOut(t *testing.T) {
+	ctx := t.Context()
+	tr := NewTracker(data.NewMemoryStore())
+	start := time.Now()
+	earlier := data.DateOf(start).AddDays(-7).String()
+	if _, err := tr.AddEntry(ctx, "Barbell Squats", "100", "10", "2", "", "", earlier); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.AddWarmup(ctx, "Barbell Squats", WarmupSet{Weight: 60, Reps: 3}, earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := tr.StartSession(ctx, "Friday", 2, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	squat := s.CurrentExercise()
+	if squat.Target != (Target{100, 11, 2}) || len(squat.Last) != 1 {
+		t.Fatalf("target %+v from %d sets, want the warm-up ignored", squat.Target, len(squat.Last))
+	}
+	for _, set := range DefaultWarmupScheme.For(squat.Name, squat.Target.Weight) {
+		if _, err := tr.LogWarmup(ctx, s, set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(squat.Warmups) != 4 || len(squat.Sets) != 0 || s.CurrentExercise() != squat {
+		t.Errorf("after warming up: %d warm-ups, %d sets, current %s", len(squat.Warmups), len(squat.Sets), s.CurrentExercise().Name)
+	}
+	if r := s.Finish(start.Add(time.Minute)); r.Sets != 0 || r.Volume != 0 {
+		t.Errorf("warm-ups counted in the report: %+v", r)
+	}
+
+	if pb, _ := tr.GetPersonalBest(ctx, "Barbell Squats"); pb.MaxVolume != 2000 {
+		t.Errorf("PB volume %g, want warm-ups left out", pb.MaxVolume)
+	}
+	history, _ := tr.GetHistory(ctx, "Barbell Squats")
+	if flags := FlagOutliers(history); len(flags) != 0 {
+		t.Errorf("flagged %v, want warm-ups neither flagged nor a baseline", flags)
+	}
+}
@@ -1,17 +1,44 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"gioui.org/app"
+	"progresstracker/cli"
+	"progresstracker/config"
 	"progresstracker/data"
 	"progresstracker/logic"
+	"progresstracker/server"
 	"progresstracker/ui"
 )
 
 func main() {
-	db, err := data.NewDB("progress.db")
+	cfg, args, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "config:", err)
+		os.Exit(2)
+	}
+	if legacy, ok := cfg.LegacyDB(); ok {
+		useLegacy(cfg, legacy)
+	}
+	if err := cfg.EnsureDirs(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create data directory:", err)
+		os.Exit(1)
+	}
+
+	db, err := data.NewDB(cfg.DBPath)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "failed to open database:", err)
 		os.Exit(1)
@@ -21,10 +48,75 @@ func main() {
 	repo := data.NewRepository(db)
 	tracker := logic.NewTracker(repo)
 	anal := logic.NewAnalytics(repo)
+	backups := data.NewBackups(db, cfg.BackupDir)
+
+	// any argument means headless: a subcommand runs, anything else prints
+	// usage instead of opening the window
+	if len(args) > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := cli.Run(ctx, args, repo, tracker, anal, backups, os.Stdin, os.Stdout, os.Stderr)
+		stop()
+		db.Close()
+		os.Exit(code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go backups.RunDaily(ctx, func(err error) {
+		fmt.Fprintln(os.Stderr, "backup failed:", err)
+	})
+	if cfg.Serve != "" {
+		go serve(ctx, cfg.Serve, repo, tracker, anal)
+	}
 
 	go func() {
-		ui.Run(repo, tracker, anal)
+		ui.Run(ctx, repo, tracker, anal, backups)
+		cancel()
 		os.Exit(0)
 	}()
 	app.Main()
 }
+
+// serve runs the HTTP API next to the window. A failure is reported but
+// leaves the window open.
+func serve(ctx context.Context, addr string, repo data.Store, tracker *logic.Tracker, anal *logic.Analytics) {
+	token, err := server.Token(ctx, repo)
+	if err == nil {
+		err = server.ListenAndServe(ctx, addr, server.New(repo, tracker, anal, token), func(bound string) {
+			fmt.Fprintf(os.Stderr, "serving on http://%s (token %s)\n", bound, token)
+		})
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "API server failed:", err)
+	}
+}
+
+// useLegacy handles a progress.db left in the working directory by an older
+// release. It is moved to the data directory when -migrate is given or the
+// user agrees at the prompt; otherwise it keeps being used where it is, so
+// existing data never silently disappears behind a fresh database.
+func useLegacy(cfg *config.Config, legacy string) {
+	if cfg.Migrate || confirm(fmt.Sprintf("Found %s from an older version. Move it to %s? [y/N] ", legacy, cfg.DBPath)) {
+		if err := cfg.MigrateLegacy(legacy); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to move database:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "Moved database to", cfg.DBPath)
+		return
+	}
+	cfg.DBPath = legacy
+	cfg.BackupDir = filepath.Join(filepath.Dir(legacy), "backups")
+	fmt.Fprintf(os.Stderr, "Using %s; run with -migrate to move it to the data directory.\n", legacy)
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal it
+// answers no.
+func confirm(question string) bool {
+	st, err := os.Stdin.Stat()
+	if err != nil || st.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Fprint(os.Stderr, question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
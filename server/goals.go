@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+// goalInput mirrors schema/goal-input.json.
+type goalInput struct {
+	Metric   string  `json:"metric"`
+	Exercise string  `json:"exercise"`
+	Target   float64 `json:"target"`
+	By       string  `json:"by"`
+}
+
+// bodyweightInput mirrors schema/bodyweight.json.
+type bodyweightInput struct {
+	Weight float64 `json:"weight"`
+	Date   string  `json:"date"`
+}
+
+func (s *Server) handleGoals(w http.ResponseWriter, r *http.Request) {
+	goals, err := s.anal.Goals(r.Context(), time.Now())
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if goals == nil {
+		goals = []*logic.GoalProgress{}
+	}
+	writeJSON(w, http.StatusOK, goals)
+}
+
+func (s *Server) handleCreateGoal(w http.ResponseWriter, r *http.Request) {
+	var in goalInput
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	target := strconv.FormatFloat(in.Target, 'f', -1, 64)
+	g, err := s.tracker.AddGoal(r.Context(), in.Metric, in.Exercise, target, in.By, data.Today())
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	goals, err := s.anal.Goals(r.Context(), time.Now())
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	for _, p := range goals {
+		if p.ID == g.ID {
+			w.Header().Set("Location", "/api/goals/"+strconv.FormatInt(g.ID, 10))
+			writeJSON(w, http.StatusCreated, p)
+			return
+		}
+	}
+	writeError(w, http.StatusInternalServerError, "goal not saved")
+}
+
+func (s *Server) handleDeleteGoal(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "goal not found")
+		return
+	}
+	if err := s.tracker.DeleteGoal(r.Context(), id); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleBodyweight(w http.ResponseWriter, r *http.Request) {
+	readings, err := s.repo.Bodyweights(r.Context())
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if readings == nil {
+		readings = []data.Bodyweight{}
+	}
+	writeJSON(w, http.StatusOK, readings)
+}
+
+func (s *Server) handleLogBodyweight(w http.ResponseWriter, r *http.Request) {
+	var in bodyweightInput
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	b, err := s.tracker.LogBodyweight(r.Context(), strconv.FormatFloat(in.Weight, 'f', -1, 64), in.Date)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.checkGoals(r)
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// checkGoals records goals reached by what a request just logged. The
+// request has succeeded by then, so a failure is left for the next check.
+func (s *Server) checkGoals(r *http.Request) {
+	s.tracker.CheckGoals(r.Context())
+}
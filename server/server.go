@@ -0,0 +1,521 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+const (
+	EnvToken     = "PROGRESSTRACKER_TOKEN"
+	tokenSetting = "api_token"
+)
+
+//go:embed schema/*.json
+var schemas embed.FS
+
+// Server exposes the tracker over HTTP/JSON, plus a small web UI. Every /api
+// route except the schemas needs "Authorization: Bearer <token>"; the web
+// pages need a session cookie obtained by entering the same token.
+type Server struct {
+	repo    data.Store
+	tracker *logic.Tracker
+	anal    *logic.Analytics
+	token   string
+	mux     *http.ServeMux
+}
+
+func New(repo data.Store, tracker *logic.Tracker, anal *logic.Analytics, token string) *Server {
+	s := &Server{repo: repo, tracker: tracker, anal: anal, token: token, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/schema/{name}", s.handleSchema)
+
+	s.mux.HandleFunc("GET /api/entries", s.auth(s.handleListEntries))
+	s.mux.HandleFunc("POST /api/entries", s.auth(s.handleCreateEntry))
+	s.mux.HandleFunc("GET /api/entries/{id}", s.auth(s.handleGetEntry))
+	s.mux.HandleFunc("PUT /api/entries/{id}", s.auth(s.handleUpdateEntry))
+	s.mux.HandleFunc("DELETE /api/entries/{id}", s.auth(s.handleDeleteEntry))
+
+	s.mux.HandleFunc("GET /api/exercises", s.auth(s.handleExercises))
+	s.mux.HandleFunc("GET /api/exercises/{name}/history", s.auth(s.handleHistory))
+	s.mux.HandleFunc("GET /api/exercises/{name}/pb", s.auth(s.handlePB))
+	s.mux.HandleFunc("GET /api/exercises/{name}/chart", s.auth(s.handleChart))
+	s.mux.HandleFunc("GET /api/pbs", s.auth(s.handlePBs))
+
+	s.mux.HandleFunc("GET /api/plan", s.auth(s.handlePlan))
+	s.mux.HandleFunc("GET /api/week", s.auth(s.handleGetWeek))
+	s.mux.HandleFunc("PUT /api/week", s.auth(s.handleSetWeek))
+
+	s.mux.HandleFunc("GET /api/goals", s.auth(s.handleGoals))
+	s.mux.HandleFunc("POST /api/goals", s.auth(s.handleCreateGoal))
+	s.mux.HandleFunc("DELETE /api/goals/{id}", s.auth(s.handleDeleteGoal))
+	s.mux.HandleFunc("GET /api/bodyweight", s.auth(s.handleBodyweight))
+	s.mux.HandleFunc("POST /api/bodyweight", s.auth(s.handleLogBodyweight))
+
+	s.webRoutes()
+
+	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="progresstracker"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Token returns the API token: $PROGRESSTRACKER_TOKEN if set, otherwise the
+// one stored in the database, generated on first use.
+func Token(ctx context.Context, repo data.Store) (string, error) {
+	if tok := os.Getenv(EnvToken); tok != "" {
+		return tok, nil
+	}
+	tok, ok, err := repo.Setting(ctx, tokenSetting)
+	if err != nil || ok {
+		return tok, err
+	}
+	return ResetToken(ctx, repo)
+}
+
+// ResetToken replaces the stored API token with a new random one.
+func ResetToken(ctx context.Context, repo data.Store) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	tok := hex.EncodeToString(buf)
+	return tok, repo.SetSetting(ctx, tokenSetting, tok)
+}
+
+// ListenAndServe serves h on addr until ctx is cancelled, then shuts down
+// gracefully. ready, if not nil, is called with the bound address.
+func ListenAndServe(ctx context.Context, addr string, h http.Handler, ready func(addr string)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	if ready != nil {
+		ready(ln.Addr().String())
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdown)
+	}
+}
+
+func (s *Server) handleSchema(w http.ResponseWriter, r *http.Request) {
+	raw, err := schemas.ReadFile("schema/" + r.PathValue("name") + ".json")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no such schema")
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(raw)
+}
+
+// entryInput mirrors schema/entry-input.json.
+type entryInput struct {
+	Exercise string  `json:"exercise"`
+	Weight   float64 `json:"weight"`
+	Reps     int     `json:"reps"`
+	Sets     int     `json:"sets"`
+	RPE      float64 `json:"rpe"`
+	Notes    string  `json:"notes"`
+	Date     string  `json:"date"`
+}
+
+func (s *Server) readEntryInput(w http.ResponseWriter, r *http.Request) (*entryInput, bool) {
+	var in entryInput
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return nil, false
+	}
+	if !logic.IsKnownExercise(in.Exercise) {
+		writeErr(w, data.Invalid("exercise", fmt.Sprintf("unknown exercise %q", in.Exercise)))
+		return nil, false
+	}
+	if in.Date == "" {
+		in.Date = data.Today().String()
+	}
+	return &in, true
+}
+
+func (in *entryInput) fields() (weight, reps, sets, rpe string) {
+	if in.RPE != 0 {
+		rpe = strconv.FormatFloat(in.RPE, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(in.Weight, 'f', -1, 64), strconv.Itoa(in.Reps), strconv.Itoa(in.Sets), rpe
+}
+
+func (s *Server) handleListEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := logic.ExportFilter{Exercise: q.Get("exercise")}
+	var err error
+	if f.From, err = optionalDate("from", q.Get("from")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	if f.To, err = optionalDate("to", q.Get("to")); err != nil {
+		writeErr(w, err)
+		return
+	}
+	limit, err := optionalInt(q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	entries, err := s.tracker.ExportEntries(r.Context(), f)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	// newest first, like the history view
+	out := make([]*data.Entry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0 && (limit == 0 || len(out) < limit); i-- {
+		out = append(out, entries[i])
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleCreateEntry(w http.ResponseWriter, r *http.Request) {
+	in, ok := s.readEntryInput(w, r)
+	if !ok {
+		return
+	}
+	weight, reps, sets, rpe := in.fields()
+	e, err := s.tracker.AddEntry(r.Context(), in.Exercise, weight, reps, sets, rpe, in.Notes, in.Date)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.checkGoals(r)
+	w.Header().Set("Location", fmt.Sprintf("/api/entries/%d", e.ID))
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func (s *Server) handleGetEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := entryID(w, r)
+	if !ok {
+		return
+	}
+	e, err := s.tracker.GetEntry(r.Context(), id)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (s *Server) handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := entryID(w, r)
+	if !ok {
+		return
+	}
+	in, ok := s.readEntryInput(w, r)
+	if !ok {
+		return
+	}
+	weight, reps, sets, rpe := in.fields()
+	e, err := s.tracker.UpdateEntry(r.Context(), id, in.Exercise, weight, reps, sets, rpe, in.Notes, in.Date)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.checkGoals(r)
+	writeJSON(w, http.StatusOK, e)
+}
+
+func (s *Server) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
+	id, ok := entryID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.tracker.DeleteEntry(r.Context(), id); err != nil {
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleExercises(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, data.AllExercises())
+}
+
+// exercise reads the {name} path value, which must be an exact exercise
+// name.
+func exercise(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if !logic.IsKnownExercise(name) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown exercise %q", name))
+		return "", false
+	}
+	return name, true
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	name, ok := exercise(w, r)
+	if !ok {
+		return
+	}
+	entries, err := s.tracker.GetHistory(r.Context(), name)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if entries == nil {
+		entries = []*data.Entry{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handlePB(w http.ResponseWriter, r *http.Request) {
+	name, ok := exercise(w, r)
+	if !ok {
+		return
+	}
+	pb, err := s.tracker.GetPersonalBest(r.Context(), name)
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pb)
+}
+
+// handlePBs lists personal bests for every exercise that has been logged.
+func (s *Server) handlePBs(w http.ResponseWriter, r *http.Request) {
+	pbs := []*data.PersonalBest{}
+	for _, ex := range data.AllExercises() {
+		last, err := s.tracker.GetLastEntry(r.Context(), ex)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		if last == nil {
+			continue
+		}
+		pb, err := s.tracker.GetPersonalBest(r.Context(), ex)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		pbs = append(pbs, pb)
+	}
+	writeJSON(w, http.StatusOK, pbs)
+}
+
+func (s *Server) handleChart(w http.ResponseWriter, r *http.Request) {
+	name, ok := exercise(w, r)
+	if !ok {
+		return
+	}
+	series := r.URL.Query().Get("series")
+	if series == "" {
+		series = "weight"
+	}
+	var pts []logic.ChartPoint
+	var err error
+	switch series {
+	case "weight":
+		pts, err = s.anal.WeightOverTime(r.Context(), name)
+	case "volume":
+		pts, err = s.anal.VolumeOverTime(r.Context(), name)
+	case "e1rm":
+		pts, err = s.anal.E1RMOverTime(r.Context(), name)
+	default:
+		writeErr(w, data.Invalid("series", "series must be weight, volume or e1rm"))
+		return
+	}
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	if pts == nil {
+		pts = []logic.ChartPoint{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"exercise": name, "series": series, "points": pts})
+}
+
+type planItem struct {
+	Exercise string      `json:"exercise"`
+	Last     *data.Entry `json:"last"`
+}
+
+func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	day := time.Now().Weekday().String()
+	if d := q.Get("day"); d != "" {
+		var ok bool
+		if day, ok = weekday(d); !ok {
+			writeErr(w, data.Invalid("day", fmt.Sprintf("unknown day %q", d)))
+			return
+		}
+	}
+	week, err := optionalInt(q.Get("week"))
+	if err != nil || week > 2 {
+		writeErr(w, data.Invalid("week", "week must be 1 or 2"))
+		return
+	}
+	if week == 0 {
+		if week, err = s.repo.GetCurrentWeek(r.Context()); err != nil {
+			writeErr(w, err)
+			return
+		}
+	}
+	items := []planItem{}
+	for _, ex := range data.WorkoutDays(day, week) {
+		last, err := s.tracker.GetLastEntry(r.Context(), ex)
+		if err != nil {
+			writeErr(w, err)
+			return
+		}
+		items = append(items, planItem{Exercise: ex, Last: last})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"day": day, "week": week, "exercises": items})
+}
+
+type weekBody struct {
+	Week int `json:"week"`
+}
+
+func (s *Server) handleGetWeek(w http.ResponseWriter, r *http.Request) {
+	week, err := s.repo.GetCurrentWeek(r.Context())
+	if err != nil {
+		writeErr(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, weekBody{week})
+}
+
+func (s *Server) handleSetWeek(w http.ResponseWriter, r *http.Request) {
+	var body weekBody
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if err := s.repo.SetCurrentWeek(r.Context(), body.Week); err != nil {
+		writeErr(w, err)
+		return
+	}
+	s.handleGetWeek(w, r)
+}
+
+func entryID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusNotFound, "entry not found")
+		return 0, false
+	}
+	return id, true
+}
+
+func weekday(name string) (string, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d.String(), true
+		}
+	}
+	return "", false
+}
+
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil && n < 0 {
+		err = errors.New("negative")
+	}
+	return n, err
+}
+
+// optionalDate parses a date query parameter, rejecting it as field.
+func optionalDate(field, s string) (data.Date, error) {
+	if s == "" {
+		return "", nil
+	}
+	d, err := data.ParseDate(s)
+	if err != nil {
+		return "", data.Invalid(field, err.Error())
+	}
+	return d, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+type validationBody struct {
+	Error  string            `json:"error"`
+	Field  string            `json:"field"`
+	Fields map[string]string `json:"fields"`
+}
+
+// writeErr picks the status for an error from the data and logic layers:
+// 404 for data.ErrNotFound, 400 listing the rejected fields for a validation
+// error and 500 for anything else.
+func writeErr(w http.ResponseWriter, err error) {
+	var verr *data.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		body := validationBody{Error: verr.Error(), Fields: map[string]string{}}
+		for _, f := range verr.Fields {
+			if body.Field == "" {
+				body.Field = f.Field
+			}
+			if _, ok := body.Fields[f.Field]; !ok {
+				body.Fields[f.Field] = f.Msg
+			}
+		}
+		writeJSON(w, http.StatusBadRequest, body)
+	case errors.Is(err, data.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,489 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts, _ := newTestServerRepo(t)
+	return ts
+}
+
+// newTestServerRepo is newTestServer with the repository behind it, for
+// data the API cannot create.
+func newTestServerRepo(t *testing.T) (*httptest.Server, *data.Repository) {
+	t.Helper()
+	db, err := data.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo := data.NewRepository(db)
+	ts := httptest.NewServer(New(repo, logic.NewTracker(repo), logic.NewAnalytics(repo), testToken))
+	t.Cleanup(ts.Close)
+	return ts, repo
+}
+
+// do sends a request with the test token and decodes the JSON response
+// into out, if given.
+func do(t *testing.T, ts *httptest.Server, method, path string, body any, out any) *http.Response {
+	t.Helper()
+	var rd io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rd = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return res
+}
+
+func wantStatus(t *testing.T, res *http.Response, want int) {
+	t.Helper()
+	if res.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d", res.Request.Method, res.Request.URL.Path, res.StatusCode, want)
+	}
+}
+
+// conforms checks v against the top level of a schema: required keys are
+// present, no unknown keys appear and values have the declared JSON type.
+func conforms(t *testing.T, schemaName string, v map[string]any) {
+	t.Helper()
+	raw, err := schemas.ReadFile("schema/" + schemaName + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Required   []string `json:"required"`
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatalf("schema %s: %v", schemaName, err)
+	}
+	for _, k := range schema.Required {
+		if _, ok := v[k]; !ok {
+			t.Errorf("%s: missing required %q in %v", schemaName, k, v)
+		}
+	}
+	for k, val := range v {
+		prop, ok := schema.Properties[k]
+		if !ok {
+			t.Errorf("%s: unexpected property %q", schemaName, k)
+			continue
+		}
+		if prop.Type != "" && jsonType(val) != prop.Type && !(prop.Type == "number" && jsonType(val) == "integer") {
+			t.Errorf("%s: %q is %s, want %s", schemaName, k, jsonType(val), prop.Type)
+		}
+	}
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+func TestAuth(t *testing.T) {
+	ts := newTestServer(t)
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req, _ := http.NewRequest("GET", ts.URL+"/api/week", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, res.StatusCode)
+		}
+	}
+	wantStatus(t, do(t, ts, "GET", "/api/week", nil, nil), http.StatusOK)
+}
+
+func TestEmptyTokenRejectsEverything(t *testing.T) {
+	db, err := data.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := data.NewRepository(db)
+	h := New(repo, logic.NewTracker(repo), logic.NewAnalytics(repo), "")
+	req := httptest.NewRequest("GET", "/api/week", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", rec.Code)
+	}
+}
+
+func TestEntryCRUD(t *testing.T) {
+	ts := newTestServer(t)
+	const ex = "Barbell Squats"
+
+	var created map[string]any
+	res := do(t, ts, "POST", "/api/entries", map[string]any{
+		"exercise": ex, "weight": 100, "reps": 5, "sets": 3, "date": "2025-03-01",
+	}, &created)
+	wantStatus(t, res, http.StatusCreated)
+	conforms(t, "entry", created)
+	if created["volume"] != 1500.0 {
+		t.Errorf("volume = %v, want 1500", created["volume"])
+	}
+	path := fmt.Sprintf("/api/entries/%d", int64(created["id"].(float64)))
+	if loc := res.Header.Get("Location"); loc != path {
+		t.Errorf("Location = %q, want %q", loc, path)
+	}
+
+	var got map[string]any
+	wantStatus(t, do(t, ts, "GET", path, nil, &got), http.StatusOK)
+	if got["exercise"] != ex || got["date"] != "2025-03-01" {
+		t.Errorf("GET returned %v", got)
+	}
+
+	var updated map[string]any
+	wantStatus(t, do(t, ts, "PUT", path, map[string]any{
+		"exercise": ex, "weight": 105, "reps": 5, "sets": 3, "notes": "easy", "date": "2025-03-01",
+	}, &updated), http.StatusOK)
+	conforms(t, "entry", updated)
+	if updated["weight"] != 105.0 || updated["notes"] != "easy" || updated["volume"] != 1575.0 {
+		t.Errorf("PUT returned %v", updated)
+	}
+
+	var list []map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/entries?exercise="+url.QueryEscape(ex), nil, &list), http.StatusOK)
+	if len(list) != 1 {
+		t.Fatalf("list has %d entries, want 1", len(list))
+	}
+
+	wantStatus(t, do(t, ts, "DELETE", path, nil, nil), http.StatusNoContent)
+	wantStatus(t, do(t, ts, "GET", path, nil, nil), http.StatusNotFound)
+	wantStatus(t, do(t, ts, "DELETE", path, nil, nil), http.StatusNotFound)
+	wantStatus(t, do(t, ts, "PUT", path, map[string]any{
+		"exercise": ex, "weight": 1, "reps": 1, "sets": 1,
+	}, nil), http.StatusNotFound)
+}
+
+func TestUpdateEntryKeepsWarmupAndRPE(t *testing.T) {
+	ts, repo := newTestServerRepo(t)
+	const ex = "Barbell Squats"
+	base := "/api/exercises/" + url.PathEscape(ex)
+	var working map[string]any
+	wantStatus(t, do(t, ts, "POST", "/api/entries", map[string]any{
+		"exercise": ex, "weight": 100, "reps": 5, "sets": 1, "rpe": 8, "date": "2025-03-03",
+	}, &working), http.StatusCreated)
+	warmup := &data.Entry{Exercise: ex, Weight: 60, Reps: 5, Sets: 1, Date: "2025-03-03", Warmup: true}
+	if err := repo.Save(t.Context(), warmup); err != nil {
+		t.Fatal(err)
+	}
+
+	// a warm-up edited above the working weight is still a warm-up
+	var got map[string]any
+	wantStatus(t, do(t, ts, "PUT", fmt.Sprintf("/api/entries/%d", warmup.ID), map[string]any{
+		"exercise": ex, "weight": 120, "reps": 5, "sets": 1, "date": "2025-03-03",
+	}, &got), http.StatusOK)
+	conforms(t, "entry", got)
+	if got["warmup"] != true || got["weight"] != 120.0 {
+		t.Errorf("updated warm-up = %v", got)
+	}
+	var pb map[string]any
+	wantStatus(t, do(t, ts, "GET", base+"/pb", nil, &pb), http.StatusOK)
+	if pb["max_weight"] != 100.0 {
+		t.Errorf("pb after editing a warm-up = %v, want 100 kg", pb)
+	}
+
+	// leaving rpe out keeps the recorded one
+	var set map[string]any
+	wantStatus(t, do(t, ts, "PUT", fmt.Sprintf("/api/entries/%d", int64(working["id"].(float64))), map[string]any{
+		"exercise": ex, "weight": 102.5, "reps": 5, "sets": 1, "date": "2025-03-03",
+	}, &set), http.StatusOK)
+	if set["rpe"] != 8.0 || set["warmup"] != nil {
+		t.Errorf("updated working set = %v, want rpe 8 kept", set)
+	}
+}
+
+func TestCreateEntryValidation(t *testing.T) {
+	ts := newTestServer(t)
+	cases := []struct {
+		name  string
+		body  any
+		field string
+	}{
+		{"unknown exercise", map[string]any{"exercise": "Jumping Jacks", "weight": 0, "reps": 10, "sets": 1}, "exercise"},
+		{"negative weight", map[string]any{"exercise": "Leg Press", "weight": -5, "reps": 10, "sets": 1}, "weight"},
+		{"zero reps", map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 0, "sets": 1}, "reps"},
+		{"bad date", map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 10, "sets": 1, "date": "2025-02-30"}, "date"},
+		{"too heavy", map[string]any{"exercise": "Leg Press", "weight": 1200, "reps": 10, "sets": 1}, "weight"},
+		{"future date", map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 10, "sets": 1, "date": "2999-01-01"}, "date"},
+		{"bad rpe", map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 10, "sets": 1, "rpe": 5}, "rpe"},
+		{"unknown field", map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 10, "sets": 1, "tempo": "3010"}, ""},
+		{"wrong type", map[string]any{"exercise": "Leg Press", "weight": "heavy", "reps": 10, "sets": 1}, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body map[string]any
+			wantStatus(t, do(t, ts, "POST", "/api/entries", c.body, &body), http.StatusBadRequest)
+			conforms(t, "error", body)
+			if field, _ := body["field"].(string); field != c.field {
+				t.Errorf("field = %q, want %q", field, c.field)
+			}
+		})
+	}
+}
+
+func TestCreateEntryListsEveryInvalidField(t *testing.T) {
+	ts := newTestServer(t)
+	var body map[string]any
+	in := map[string]any{"exercise": "Leg Press", "weight": 50, "reps": 500, "sets": 0, "date": "2025-13-01"}
+	wantStatus(t, do(t, ts, "POST", "/api/entries", in, &body), http.StatusBadRequest)
+	conforms(t, "error", body)
+	fields, _ := body["fields"].(map[string]any)
+	for _, f := range []string{"reps", "sets", "date"} {
+		if msg, _ := fields[f].(string); msg == "" {
+			t.Errorf("no reason given for %s in %v", f, body)
+		}
+	}
+	if len(fields) != 3 || body["field"] != "reps" {
+		t.Errorf("fields = %v, field = %v; want reps, sets and date with reps first", fields, body["field"])
+	}
+}
+
+func TestEntryDatesAreNormalized(t *testing.T) {
+	ts := newTestServer(t)
+	var e data.Entry
+	body := map[string]any{"exercise": "Leg Press", "weight": 100, "reps": 10, "sets": 1, "date": "2025-3-1"}
+	wantStatus(t, do(t, ts, "POST", "/api/entries", body, &e), http.StatusCreated)
+	if e.Date != "2025-03-01" {
+		t.Errorf("date stored as %q, want 2025-03-01", e.Date)
+	}
+	var list []data.Entry
+	wantStatus(t, do(t, ts, "GET", "/api/entries?from=1/3/2025&to=2025-03-01", nil, &list), http.StatusOK)
+	if len(list) != 1 {
+		t.Errorf("date filter returned %d entries, want 1", len(list))
+	}
+	var errBody map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/entries?from=March", nil, &errBody), http.StatusBadRequest)
+	if errBody["field"] != "from" {
+		t.Errorf("bad from: field = %v, want from", errBody["field"])
+	}
+}
+
+func TestEntryRPE(t *testing.T) {
+	ts := newTestServer(t)
+	var e map[string]any
+	body := map[string]any{"exercise": "Barbell Squats", "weight": 100, "reps": 5, "sets": 1, "rpe": 8, "date": "2025-03-01"}
+	wantStatus(t, do(t, ts, "POST", "/api/entries", body, &e), http.StatusCreated)
+	conforms(t, "entry", e)
+	if e["rpe"] != 8.0 {
+		t.Errorf("rpe = %v, want 8", e["rpe"])
+	}
+	body["rpe"] = 11
+	wantStatus(t, do(t, ts, "POST", "/api/entries", body, nil), http.StatusBadRequest)
+
+	var chart map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/exercises/"+url.PathEscape("Barbell Squats")+"/chart?series=e1rm", nil, &chart), http.StatusOK)
+	conforms(t, "chart", chart)
+	pts := chart["points"].([]any)
+	if len(pts) != 1 || pts[0].(map[string]any)["value"] != 123.3 {
+		t.Errorf("e1rm points = %v, want 123.3", pts)
+	}
+}
+
+func TestExerciseQueries(t *testing.T) {
+	ts := newTestServer(t)
+	const ex = "Leg Press"
+	for i, w := range []float64{100, 120, 110} {
+		body := map[string]any{"exercise": ex, "weight": w, "reps": 10, "sets": 2, "date": fmt.Sprintf("2025-03-0%d", i+1)}
+		wantStatus(t, do(t, ts, "POST", "/api/entries", body, nil), http.StatusCreated)
+	}
+	base := "/api/exercises/" + url.PathEscape(ex)
+
+	var history []map[string]any
+	wantStatus(t, do(t, ts, "GET", base+"/history", nil, &history), http.StatusOK)
+	if len(history) != 3 || history[0]["date"] != "2025-03-03" {
+		t.Errorf("history not newest first: %v", history)
+	}
+
+	var pb map[string]any
+	wantStatus(t, do(t, ts, "GET", base+"/pb", nil, &pb), http.StatusOK)
+	conforms(t, "personal-best", pb)
+	if pb["max_weight"] != 120.0 || pb["max_volume"] != 2400.0 {
+		t.Errorf("pb = %v", pb)
+	}
+
+	var pbs []map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/pbs", nil, &pbs), http.StatusOK)
+	if len(pbs) != 1 || pbs[0]["exercise"] != ex {
+		t.Errorf("pbs = %v", pbs)
+	}
+
+	var chart map[string]any
+	wantStatus(t, do(t, ts, "GET", base+"/chart?series=volume", nil, &chart), http.StatusOK)
+	conforms(t, "chart", chart)
+	pts := chart["points"].([]any)
+	if len(pts) != 3 || pts[0].(map[string]any)["value"] != 2000.0 {
+		t.Errorf("chart points = %v", pts)
+	}
+	wantStatus(t, do(t, ts, "GET", base+"/chart?series=reps", nil, nil), http.StatusBadRequest)
+	wantStatus(t, do(t, ts, "GET", "/api/exercises/Nope/history", nil, nil), http.StatusNotFound)
+}
+
+func TestPlanAndWeek(t *testing.T) {
+	ts := newTestServer(t)
+
+	var week map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/week", nil, &week), http.StatusOK)
+	conforms(t, "week", week)
+	if week["week"] != 1.0 {
+		t.Errorf("default week = %v, want 1", week["week"])
+	}
+	wantStatus(t, do(t, ts, "PUT", "/api/week", map[string]any{"week": 2}, &week), http.StatusOK)
+	if week["week"] != 2.0 {
+		t.Errorf("week after PUT = %v, want 2", week["week"])
+	}
+	wantStatus(t, do(t, ts, "PUT", "/api/week", map[string]any{"week": 3}, nil), http.StatusBadRequest)
+
+	var plan map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/plan?day=friday", nil, &plan), http.StatusOK)
+	conforms(t, "plan", plan)
+	exs := plan["exercises"].([]any)
+	want := data.WorkoutDays("Friday", 2)
+	if plan["week"] != 2.0 || len(exs) != len(want) || exs[0].(map[string]any)["exercise"] != want[0] {
+		t.Errorf("plan = %v", plan)
+	}
+
+	wantStatus(t, do(t, ts, "GET", "/api/plan?day=saturday", nil, &plan), http.StatusOK)
+	if len(plan["exercises"].([]any)) != 0 {
+		t.Errorf("saturday should be a rest day: %v", plan)
+	}
+	wantStatus(t, do(t, ts, "GET", "/api/plan?day=someday", nil, nil), http.StatusBadRequest)
+}
+
+func TestGoals(t *testing.T) {
+	ts := newTestServer(t)
+	var goals []map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/goals", nil, &goals), http.StatusOK)
+	if goals == nil || len(goals) != 0 {
+		t.Fatalf("goals on an empty database = %v, want []", goals)
+	}
+
+	var body validationBody
+	wantStatus(t, do(t, ts, "POST", "/api/goals", map[string]any{"metric": "bodyweight", "target": 80}, &body), http.StatusBadRequest)
+	if body.Fields["metric"] == "" {
+		t.Errorf("bodyweight goal without a reading: %+v", body)
+	}
+	var reading map[string]any
+	wantStatus(t, do(t, ts, "POST", "/api/bodyweight", map[string]any{"weight": 84.5}, &reading), http.StatusCreated)
+	conforms(t, "bodyweight", reading)
+	wantStatus(t, do(t, ts, "POST", "/api/bodyweight", map[string]any{"weight": 4}, nil), http.StatusBadRequest)
+
+	var bw map[string]any
+	wantStatus(t, do(t, ts, "POST", "/api/goals", map[string]any{"metric": "bodyweight", "target": 80}, &bw), http.StatusCreated)
+	conforms(t, "goal", bw)
+	if bw["start"] != 84.5 || bw["title"] != "Bodyweight 80 kg" {
+		t.Errorf("bodyweight goal = %v", bw)
+	}
+
+	wantStatus(t, do(t, ts, "POST", "/api/entries", map[string]any{
+		"exercise": "Barbell Squats", "weight": 100, "reps": 5, "sets": 3,
+	}, nil), http.StatusCreated)
+	var squat map[string]any
+	wantStatus(t, do(t, ts, "POST", "/api/goals", map[string]any{"exercise": "Barbell Squats", "target": 105}, &squat), http.StatusCreated)
+	conforms(t, "goal", squat)
+	wantStatus(t, do(t, ts, "POST", "/api/goals", map[string]any{"exercise": "Barbell Squats", "target": 120, "by": "2020-01-01", "extra": 1}, nil), http.StatusBadRequest)
+
+	// correcting a set to the target weight marks the goal reached, and it
+	// stays reached when the set is corrected back down
+	var set map[string]any
+	wantStatus(t, do(t, ts, "POST", "/api/entries", map[string]any{
+		"exercise": "Barbell Squats", "weight": 102.5, "reps": 3, "sets": 1,
+	}, &set), http.StatusCreated)
+	setPath := fmt.Sprintf("/api/entries/%d", int64(set["id"].(float64)))
+	for _, weight := range []float64{105, 102.5} {
+		wantStatus(t, do(t, ts, "PUT", setPath, map[string]any{
+			"exercise": "Barbell Squats", "weight": weight, "reps": 3, "sets": 1,
+		}, nil), http.StatusOK)
+	}
+	wantStatus(t, do(t, ts, "GET", "/api/goals", nil, &goals), http.StatusOK)
+	if len(goals) != 2 || goals[1]["achieved"] == nil || goals[0]["achieved"] != nil {
+		t.Fatalf("goals after reaching one = %v; want the open one first", goals)
+	}
+
+	path := fmt.Sprintf("/api/goals/%d", int64(bw["id"].(float64)))
+	wantStatus(t, do(t, ts, "DELETE", path, nil, nil), http.StatusNoContent)
+	wantStatus(t, do(t, ts, "DELETE", path, nil, nil), http.StatusNotFound)
+
+	var readings []map[string]any
+	wantStatus(t, do(t, ts, "GET", "/api/bodyweight", nil, &readings), http.StatusOK)
+	if len(readings) != 1 || readings[0]["weight"] != 84.5 {
+		t.Errorf("bodyweight readings = %v", readings)
+	}
+}
+
+func TestSchemasArePublicAndValidJSON(t *testing.T) {
+	ts := newTestServer(t)
+	files, err := schemas.ReadDir("schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		name := f.Name()[:len(f.Name())-len(".json")]
+		res, err := http.Get(ts.URL + "/api/schema/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]any
+		err = json.NewDecoder(res.Body).Decode(&v)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK || err != nil {
+			t.Errorf("schema %s: status %d, %v", name, res.StatusCode, err)
+		}
+	}
+}
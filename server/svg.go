@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+
+	"progresstracker/logic"
+)
+
+// Chart geometry in SVG user units. The viewBox scales it to any width, so
+// the same markup works on a phone and a desktop browser.
+const (
+	chartW    = 600
+	chartH    = 220
+	chartPadL = 50
+	chartPadR = 20
+	chartPadT = 20
+	chartPadB = 36
+)
+
+// svgChart renders points as a line chart in the style of the desktop
+// charts: four grid lines, value labels on the left and first, middle and
+// last dates along the bottom. Each dot carries a tooltip.
+func svgChart(points []logic.ChartPoint, color, unit string) template.HTML {
+	if len(points) == 0 {
+		return ""
+	}
+	plotW := float64(chartW - chartPadL - chartPadR)
+	plotH := float64(chartH - chartPadT - chartPadB)
+
+	minV, maxV := math.MaxFloat64, -math.MaxFloat64
+	for _, p := range points {
+		minV = math.Min(minV, p.Value)
+		maxV = math.Max(maxV, p.Value)
+	}
+	if maxV == minV {
+		minV--
+		maxV++
+	}
+	rangeV := maxV - minV
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return chartPadL + plotW/2
+		}
+		return chartPadL + float64(i)*plotW/float64(len(points)-1)
+	}
+	y := func(v float64) float64 {
+		return chartPadT + plotH - (v-minV)/rangeV*plotH
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img" xmlns="http://www.w3.org/2000/svg">`, chartW, chartH)
+	for i := 0; i <= 4; i++ {
+		gy := chartPadT + float64(i)*plotH/4
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, chartPadL, gy, chartW-chartPadR, gy)
+		fmt.Fprintf(&b, `<text class="axis" x="%d" y="%.1f" text-anchor="end">%.0f</text>`, chartPadL-8, gy+4, maxV-float64(i)*rangeV/4)
+	}
+
+	labels := []int{0}
+	if len(points) > 2 {
+		labels = append(labels, len(points)/2)
+	}
+	if len(points) > 1 {
+		labels = append(labels, len(points)-1)
+	}
+	for _, i := range labels {
+		date := points[i].Date
+		if len(date) >= 10 {
+			date = date[5:10]
+		}
+		fmt.Fprintf(&b, `<text class="axis" x="%.1f" y="%d" text-anchor="middle">%s</text>`, x(i), chartH-12, html.EscapeString(date.String()))
+	}
+
+	if len(points) > 1 {
+		var pts []string
+		for i, p := range points {
+			pts = append(pts, fmt.Sprintf("%.1f,%.1f", x(i), y(p.Value)))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, color, strings.Join(pts, " "))
+	}
+	for i, p := range points {
+		label := formatNumber(p.Value)
+		if unit != "" {
+			label += " " + unit
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s: %s</title></circle>`,
+			x(i), y(p.Value), color, html.EscapeString(p.Date.String()), label)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+)
+
+const sessionCookie = "pt_session"
+
+//go:embed web
+var webFiles embed.FS
+
+var pages = template.Must(template.New("").Funcs(template.FuncMap{
+	"kg":    func(v float64) string { return formatNumber(v) },
+	"query": func(day, ex string) template.URL { return template.URL(pagesQuery(day, ex)) },
+}).ParseFS(webFiles, "web/*.html"))
+
+// webPage is the data behind every page template. Tab picks the page; the
+// day, week and exercise selection carry across tabs like the desktop
+// sidebar.
+type webPage struct {
+	Tab       string
+	Days      []string
+	Day       string
+	Week      int
+	Exercises []string
+	Exercise  string
+	Flash     string
+	Error     string
+
+	// log tab
+	Last *data.Entry
+	PB   *data.PersonalBest
+	Form entryForm
+
+	// history tab
+	Entries []*data.Entry
+
+	// analytics tab
+	WeightChart template.HTML
+	VolumeChart template.HTML
+	Stats       []webStat
+}
+
+type entryForm struct {
+	Weight, Reps, Sets, RPE, Notes, Date string
+}
+
+type webStat struct {
+	Label, Value string
+}
+
+func (s *Server) webRoutes() {
+	static, _ := fs.Sub(webFiles, "web/static")
+	s.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.FS(static))))
+
+	s.mux.HandleFunc("GET /login", s.handleLoginPage)
+	s.mux.HandleFunc("POST /login", s.handleLogin)
+	s.mux.HandleFunc("POST /logout", s.handleLogout)
+
+	s.mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/log", http.StatusSeeOther)
+	})
+	s.mux.HandleFunc("GET /log", s.session(s.handleLogPage))
+	s.mux.HandleFunc("POST /log", s.session(s.handleLogEntry))
+	s.mux.HandleFunc("GET /history", s.session(s.handleHistoryPage))
+	s.mux.HandleFunc("GET /analytics", s.session(s.handleAnalyticsPage))
+	s.mux.HandleFunc("POST /week", s.session(s.handleToggleWeek))
+}
+
+// sessionValue is what the login cookie holds: derived from the API token,
+// so it stops working when the token is reset, without exposing the token
+// itself.
+func (s *Server) sessionValue() string {
+	mac := hmac.New(sha256.New, []byte(s.token))
+	mac.Write([]byte("web-session"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Server) session(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie(sessionCookie)
+		if err != nil || s.token == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(s.sessionValue())) != 1 {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	s.render(w, http.StatusOK, "login", &webPage{Tab: "login"})
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	tok := strings.TrimSpace(r.FormValue("token"))
+	if s.token == "" || subtle.ConstantTimeCompare([]byte(tok), []byte(s.token)) != 1 {
+		s.render(w, http.StatusUnauthorized, "login", &webPage{Tab: "login", Error: "That token is not right."})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    s.sessionValue(),
+		Path:     "/",
+		MaxAge:   int((90 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/log", http.StatusSeeOther)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// page builds the shared part of a page from the day and ex query (or form)
+// values, defaulting to today's workout and its first exercise.
+func (s *Server) page(r *http.Request, tab string) *webPage {
+	p := &webPage{Tab: tab, Days: data.DayOrder, Week: 1}
+	var err error
+	if p.Week, err = s.repo.GetCurrentWeek(r.Context()); err != nil {
+		p.Error = err.Error()
+	}
+	p.Day = r.FormValue("day")
+	if _, ok := data.WorkoutPlans[p.Day]; !ok {
+		p.Day = data.DayOrder[0]
+		if today := time.Now().Weekday().String(); data.WorkoutPlans[today].Week1 != nil {
+			p.Day = today
+		}
+	}
+	p.Exercises = data.WorkoutDays(p.Day, p.Week)
+	p.Exercise = r.FormValue("ex")
+	if !logic.IsKnownExercise(p.Exercise) && len(p.Exercises) > 0 {
+		p.Exercise = p.Exercises[0]
+	}
+	return p
+}
+
+func (s *Server) handleLogPage(w http.ResponseWriter, r *http.Request) {
+	p := s.page(r, "log")
+	if r.FormValue("saved") != "" {
+		p.Flash = "Entry saved."
+	}
+	s.renderLog(w, r, http.StatusOK, p)
+}
+
+func (s *Server) renderLog(w http.ResponseWriter, r *http.Request, status int, p *webPage) {
+	if p.Form.Date == "" {
+		p.Form.Date = data.Today().String()
+	}
+	var err error
+	if p.Last, err = s.tracker.GetLastEntry(r.Context(), p.Exercise); err == nil {
+		p.PB, err = s.tracker.GetPersonalBest(r.Context(), p.Exercise)
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	s.render(w, status, "log", p)
+}
+
+func (s *Server) handleLogEntry(w http.ResponseWriter, r *http.Request) {
+	p := s.page(r, "log")
+	p.Form = entryForm{
+		Weight: strings.TrimSpace(r.FormValue("weight")),
+		Reps:   strings.TrimSpace(r.FormValue("reps")),
+		Sets:   strings.TrimSpace(r.FormValue("sets")),
+		RPE:    strings.TrimSpace(r.FormValue("rpe")),
+		Notes:  strings.TrimSpace(r.FormValue("notes")),
+		Date:   strings.TrimSpace(r.FormValue("date")),
+	}
+	if _, err := s.tracker.AddEntry(r.Context(), p.Exercise, p.Form.Weight, p.Form.Reps, p.Form.Sets, p.Form.RPE, p.Form.Notes, p.Form.Date); err != nil {
+		p.Error = "Error: " + err.Error()
+		status := http.StatusInternalServerError
+		if errors.Is(err, data.ErrValidation) {
+			status = http.StatusBadRequest
+		}
+		s.renderLog(w, r, status, p)
+		return
+	}
+	s.checkGoals(r)
+	http.Redirect(w, r, fmt.Sprintf("/log%s&saved=1", pagesQuery(p.Day, p.Exercise)), http.StatusSeeOther)
+}
+
+func (s *Server) handleHistoryPage(w http.ResponseWriter, r *http.Request) {
+	p := s.page(r, "history")
+	var err error
+	if p.Entries, err = s.tracker.GetHistory(r.Context(), p.Exercise); err == nil {
+		p.PB, err = s.tracker.GetPersonalBest(r.Context(), p.Exercise)
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	s.render(w, http.StatusOK, "history", p)
+}
+
+func (s *Server) handleAnalyticsPage(w http.ResponseWriter, r *http.Request) {
+	p := s.page(r, "analytics")
+	weight, err := s.anal.WeightOverTime(r.Context(), p.Exercise)
+	if err != nil {
+		p.Error = err.Error()
+	}
+	volume, err := s.anal.VolumeOverTime(r.Context(), p.Exercise)
+	if err != nil {
+		p.Error = err.Error()
+	}
+	p.WeightChart = svgChart(weight, "#00c88c", "kg")
+	p.VolumeChart = svgChart(volume, "#6478ff", "")
+	if len(weight) > 0 {
+		first, last := weight[0].Value, weight[len(weight)-1].Value
+		p.Stats = []webStat{
+			{"Total Sessions", fmt.Sprint(len(weight))},
+			{"Starting Weight", fmt.Sprintf("%.1f kg", first)},
+			{"Current Weight", fmt.Sprintf("%.1f kg", last)},
+			{"Weight Change", fmt.Sprintf("%+.1f kg", last-first)},
+		}
+	}
+	s.render(w, http.StatusOK, "analytics", p)
+}
+
+func (s *Server) handleToggleWeek(w http.ResponseWriter, r *http.Request) {
+	week, err := s.repo.GetCurrentWeek(r.Context())
+	if err == nil {
+		err = s.repo.SetCurrentWeek(r.Context(), 3-week)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tab := r.FormValue("tab")
+	if tab != "history" && tab != "analytics" {
+		tab = "log"
+	}
+	http.Redirect(w, r, "/"+tab+pagesQuery(r.FormValue("day"), ""), http.StatusSeeOther)
+}
+
+func pagesQuery(day, ex string) string {
+	v := url.Values{"day": {day}}
+	if ex != "" {
+		v.Set("ex", ex)
+	}
+	return "?" + v.Encode()
+}
+
+func (s *Server) render(w http.ResponseWriter, status int, name string, p *webPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pages.ExecuteTemplate(w, name, p); err != nil {
+		fmt.Fprintf(w, "<p>template error: %s</p>", template.HTMLEscapeString(err.Error()))
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"progresstracker/logic"
+)
+
+// webClient logs in to ts and returns a client carrying the session cookie.
+func webClient(t *testing.T, ts interface{ Client() *http.Client }, base string) *http.Client {
+	t.Helper()
+	jar, _ := cookiejar.New(nil)
+	c := ts.Client()
+	c.Jar = jar
+	res, err := c.PostForm(base+"/login", url.Values{"token": {testToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Request.URL.Path != "/log" {
+		t.Fatalf("login ended at %s, want /log", res.Request.URL.Path)
+	}
+	return c
+}
+
+func body(t *testing.T, res *http.Response) string {
+	t.Helper()
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestWebRequiresLogin(t *testing.T) {
+	ts := newTestServer(t)
+	for _, path := range []string{"/", "/log", "/history", "/analytics"} {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.Request.URL.Path != "/login" {
+			t.Errorf("%s ended at %s, want /login", path, res.Request.URL.Path)
+		}
+	}
+
+	res, err := http.PostForm(ts.URL+"/login", url.Values{"token": {"wrong"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusUnauthorized || !strings.Contains(body(t, res), "not right") {
+		t.Errorf("wrong token: status %d", res.StatusCode)
+	}
+}
+
+func TestWebLogHistoryAnalytics(t *testing.T) {
+	ts := newTestServer(t)
+	c := webClient(t, ts, ts.URL)
+	const ex = "Leg Press"
+
+	for i, w := range []string{"100", "120"} {
+		res, err := c.PostForm(ts.URL+"/log", url.Values{
+			"day": {"Friday"}, "ex": {ex}, "weight": {w}, "reps": {"10"}, "sets": {"3"},
+			"date": {"2025-03-0" + string(rune('1'+i))},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page := body(t, res); !strings.Contains(page, "Entry saved.") {
+			t.Fatalf("save %d: no confirmation in page", i)
+		}
+	}
+
+	res, err := c.PostForm(ts.URL+"/log", url.Values{"day": {"Friday"}, "ex": {ex}, "weight": {"abc"}, "reps": {"10"}, "sets": {"3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusBadRequest || !strings.Contains(body(t, res), "invalid weight") {
+		t.Errorf("invalid weight: status %d", res.StatusCode)
+	}
+
+	res, err = c.Get(ts.URL + "/history?day=Friday&ex=" + url.QueryEscape(ex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := body(t, res)
+	if !strings.Contains(page, "120 kg × 10 reps × 3 sets 🏆") || !strings.Contains(page, "100 kg × 10 reps") {
+		t.Errorf("history page missing entries:\n%s", page)
+	}
+
+	res, err = c.Get(ts.URL + "/analytics?day=Friday&ex=" + url.QueryEscape(ex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page = body(t, res)
+	if strings.Count(page, "<svg") != 2 || !strings.Contains(page, "&#43;20.0 kg") {
+		t.Errorf("analytics page missing charts or stats:\n%s", page)
+	}
+	if strings.Contains(page, "http://") && !strings.Contains(page, `xmlns="http://www.w3.org/2000/svg"`) {
+		t.Error("page references an external resource")
+	}
+}
+
+func TestWebWeekToggle(t *testing.T) {
+	ts := newTestServer(t)
+	c := webClient(t, ts, ts.URL)
+	res, err := c.PostForm(ts.URL+"/week", url.Values{"tab": {"history"}, "day": {"Monday"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := body(t, res)
+	if res.Request.URL.Path != "/history" || !strings.Contains(page, "Week 2") {
+		t.Errorf("after toggle at %s, page lacks Week 2", res.Request.URL.Path)
+	}
+}
+
+func TestSVGChart(t *testing.T) {
+	if svgChart(nil, "#fff", "kg") != "" {
+		t.Error("empty series should render nothing")
+	}
+	one := string(svgChart([]logic.ChartPoint{{Date: "2025-03-01", Value: 50}}, "#fff", "kg"))
+	if strings.Contains(one, "<polyline") || strings.Count(one, "<circle") != 1 {
+		t.Errorf("single point chart: %s", one)
+	}
+	pts := []logic.ChartPoint{{Date: "2025-03-01", Value: 50}, {Date: "2025-03-08", Value: 55}, {Date: "2025-03-15", Value: 52.5}}
+	svg := string(svgChart(pts, "#00c88c", "kg"))
+	for _, want := range []string{"<polyline", "03-01", "03-08", "03-15", "2025-03-15: 52.5 kg"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("chart lacks %q", want)
+		}
+	}
+}
@@ -1,16 +1,20 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"os"
+	"strings"
 	"time"
 
 	"progresstracker/data"
 	"progresstracker/logic"
 
 	"gioui.org/app"
+	"gioui.org/io/key"
 	"gioui.org/layout"
 	"gioui.org/op"
 	"gioui.org/op/clip"
@@ -23,16 +27,20 @@ import (
 type NavTab int
 
 const (
-	TabLog NavTab = iota
+	TabDashboard NavTab = iota
+	TabLog
 	TabHistory
 	TabAnalytics
+	TabSettings
 )
 
 type App struct {
+	ctx     context.Context
 	th      *material.Theme
 	tracker *logic.Tracker
 	anal    *logic.Analytics
-	repo    *data.Repository
+	repo    data.Store
+	backups *data.Backups
 
 	activeTab NavTab
 
@@ -45,51 +53,160 @@ type App struct {
 	weightEdit widget.Editor
 	repsEdit   widget.Editor
 	setsEdit   widget.Editor
+	rpeEdit    widget.Editor
 	notesEdit  widget.Editor
 	dateEdit   widget.Editor
+	datePick   datePicker
 	saveBtn    widget.Clickable
 	statusMsg  string
 	statusOK   bool
+	formErrs   map[string]string // rejected log form fields and why
+	confirmKey string            // logFormKey of an outlier awaiting a second click
+	rest       restTimer
+	sess       sessionView
+	sessionBtn widget.Clickable
+	logWarmups warmupRow
 
-	navBtns [3]widget.Clickable
+	navBtns [5]widget.Clickable
 
-	histList    widget.List
-	histEntries []*data.Entry
-	histPB      *data.PersonalBest
+	loader   *loader
+	retryBtn widget.Clickable
+	toast    toast
 
-	chartWeight []logic.ChartPoint
-	chartVolume []logic.ChartPoint
+	dash              resource[*logic.DashboardSummary]
+	dashScroll        widget.List
+	dashStartBtn      widget.Clickable
+	dashSessionBtn    widget.Clickable
+	dashDeloadBtn     widget.Clickable
+	dashNextDeloadBtn widget.Clickable
+
+	last resource[lastData]
+
+	histList widget.List
+	hist     resource[historyData]
+
+	charts      resource[chartData]
 	chartScroll widget.List
 	logScroll   widget.List
 
 	currentWeek   int
 	weekToggleBtn widget.Clickable
+
+	settingsScroll  widget.List
+	settingsMsg     string
+	settingsOK      bool
+	expPathEdit     widget.Editor
+	expExerciseEdit widget.Editor
+	expFromEdit     widget.Editor
+	expToEdit       widget.Editor
+	exportBtn       widget.Clickable
+	impPathEdit     widget.Editor
+	impMapEdit      widget.Editor
+	impUnit         string
+	impUnitBtn      widget.Clickable
+	impPreviewBtn   widget.Clickable
+	impRunBtn       widget.Clickable
+	impPreview      *logic.ImportResult
+	bakPathEdit     widget.Editor
+	backupBtn       widget.Clickable
+	restoreModeBtn  widget.Clickable
+	restoreBtn      widget.Clickable
+	restoreReplace  bool
+	restoreArmed    bool
+	restoreReport   *data.RestoreReport
+	appSource       int
+	appSourceBtn    widget.Clickable
+	appUnit         string
+	appUnitBtn      widget.Clickable
+	appPathEdit     widget.Editor
+	appAnalyzeBtn   widget.Clickable
+	appImportBtn    widget.Clickable
+	appImport       *logic.AppImport
+	appChoices      []int
+	appChoiceBtns   []widget.Clickable
+
+	backupList       []data.BackupInfo
+	backupKeep       int
+	backupSel        int
+	backupSelBtns    []widget.Clickable
+	backupPreview    *data.BackupPreview
+	keepMinusBtn     widget.Clickable
+	keepPlusBtn      widget.Clickable
+	snapNowBtn       widget.Clickable
+	snapRestoreBtn   widget.Clickable
+	snapRestoreArmed bool
+
+	warmScheme    logic.WarmupScheme
+	warmStepsEdit widget.Editor
+	warmBarEdit   widget.Editor
+	warmSaveBtn   widget.Clickable
+
+	equip            logic.Equipment
+	platesEdit       widget.Editor
+	barsEdit         widget.Editor
+	dumbbellStepEdit widget.Editor
+	machineStepEdit  widget.Editor
+	equipSaveBtn     widget.Clickable
+
+	prog             resource[[]*logic.Prescription]
+	progTemplateEdit widget.Editor
+	progLiftsEdit    widget.Editor
+	progSaveBtn      widget.Clickable
+	progClearBtn     widget.Clickable
+
+	goals          resource[[]*logic.GoalProgress]
+	goalEdit       widget.Editor
+	goalAddBtn     widget.Clickable
+	goalDelBtns    map[int64]*widget.Clickable
+	bodyweightEdit widget.Editor
+	bodyweightBtn  widget.Clickable
+	bodyweightMsg  string
 }
 
-func NewApp(repo *data.Repository, tracker *logic.Tracker, anal *logic.Analytics) *App {
+func NewApp(ctx context.Context, repo data.Store, tracker *logic.Tracker, anal *logic.Analytics, backups *data.Backups) *App {
 	a := &App{
+		ctx:       ctx,
 		th:        NewTheme(),
 		tracker:   tracker,
 		anal:      anal,
 		repo:      repo,
+		backups:   backups,
+		loader:    newLoader(),
 		activeDay: 0,
 		activeEx:  0,
 	}
 	a.exScroll.Axis = layout.Vertical
 	a.histList.Axis = layout.Vertical
 	a.chartScroll.Axis = layout.Vertical
-	a.currentWeek = repo.GetCurrentWeek()
+	a.dashScroll.Axis = layout.Vertical
+	a.reloadWeek()
 	a.logScroll.Axis = layout.Vertical
-	a.dateEdit.SetText(time.Now().Format("2006-01-02"))
+	a.dateEdit.SetText(data.Today().String())
 	a.dateEdit.SingleLine = true
 	a.weightEdit.SingleLine = true
 	a.repsEdit.SingleLine = true
 	a.setsEdit.SingleLine = true
+	a.rpeEdit.SingleLine = true
 	a.notesEdit.SingleLine = true
+	a.initSettings()
+	a.loadWarmupScheme()
+	a.loadEquipment()
+	a.loadProgram()
 	a.rebuildExBtns()
 	return a
 }
 
+// reloadWeek reads the current week from the store. On failure it keeps
+// showing week 1 and reports the error.
+func (a *App) reloadWeek() {
+	week, err := a.repo.GetCurrentWeek(a.ctx)
+	if err != nil {
+		a.showError(err)
+		week = 1
+	}
+	a.currentWeek = week
+}
+
 func (a *App) rebuildExBtns() {
 	exs := data.WorkoutDays(data.DayOrder[a.activeDay], a.currentWeek)
 	a.exBtns = make([]widget.Clickable, len(exs))
@@ -109,34 +226,98 @@ func (a *App) currentExercise() string {
 	return exs[a.activeEx]
 }
 
+type lastData struct {
+	entry *data.Entry
+	pb    *data.PersonalBest
+	e1rm  float64 // RPE-adjusted, from the last session
+}
+
+type historyData struct {
+	entries []*data.Entry
+	pb      *data.PersonalBest
+	flags   map[int64]*logic.Outlier
+	grouped map[data.Date][]*data.Entry // superset or circuit partners' sets
+}
+
+type chartData struct {
+	weight []logic.ChartPoint
+	volume []logic.ChartPoint
+	e1rm   []logic.ChartPoint
+}
+
+// The load* methods are called from layout. They only start a query when the
+// cached data is missing, stale or for another exercise.
+
+func (a *App) loadLast() {
+	ex := a.currentExercise()
+	load(a.loader, &a.last, ex, func() (lastData, error) {
+		last, err := a.tracker.GetLastEntry(a.ctx, ex)
+		if err != nil {
+			return lastData{}, err
+		}
+		pb, err := a.tracker.GetPersonalBest(a.ctx, ex)
+		if err != nil {
+			return lastData{}, err
+		}
+		e1rm, err := a.tracker.RecentE1RM(a.ctx, ex)
+		return lastData{last, pb, e1rm}, err
+	})
+}
+
 func (a *App) loadHistory() {
 	ex := a.currentExercise()
-	if ex == "" {
-		return
-	}
-	entries, err := a.tracker.GetHistory(ex)
-	if err == nil {
-		a.histEntries = entries
-	}
-	pb, err := a.tracker.GetPersonalBest(ex)
-	if err == nil {
-		a.histPB = pb
-	}
+	load(a.loader, &a.hist, ex, func() (historyData, error) {
+		entries, err := a.tracker.GetHistory(a.ctx, ex)
+		if err != nil {
+			return historyData{}, err
+		}
+		pb, err := a.tracker.GetPersonalBest(a.ctx, ex)
+		if err != nil {
+			return historyData{}, err
+		}
+		grouped, err := a.tracker.GroupedSets(a.ctx, ex)
+		return historyData{entries, pb, logic.FlagOutliers(entries), grouped}, err
+	})
+}
+
+// loadDashboard is keyed by date so the summary rolls over at midnight.
+func (a *App) loadDashboard() {
+	now := time.Now()
+	load(a.loader, &a.dash, data.DateOf(now).String(), func() (*logic.DashboardSummary, error) {
+		return a.anal.Dashboard(a.ctx, now)
+	})
 }
 
 func (a *App) loadCharts() {
 	ex := a.currentExercise()
-	if ex == "" {
-		return
-	}
-	pts, _ := a.anal.WeightOverTime(ex)
-	a.chartWeight = pts
-	pts2, _ := a.anal.VolumeOverTime(ex)
-	a.chartVolume = pts2
+	load(a.loader, &a.charts, ex, func() (chartData, error) {
+		weight, err := a.anal.WeightOverTime(a.ctx, ex)
+		if err != nil {
+			return chartData{}, err
+		}
+		volume, err := a.anal.VolumeOverTime(a.ctx, ex)
+		if err != nil {
+			return chartData{}, err
+		}
+		e1rm, err := a.anal.E1RMOverTime(a.ctx, ex)
+		return chartData{weight, volume, e1rm}, err
+	})
+}
+
+// invalidateData marks every cached query out of date, after entries or
+// settings change.
+func (a *App) invalidateData() {
+	a.last.invalidate()
+	a.hist.invalidate()
+	a.charts.invalidate()
+	a.dash.invalidate()
+	a.prog.invalidate()
+	a.goals.invalidate()
 }
 
 func (a *App) Run(w *app.Window) error {
 	var ops op.Ops
+	a.loader.window = w
 	for {
 		e := w.Event()
 		switch ev := e.(type) {
@@ -144,6 +325,7 @@ func (a *App) Run(w *app.Window) error {
 			return ev.Err
 		case app.FrameEvent:
 			gtx := app.NewContext(&ops, ev)
+			a.loader.apply()
 			a.update(gtx)
 			a.layout(gtx)
 			ev.Frame(&ops)
@@ -153,28 +335,36 @@ func (a *App) Run(w *app.Window) error {
 
 func (a *App) update(gtx layout.Context) {
 	if a.weekToggleBtn.Clicked(gtx) {
+		week := 1
 		if a.currentWeek == 1 {
-			a.currentWeek = 2
+			week = 2
+		}
+		if err := a.repo.SetCurrentWeek(a.ctx, week); err != nil {
+			a.showError(err)
 		} else {
-			a.currentWeek = 1
+			a.currentWeek = week
+			a.activeEx = 0
+			a.rebuildExBtns()
+			a.statusMsg = ""
+			a.dash.invalidate()
 		}
-		a.repo.SetCurrentWeek(a.currentWeek)
-		a.activeEx = 0
-		a.rebuildExBtns()
-		a.statusMsg = ""
 	}
 
 	for i := range a.navBtns {
 		if a.navBtns[i].Clicked(gtx) {
 			a.activeTab = NavTab(i)
-			if a.activeTab == TabHistory {
-				a.loadHistory()
-			} else if a.activeTab == TabAnalytics {
-				a.loadCharts()
+			// pick up changes made elsewhere, e.g. through the API server
+			a.invalidateData()
+			if a.activeTab == TabSettings {
+				a.refreshBackups()
 			}
 		}
 	}
 
+	if a.retryBtn.Clicked(gtx) {
+		a.invalidateData()
+	}
+
 	for i := range a.dayBtns {
 		if a.dayBtns[i].Clicked(gtx) {
 			if a.activeDay != i {
@@ -186,6 +376,23 @@ func (a *App) update(gtx layout.Context) {
 		}
 	}
 
+	a.updateDeload(gtx)
+	a.updateGoals(gtx)
+	if d := a.dash.val; a.dashSessionBtn.Clicked(gtx) && d != nil && d.Day != "" {
+		a.startSession(gtx, d.Day)
+	}
+	if d := a.dash.val; a.dashStartBtn.Clicked(gtx) && d != nil && d.Day != "" {
+		for i, day := range data.DayOrder {
+			if day == d.Day && a.activeDay != i {
+				a.activeDay = i
+				a.activeEx = 0
+				a.rebuildExBtns()
+			}
+		}
+		a.statusMsg = ""
+		a.activeTab = TabLog
+	}
+
 	day := data.DayOrder[a.activeDay]
 	exs := data.WorkoutDays(day, a.currentWeek)
 	for i := range a.exBtns {
@@ -193,45 +400,158 @@ func (a *App) update(gtx layout.Context) {
 			if a.activeEx != i {
 				a.activeEx = i
 				a.statusMsg = ""
-				if a.activeTab == TabHistory {
-					a.loadHistory()
-				} else if a.activeTab == TabAnalytics {
-					a.loadCharts()
-				}
 			}
 		}
 	}
 
+	a.updateSettings(gtx)
+	a.updateBackups(gtx)
+
+	clearEditedErrors(gtx, a.logFields(), a.formErrs)
+	if a.datePick.update(gtx, &a.dateEdit) {
+		delete(a.formErrs, "date")
+	}
 	if a.saveBtn.Clicked(gtx) {
-		ex := a.currentExercise()
-		entry, err := a.tracker.AddEntry(
-			ex,
-			a.weightEdit.Text(),
-			a.repsEdit.Text(),
-			a.setsEdit.Text(),
-			a.notesEdit.Text(),
-			a.dateEdit.Text(),
-		)
-		if err != nil {
-			a.statusMsg = "Error: " + err.Error()
+		a.saveEntry(gtx)
+	}
+	a.updateLogWarmups(gtx)
+	if a.sessionBtn.Clicked(gtx) {
+		a.startSession(gtx, data.DayOrder[a.activeDay])
+	}
+	a.updateSession(gtx)
+	// after saveEntry and updateSession, so a rest they start schedules its
+	// first tick
+	a.updateRest(gtx)
+}
+
+// logFormKey identifies what the log form would save, so a confirmation
+// only covers the values it was given for.
+func (a *App) logFormKey() string {
+	return strings.Join([]string{
+		a.currentExercise(),
+		a.weightEdit.Text(),
+		a.repsEdit.Text(),
+		a.setsEdit.Text(),
+		a.rpeEdit.Text(),
+		a.dateEdit.Text(),
+	}, "\x00")
+}
+
+// saveEntry saves the log form. An entry far above the recent history needs
+// a second click, which is how typos like 800 for 80 are caught.
+func (a *App) saveEntry(gtx layout.Context) {
+	ex := a.currentExercise()
+	a.formErrs = nil
+	if key := a.logFormKey(); key != a.confirmKey {
+		a.confirmKey = ""
+		o, err := a.tracker.CheckEntry(a.ctx, ex, a.weightEdit.Text(), a.repsEdit.Text(), a.setsEdit.Text(), a.rpeEdit.Text(), a.notesEdit.Text(), a.dateEdit.Text())
+		if err == nil && o != nil {
+			a.confirmKey = key
+			a.statusMsg = "Is this right? The " + o.String() + ". Click SAVE ANYWAY to keep it."
 			a.statusOK = false
-		} else {
-			a.statusMsg = fmt.Sprintf("Saved! Volume: %.0f kg", entry.Volume)
-			a.statusOK = true
-			a.weightEdit.SetText("")
-			a.repsEdit.SetText("")
-			a.setsEdit.SetText("")
-			a.notesEdit.SetText("")
-			a.dateEdit.SetText(time.Now().Format("2006-01-02"))
+			return
+		}
+		// errors are reported by AddEntry below
+	}
+	a.confirmKey = ""
+	entry, err := a.tracker.AddEntry(
+		a.ctx,
+		ex,
+		a.weightEdit.Text(),
+		a.repsEdit.Text(),
+		a.setsEdit.Text(),
+		a.rpeEdit.Text(),
+		a.notesEdit.Text(),
+		a.dateEdit.Text(),
+	)
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.showFormErrors(gtx, ve)
+	case err != nil:
+		a.statusMsg = ""
+		a.showError(err)
+	default:
+		a.statusMsg = fmt.Sprintf("Saved! Volume: %.0f kg", entry.Volume)
+		a.statusOK = true
+		a.weightEdit.SetText("")
+		a.repsEdit.SetText("")
+		a.setsEdit.SetText("")
+		a.rpeEdit.SetText("")
+		a.notesEdit.SetText("")
+		a.dateEdit.SetText(data.Today().String())
+		a.checkGoals()
+		a.invalidateData()
+		a.startRest([]string{ex}, gtx.Now)
+	}
+}
+
+type formField struct {
+	name string
+	ed   *widget.Editor
+}
+
+// logFields are the log form inputs in layout order, named like the fields
+// of a data.ValidationError.
+func (a *App) logFields() []formField {
+	return []formField{
+		{"weight", &a.weightEdit},
+		{"reps", &a.repsEdit},
+		{"sets", &a.setsEdit},
+		{"rpe", &a.rpeEdit},
+		{"date", &a.dateEdit},
+		{"notes", &a.notesEdit},
+	}
+}
+
+// showFormErrors marks every field ve rejected and moves focus to the first
+// of them.
+func (a *App) showFormErrors(gtx layout.Context, ve *data.ValidationError) {
+	a.formErrs = map[string]string{}
+	for _, f := range ve.Fields {
+		if _, ok := a.formErrs[f.Field]; !ok {
+			a.formErrs[f.Field] = f.Msg
+		}
+	}
+	a.statusMsg = "Please fix the highlighted fields"
+	a.statusOK = false
+	for _, f := range a.logFields() {
+		if _, ok := a.formErrs[f.name]; ok {
+			gtx.Execute(key.FocusCmd{Tag: f.ed})
+			break
+		}
+	}
+}
+
+// clearEditedErrors drops the mark in errs from fields edited since they
+// were rejected.
+func clearEditedErrors(gtx layout.Context, fields []formField, errs map[string]string) {
+	if len(errs) == 0 {
+		return
+	}
+	for _, f := range fields {
+		for {
+			ev, ok := f.ed.Update(gtx)
+			if !ok {
+				break
+			}
+			if _, ok := ev.(widget.ChangeEvent); ok {
+				delete(errs, f.name)
+			}
 		}
 	}
 }
 
 func (a *App) layout(gtx layout.Context) layout.Dimensions {
 	fillRect(gtx, ColorBg, gtx.Constraints.Max.X, gtx.Constraints.Max.Y)
-	return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
-		layout.Rigid(a.layoutSidebar),
-		layout.Flexed(1, a.layoutMain),
+	return layout.Stack{}.Layout(gtx,
+		layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				layout.Rigid(a.layoutSidebar),
+				layout.Flexed(1, a.layoutMain),
+			)
+		}),
+		layout.Expanded(a.layoutToast),
 	)
 }
 
@@ -282,9 +602,11 @@ func (a *App) layoutSidebar(gtx layout.Context) layout.Dimensions {
 
 		// Navigation section
 		layout.Rigid(a.sectionLabel("NAVIGATION")),
-		layout.Rigid(a.navBtn(0, "Log Workout")),
-		layout.Rigid(a.navBtn(1, "History")),
-		layout.Rigid(a.navBtn(2, "Analytics")),
+		layout.Rigid(a.navBtn(0, "Dashboard")),
+		layout.Rigid(a.navBtn(1, "Log Workout")),
+		layout.Rigid(a.navBtn(2, "History")),
+		layout.Rigid(a.navBtn(3, "Analytics")),
+		layout.Rigid(a.navBtn(4, "Settings")),
 		layout.Rigid(a.sidebarDivider),
 
 		// Workout Day section
@@ -390,36 +712,58 @@ func (a *App) sidebarClickable(gtx layout.Context, btn *widget.Clickable, label
 
 func (a *App) layoutMain(gtx layout.Context) layout.Dimensions {
 	switch a.activeTab {
+	case TabDashboard:
+		return a.layoutDashboard(gtx)
 	case TabLog:
 		return a.layoutLog(gtx)
 	case TabHistory:
 		return a.layoutHistory(gtx)
 	case TabAnalytics:
 		return a.layoutAnalytics(gtx)
+	case TabSettings:
+		return a.layoutSettings(gtx)
 	}
 	return layout.Dimensions{}
 }
 
 func (a *App) layoutLog(gtx layout.Context) layout.Dimensions {
 	return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		if a.sess.s != nil || a.sess.report != nil {
+			return a.logScroll.Layout(gtx, 2, func(gtx layout.Context, idx int) layout.Dimensions {
+				if idx == 0 {
+					return a.layoutSession(gtx)
+				}
+				return layout.Spacer{Height: unit.Dp(40)}.Layout(gtx)
+			})
+		}
 		return a.logScroll.Layout(gtx, 3, func(gtx layout.Context, idx int) layout.Dimensions {
 			switch idx {
 			case 0:
 				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						t := material.H5(a.th, a.currentExercise())
-						t.Color = ColorText
-						return t.Layout(gtx)
-					}),
-					layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						t := material.Body2(a.th, data.DayOrder[a.activeDay]+" Workout")
-						t.Color = ColorSubtext
-						return t.Layout(gtx)
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+							layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+								return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										t := material.H5(a.th, a.currentExercise())
+										t.Color = ColorText
+										return t.Layout(gtx)
+									}),
+									layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+									layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+										t := material.Body2(a.th, data.DayOrder[a.activeDay]+" Workout")
+										t.Color = ColorSubtext
+										return t.Layout(gtx)
+									}),
+								)
+							}),
+							layout.Rigid(a.smallButton(&a.sessionBtn, "START WORKOUT")),
+						)
 					}),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
 					layout.Rigid(a.layoutLastCard),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+					layout.Rigid(a.layoutRestCard),
 				)
 			case 1:
 				return a.layoutFormCard(gtx)
@@ -432,8 +776,8 @@ func (a *App) layoutLog(gtx layout.Context) layout.Dimensions {
 }
 
 func (a *App) layoutLastCard(gtx layout.Context) layout.Dimensions {
-	last, _ := a.tracker.GetLastEntry(a.currentExercise())
-	pb, _ := a.tracker.GetPersonalBest(a.currentExercise())
+	a.loadLast()
+	last, pb := a.last.val.entry, a.last.val.pb
 
 	// draw card background based on content height
 	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
@@ -448,6 +792,12 @@ func (a *App) layoutLastCard(gtx layout.Context) layout.Dimensions {
 					}),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.loadStatus(gtx, "last session", a.last.loading(), a.last.err)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if !a.last.has {
+							return layout.Dimensions{}
+						}
 						s := "No previous entries"
 						if last != nil {
 							s = fmt.Sprintf("Last: %.1f kg × %d reps × %d sets = %.0f vol  (%s)", last.Weight, last.Reps, last.Sets, last.Volume, last.Date)
@@ -458,6 +808,9 @@ func (a *App) layoutLastCard(gtx layout.Context) layout.Dimensions {
 					}),
 					layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if !a.last.has {
+							return layout.Dimensions{}
+						}
 						s := "No personal bests yet"
 						if pb != nil && pb.MaxWeight > 0 {
 							s = fmt.Sprintf("🏆  Best Weight: %.1f kg     Best Volume: %.0f", pb.MaxWeight, pb.MaxVolume)
@@ -483,64 +836,38 @@ func (a *App) layoutFormCard(gtx layout.Context) layout.Dimensions {
 			}),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
 
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				t := material.Caption(a.th, "WEIGHT (kg)")
-				t.Color = ColorSubtext
-				return t.Layout(gtx)
-			}),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return plainEditor(gtx, a.th, &a.weightEdit, "e.g. 60")
-			}),
+			layout.Rigid(a.formInput("WEIGHT (kg)", "weight", &a.weightEdit, "e.g. 60")),
+			layout.Rigid(a.layoutPlates(a.currentExercise(), &a.weightEdit)),
+			layout.Rigid(a.layoutWarmupRow(&a.logWarmups)),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
 
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				t := material.Caption(a.th, "REPS")
-				t.Color = ColorSubtext
-				return t.Layout(gtx)
-			}),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return plainEditor(gtx, a.th, &a.repsEdit, "e.g. 10")
-			}),
+			layout.Rigid(a.formInput("REPS", "reps", &a.repsEdit, "e.g. 10")),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
 
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				t := material.Caption(a.th, "SETS")
-				t.Color = ColorSubtext
-				return t.Layout(gtx)
-			}),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return plainEditor(gtx, a.th, &a.setsEdit, "e.g. 3")
-			}),
+			layout.Rigid(a.formInput("SETS", "sets", &a.setsEdit, "e.g. 3")),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
 
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				t := material.Caption(a.th, "DATE (YYYY-MM-DD)")
-				t.Color = ColorSubtext
-				return t.Layout(gtx)
-			}),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return plainEditor(gtx, a.th, &a.dateEdit, "2025-01-01")
-			}),
+			layout.Rigid(a.formInput("RPE OR RIR (optional)", "rpe", &a.rpeEdit, "e.g. 8 or 2 rir")),
+			layout.Rigid(a.layoutSuggestion(a.currentExercise(), a.last.val.e1rm, &a.repsEdit, &a.rpeEdit)),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
 
+			layout.Rigid(a.formInput("DATE (YYYY-MM-DD, yesterday, -2d)", "date", &a.dateEdit, "2025-01-01")),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				t := material.Caption(a.th, "NOTES (optional)")
-				t.Color = ColorSubtext
-				return t.Layout(gtx)
-			}),
-			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
-			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				return plainEditor(gtx, a.th, &a.notesEdit, "e.g. felt strong today")
+				return a.datePick.layout(gtx, a.th, &a.dateEdit)
 			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
+
+			layout.Rigid(a.formInput("NOTES (optional)", "notes", &a.notesEdit, "e.g. felt strong today")),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(22)}.Layout),
 
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				btn := material.Button(a.th, &a.saveBtn, "SAVE ENTRY")
-				btn.Background = ColorAccent
+				label, bg := "SAVE ENTRY", ColorAccent
+				if a.confirmKey != "" && a.confirmKey == a.logFormKey() {
+					label, bg = "SAVE ANYWAY", ColorGold
+				}
+				btn := material.Button(a.th, &a.saveBtn, label)
+				btn.Background = bg
 				btn.Color = color.NRGBA{A: 255}
 				return btn.Layout(gtx)
 			}),
@@ -562,6 +889,39 @@ func (a *App) layoutFormCard(gtx layout.Context) layout.Dimensions {
 	})
 }
 
+// formInput lays out a labelled log form editor. A rejected field gets a red
+// border and its reason underneath.
+func (a *App) formInput(label, name string, ed *widget.Editor, hint string) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		reason, invalid := a.formErrs[name]
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.Caption(a.th, label)
+				t.Color = ColorSubtext
+				if invalid {
+					t.Color = ColorRed
+				}
+				return t.Layout(gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if invalid {
+					return borderedEditor(gtx, a.th, ed, hint, ColorRed)
+				}
+				return plainEditor(gtx, a.th, ed, hint)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if !invalid {
+					return layout.Dimensions{}
+				}
+				t := material.Caption(a.th, reason)
+				t.Color = ColorRed
+				return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, t.Layout)
+			}),
+		)
+	}
+}
+
 func (a *App) fieldOf(label string, ed *widget.Editor) layout.Widget {
 	return func(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
@@ -580,6 +940,7 @@ func (a *App) fieldOf(label string, ed *widget.Editor) layout.Widget {
 
 func (a *App) layoutHistory(gtx layout.Context) layout.Dimensions {
 	a.loadHistory()
+	entries, pb := a.hist.val.entries, a.hist.val.pb
 	return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
 		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -589,19 +950,22 @@ func (a *App) layoutHistory(gtx layout.Context) layout.Dimensions {
 			}),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(16)}.Layout),
 			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-				if a.histPB == nil || a.histPB.MaxWeight == 0 {
+				return a.loadStatus(gtx, "history", a.hist.loading(), a.hist.err)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if pb == nil || pb.MaxWeight == 0 {
 					return layout.Dimensions{}
 				}
 				return cardLayout(gtx, func(gtx layout.Context) layout.Dimensions {
 					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-							t := material.Body1(a.th, fmt.Sprintf("🏆  Best Weight: %.1f kg", a.histPB.MaxWeight))
+							t := material.Body1(a.th, fmt.Sprintf("🏆  Best Weight: %.1f kg", pb.MaxWeight))
 							t.Color = ColorGold
 							return t.Layout(gtx)
 						}),
 						layout.Rigid(layout.Spacer{Width: unit.Dp(40)}.Layout),
 						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-							t := material.Body1(a.th, fmt.Sprintf("🔥  Best Volume: %.0f", a.histPB.MaxVolume))
+							t := material.Body1(a.th, fmt.Sprintf("🔥  Best Volume: %.0f", pb.MaxVolume))
 							t.Color = ColorAccent2
 							return t.Layout(gtx)
 						}),
@@ -610,46 +974,35 @@ func (a *App) layoutHistory(gtx layout.Context) layout.Dimensions {
 			}),
 			layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
 			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-				if len(a.histEntries) == 0 {
+				if !a.hist.has {
+					return layout.Dimensions{}
+				}
+				if len(entries) == 0 {
 					t := material.Body1(a.th, "No entries yet. Log your first workout!")
 					t.Color = ColorSubtext
 					return t.Layout(gtx)
 				}
-				return a.histList.Layout(gtx, len(a.histEntries), func(gtx layout.Context, idx int) layout.Dimensions {
-					e := a.histEntries[idx]
-					isPB := a.histPB != nil && e.Weight == a.histPB.MaxWeight
+				return a.histList.Layout(gtx, len(entries), func(gtx layout.Context, idx int) layout.Dimensions {
+					e := entries[idx]
+					isPB := pb != nil && e.Weight == pb.MaxWeight && !e.Warmup
+					flag := a.hist.val.flags[e.ID]
+					// the partners' sets go under the day's last row
+					var grouped []string
+					if idx+1 == len(entries) || entries[idx+1].Date != e.Date {
+						grouped = groupedLines(a.hist.val.grouped[e.Date])
+					}
 					return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-						return cardLayout(gtx, func(gtx layout.Context) layout.Dimensions {
-							return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
-								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-									t := material.Body2(a.th, e.Date)
-									t.Color = ColorSubtext
-									return layout.Inset{Right: unit.Dp(16)}.Layout(gtx, t.Layout)
-								}),
-								layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-									s := fmt.Sprintf("%.1f kg × %d reps × %d sets", e.Weight, e.Reps, e.Sets)
-									t := material.Body1(a.th, s)
-									if isPB {
-										t.Color = ColorGold
-									} else {
-										t.Color = ColorText
-									}
-									return t.Layout(gtx)
-								}),
-								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-									t := material.Body2(a.th, fmt.Sprintf("Vol: %.0f", e.Volume))
-									t.Color = ColorAccent2
-									return t.Layout(gtx)
-								}),
-								layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-									if isPB {
-										t := material.Body2(a.th, "  🏆")
-										return t.Layout(gtx)
-									}
-									return layout.Dimensions{}
-								}),
-							)
-						})
+						rows := []layout.FlexChild{layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							return a.layoutHistoryRow(gtx, e, isPB, flag)
+						})}
+						for _, line := range grouped {
+							rows = append(rows, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								t := material.Body2(a.th, line)
+								t.Color = ColorSubtext
+								return layout.Inset{Top: unit.Dp(4), Left: unit.Dp(16)}.Layout(gtx, t.Layout)
+							}))
+						}
+						return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
 					})
 				})
 			}),
@@ -657,18 +1010,96 @@ func (a *App) layoutHistory(gtx layout.Context) layout.Dimensions {
 	})
 }
 
+// groupedLines describes a day's sets of superset or circuit partners, one
+// line per exercise.
+func groupedLines(entries []*data.Entry) []string {
+	var names []string
+	byEx := map[string][]*data.Entry{}
+	for _, e := range entries {
+		if byEx[e.Exercise] == nil {
+			names = append(names, e.Exercise)
+		}
+		byEx[e.Exercise] = append(byEx[e.Exercise], e)
+	}
+	lines := make([]string, len(names))
+	for i, n := range names {
+		lines[i] = "↳ with " + n + ": " + formatSets(byEx[n])
+	}
+	return lines
+}
+
+func (a *App) layoutHistoryRow(gtx layout.Context, e *data.Entry, isPB bool, flag *logic.Outlier) layout.Dimensions {
+	return cardLayout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.Body2(a.th, e.Date.String())
+				t.Color = ColorSubtext
+				return layout.Inset{Right: unit.Dp(16)}.Layout(gtx, t.Layout)
+			}),
+			layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				s := fmt.Sprintf("%.1f kg × %d reps × %d sets", e.Weight, e.Reps, e.Sets)
+				if e.RPE != 0 {
+					s += " " + logic.FormatEffort(e.RPE)
+				}
+				t := material.Body1(a.th, s)
+				switch {
+				case isPB:
+					t.Color = ColorGold
+				case e.Warmup:
+					t.Color = ColorSubtext
+				default:
+					t.Color = ColorText
+				}
+				return t.Layout(gtx)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if e.Warmup {
+					t := material.Body2(a.th, "warm-up")
+					t.Color = ColorSubtext
+					return t.Layout(gtx)
+				}
+				t := material.Body2(a.th, fmt.Sprintf("Vol: %.0f", e.Volume))
+				t.Color = ColorAccent2
+				return t.Layout(gtx)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if isPB {
+					t := material.Body2(a.th, "  🏆")
+					return t.Layout(gtx)
+				}
+				return layout.Dimensions{}
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if flag == nil {
+					return layout.Dimensions{}
+				}
+				// likely a typo: say by how much it jumps
+				t := material.Body2(a.th, fmt.Sprintf("  ⚠ %s +%.0f%%", flag.Measure, flag.Change()*100))
+				t.Color = ColorRed
+				return t.Layout(gtx)
+			}),
+		)
+	})
+}
+
 func (a *App) layoutAnalytics(gtx layout.Context) layout.Dimensions {
 	a.loadCharts()
+	weight, volume, e1rm := a.charts.val.weight, a.charts.val.volume, a.charts.val.e1rm
 	return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
-		return a.chartScroll.Layout(gtx, 5, func(gtx layout.Context, idx int) layout.Dimensions {
+		return a.chartScroll.Layout(gtx, 6, func(gtx layout.Context, idx int) layout.Dimensions {
 			switch idx {
 			case 0:
 				t := material.H5(a.th, "Analytics: "+a.currentExercise())
 				t.Color = ColorText
 				return t.Layout(gtx)
 			case 1:
-				return layout.Spacer{Height: unit.Dp(20)}.Layout(gtx)
+				return layout.Inset{Top: unit.Dp(20)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return a.loadStatus(gtx, "charts", a.charts.loading(), a.charts.err)
+				})
 			case 2:
+				if !a.charts.has {
+					return layout.Dimensions{}
+				}
 				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 						t := material.Body1(a.th, "Weight Over Time")
@@ -676,15 +1107,18 @@ func (a *App) layoutAnalytics(gtx layout.Context) layout.Dimensions {
 						return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, t.Layout)
 					}),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						if len(a.chartWeight) == 0 {
+						if len(weight) == 0 {
 							t := material.Body2(a.th, "No data yet — log some entries first.")
 							t.Color = ColorSubtext
 							return t.Layout(gtx)
 						}
-						return drawLineChart(gtx, a.chartWeight, ColorChartLine, "Weight")
+						return drawLineChart(gtx, weight, ColorChartLine, "Weight")
 					}),
 				)
 			case 3:
+				if !a.charts.has {
+					return layout.Dimensions{}
+				}
 				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 					layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
@@ -693,15 +1127,38 @@ func (a *App) layoutAnalytics(gtx layout.Context) layout.Dimensions {
 						return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, t.Layout)
 					}),
 					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-						if len(a.chartVolume) == 0 {
+						if len(volume) == 0 {
 							t := material.Body2(a.th, "No data yet — log some entries first.")
 							t.Color = ColorSubtext
 							return t.Layout(gtx)
 						}
-						return drawLineChart(gtx, a.chartVolume, ColorChartVol, "Volume")
+						return drawLineChart(gtx, volume, ColorChartVol, "Volume")
 					}),
 				)
 			case 4:
+				if !a.charts.has {
+					return layout.Dimensions{}
+				}
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(layout.Spacer{Height: unit.Dp(24)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						t := material.Body1(a.th, "Estimated 1RM (RPE-adjusted)")
+						t.Color = ColorGold
+						return layout.Inset{Bottom: unit.Dp(8)}.Layout(gtx, t.Layout)
+					}),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						if len(e1rm) == 0 {
+							t := material.Body2(a.th, "No data yet — log some entries first.")
+							t.Color = ColorSubtext
+							return t.Layout(gtx)
+						}
+						return drawLineChart(gtx, e1rm, ColorGold, "e1RM")
+					}),
+				)
+			case 5:
+				if !a.charts.has {
+					return layout.Dimensions{}
+				}
 				return a.layoutStatsTable(gtx)
 			}
 			return layout.Dimensions{}
@@ -719,20 +1176,20 @@ func (a *App) layoutStatsTable(gtx layout.Context) layout.Dimensions {
 					return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, t.Layout)
 				}),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-					if len(a.chartWeight) == 0 {
+					if len(a.charts.val.weight) == 0 {
 						t := material.Body2(a.th, "No data available")
 						t.Color = ColorSubtext
 						return t.Layout(gtx)
 					}
-					first := a.chartWeight[0]
-					last := a.chartWeight[len(a.chartWeight)-1]
+					first := a.charts.val.weight[0]
+					last := a.charts.val.weight[len(a.charts.val.weight)-1]
 					diff := last.Value - first.Value
 					diffStr := fmt.Sprintf("+%.1f", diff)
 					if diff < 0 {
 						diffStr = fmt.Sprintf("%.1f", diff)
 					}
 					rows := []struct{ label, value string }{
-						{"Total Sessions", fmt.Sprintf("%d", len(a.chartWeight))},
+						{"Total Sessions", fmt.Sprintf("%d", len(a.charts.val.weight))},
 						{"Starting Weight", fmt.Sprintf("%.1f kg", first.Value)},
 						{"Current Weight", fmt.Sprintf("%.1f kg", last.Value)},
 						{"Weight Change", diffStr + " kg"},
@@ -765,8 +1222,8 @@ func (a *App) layoutStatsTable(gtx layout.Context) layout.Dimensions {
 	})
 }
 
-func Run(repo *data.Repository, tracker *logic.Tracker, anal *logic.Analytics) {
-	a := NewApp(repo, tracker, anal)
+func Run(ctx context.Context, repo data.Store, tracker *logic.Tracker, anal *logic.Analytics, backups *data.Backups) {
+	a := NewApp(ctx, repo, tracker, anal, backups)
 	w := new(app.Window)
 	w.Option(
 		app.Title("ProgressTracker"),
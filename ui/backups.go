@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+func (a *App) refreshBackups() {
+	list, err := a.backups.List()
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.backupList = list
+	keep, err := a.backups.Keep(a.ctx)
+	if err != nil {
+		a.showError(err)
+	}
+	a.backupKeep = keep
+	a.backupSelBtns = make([]widget.Clickable, len(list))
+	a.backupSel = -1
+	a.backupPreview = nil
+	a.snapRestoreArmed = false
+}
+
+func (a *App) updateBackups(gtx layout.Context) {
+	if a.keepMinusBtn.Clicked(gtx) && a.backupKeep > 1 {
+		a.setKeep(a.backupKeep - 1)
+	}
+	if a.keepPlusBtn.Clicked(gtx) {
+		a.setKeep(a.backupKeep + 1)
+	}
+	if a.snapNowBtn.Clicked(gtx) {
+		info, err := a.backups.Take(a.ctx)
+		if err != nil {
+			a.setSettingsStatus(false, "Error: %v", err)
+		} else {
+			a.refreshBackups()
+			a.setSettingsStatus(true, "Backup saved as %s", info.Name)
+		}
+	}
+	for i := range a.backupSelBtns {
+		if a.backupSelBtns[i].Clicked(gtx) {
+			a.selectBackup(i)
+		}
+	}
+	if a.snapRestoreBtn.Clicked(gtx) && a.backupSel >= 0 {
+		if !a.snapRestoreArmed {
+			a.snapRestoreArmed = true
+			a.setSettingsStatus(false, "Restoring replaces all current data. Click again to confirm.")
+		} else {
+			a.restoreBackup()
+		}
+	}
+}
+
+func (a *App) setKeep(n int) {
+	if err := a.backups.SetKeep(a.ctx, n); err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.refreshBackups()
+}
+
+func (a *App) selectBackup(i int) {
+	a.backupSel = i
+	a.snapRestoreArmed = false
+	p, err := a.backups.Preview(a.ctx, a.backupList[i].Path)
+	if err != nil {
+		a.backupPreview = nil
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.backupPreview = p
+}
+
+func (a *App) restoreBackup() {
+	name := a.backupList[a.backupSel].Name
+	if err := a.backups.Restore(a.ctx, a.backupList[a.backupSel].Path); err != nil {
+		a.snapRestoreArmed = false
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.refreshBackups()
+	a.setSettingsStatus(true, "Restored %s (the previous state was backed up first)", name)
+	a.reloadWeek()
+	a.rebuildExBtns()
+	a.invalidateData()
+}
+
+func (a *App) layoutSnapshotsCard(gtx layout.Context) layout.Dimensions {
+	keep := a.backupKeep
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Automatic Backups", ColorAccent)),
+			layout.Rigid(a.cardLine(fmt.Sprintf("A snapshot is taken on startup and daily in %s", a.backups.Dir()), ColorSubtext)),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						t := material.Body2(a.th, fmt.Sprintf("Keep %d backups", keep))
+						t.Color = ColorText
+						return layout.Inset{Right: unit.Dp(12)}.Layout(gtx, t.Layout)
+					}),
+					layout.Rigid(a.smallButton(&a.keepMinusBtn, "−")),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+					layout.Rigid(a.smallButton(&a.keepPlusBtn, "+")),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(20)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.accentButton(gtx, &a.snapNowBtn, "BACK UP NOW")
+					}),
+				)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+		}
+		if len(a.backupList) == 0 {
+			children = append(children, layout.Rigid(a.cardLine("No backups yet.", ColorSubtext)))
+		}
+		for i, b := range a.backupList {
+			i, b := i, b
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				label := fmt.Sprintf("%s   %s   %.1f KB", b.Time.Format("2006-01-02 15:04"), b.Name, float64(b.Size)/1024)
+				return a.selectableRow(gtx, &a.backupSelBtns[i], label, a.backupSel == i)
+			}))
+		}
+		if p := a.backupPreview; p != nil {
+			summary := fmt.Sprintf("%d entries across %d exercises", p.Entries, p.Exercises)
+			if p.Entries > 0 {
+				summary += fmt.Sprintf(", %s to %s", p.FirstDate, p.LastDate)
+			}
+			restore := "RESTORE THIS BACKUP"
+			if a.snapRestoreArmed {
+				restore = "CONFIRM RESTORE"
+			}
+			children = append(children,
+				layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+				layout.Rigid(a.cardLine(summary, ColorAccent2)),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					btn := material.Button(a.th, &a.snapRestoreBtn, restore)
+					btn.Background = ColorAccent2
+					if a.snapRestoreArmed {
+						btn.Background = ColorRed
+					}
+					return btn.Layout(gtx)
+				}),
+			)
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) smallButton(btn *widget.Clickable, label string) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		b := material.Button(a.th, btn, label)
+		b.Background = ColorBorder
+		b.Color = ColorText
+		b.Inset = layout.UniformInset(unit.Dp(8))
+		return b.Layout(gtx)
+	}
+}
+
+// selectableRow is a full-width clickable text row, highlighted when active.
+func (a *App) selectableRow(gtx layout.Context, btn *widget.Clickable, label string, active bool) layout.Dimensions {
+	return btn.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Stack{}.Layout(gtx,
+			layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+				if active {
+					r := clip.Rect{Max: image.Pt(gtx.Constraints.Min.X, gtx.Constraints.Min.Y)}.Push(gtx.Ops)
+					paint.ColorOp{Color: ColorActive}.Add(gtx.Ops)
+					paint.PaintOp{}.Add(gtx.Ops)
+					r.Pop()
+				}
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			}),
+			layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+				return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(6), Left: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					t := material.Body2(a.th, label)
+					t.Color = ColorText
+					if active {
+						t.Color = ColorAccent
+					}
+					return t.Layout(gtx)
+				})
+			}),
+		)
+	})
+}
@@ -126,7 +126,7 @@ func drawLineChart(gtx layout.Context, points []logic.ChartPoint, lineColor colo
 			if len(date) >= 10 {
 				date = date[5:10]
 			}
-			drawLabel(gtx, date, x-15, padT+chartH+8, ColorSubtext)
+			drawLabel(gtx, date.String(), x-15, padT+chartH+8, ColorSubtext)
 		}
 	}
 
@@ -87,6 +87,12 @@ func drawCard(gtx layout.Context, content layout.Widget) layout.Dimensions {
 
 // plainEditor renders a simple single-line text input with visible background.
 func plainEditor(gtx layout.Context, th *material.Theme, ed *widget.Editor, hint string) layout.Dimensions {
+	return borderedEditor(gtx, th, ed, hint, ColorBorder)
+}
+
+// borderedEditor is plainEditor with the border drawn in border, used to
+// flag rejected input.
+func borderedEditor(gtx layout.Context, th *material.Theme, ed *widget.Editor, hint string, border color.NRGBA) layout.Dimensions {
 	// fixed height input box
 	gtx.Constraints.Min.Y = gtx.Dp(unit.Dp(36))
 	gtx.Constraints.Max.Y = gtx.Dp(unit.Dp(36))
@@ -102,7 +108,7 @@ func plainEditor(gtx layout.Context, th *material.Theme, ed *widget.Editor, hint
 		Path:  clip.UniformRRect(image.Rectangle{Max: image.Pt(gtx.Constraints.Max.X, gtx.Constraints.Min.Y)}, 4).Path(gtx.Ops),
 		Width: 1,
 	}.Op().Push(gtx.Ops)
-	paint.ColorOp{Color: ColorBorder}.Add(gtx.Ops)
+	paint.ColorOp{Color: border}.Add(gtx.Ops)
 	paint.PaintOp{}.Add(gtx.Ops)
 	borderStack.Pop()
 
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+func (a *App) layoutDashboard(gtx layout.Context) layout.Dimensions {
+	a.loadDashboard()
+	d := a.dash.val
+	if d == nil {
+		return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return a.loadStatus(gtx, "dashboard", a.dash.loading(), a.dash.err)
+		})
+	}
+
+	cards := []layout.Widget{
+		a.dashHeader,
+		a.dashTodayCard,
+		a.dashWeekCard,
+		a.dashLastSessionCard,
+		a.dashGoalsCard,
+		a.dashPRCard,
+		a.dashAttentionCard,
+		a.dashFatigueCard,
+	}
+	return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return a.dashScroll.Layout(gtx, len(cards), func(gtx layout.Context, idx int) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, cards[idx])
+		})
+	})
+}
+
+func (a *App) dashHeader(gtx layout.Context) layout.Dimensions {
+	d := a.dash.val
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			t := material.H5(a.th, "Dashboard")
+			t.Color = ColorText
+			return t.Layout(gtx)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			day := d.Day
+			if day == "" {
+				day = "Rest day"
+			}
+			t := material.Body2(a.th, fmt.Sprintf("%s  ·  %s  ·  Week %d", d.Today, day, d.Week))
+			t.Color = ColorSubtext
+			return t.Layout(gtx)
+		}),
+	)
+}
+
+func (a *App) dashTodayCard(gtx layout.Context) layout.Dimensions {
+	d := a.dash.val
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Today's Workout", ColorAccent)),
+		}
+		if d.Day == "" {
+			children = append(children, layout.Rigid(a.cardLine("Nothing scheduled today. Enjoy the rest!", ColorSubtext)))
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		}
+		for i, ex := range d.Exercises {
+			children = append(children, layout.Rigid(a.cardLine(fmt.Sprintf("%d.  %s", i+1, ex), ColorText)))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.dashSessionBtn, "START WORKOUT")
+						btn.Background = ColorAccent
+						btn.Color = color.NRGBA{A: 255}
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.dashStartBtn, "START LOGGING")
+						btn.Background = ColorBorder
+						btn.Color = ColorText
+						return btn.Layout(gtx)
+					}),
+				)
+			}),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) dashWeekCard(gtx layout.Context) layout.Dimensions {
+	d := a.dash.val
+	change := "no volume last week"
+	if d.LastWeekVolume > 0 {
+		pct := (d.WeekVolume - d.LastWeekVolume) / d.LastWeekVolume * 100
+		change = fmt.Sprintf("%+.0f%% vs last week (%.0f)", pct, d.LastWeekVolume)
+	}
+	streak := fmt.Sprintf("🔥  Streak: %d training day", d.Streak)
+	if d.Streak != 1 {
+		streak += "s"
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardTitle("This Week", ColorAccent2)),
+			layout.Rigid(a.cardLine(fmt.Sprintf("Volume: %.0f  ·  %s", d.WeekVolume, change), ColorText)),
+			layout.Rigid(a.cardLine(streak, ColorGold)),
+		)
+	})
+}
+
+func (a *App) dashLastSessionCard(gtx layout.Context) layout.Dimensions {
+	s := a.dash.val.LastSession
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Last Session", ColorAccent)),
+		}
+		if s == nil {
+			children = append(children, layout.Rigid(a.cardLine("No workouts logged yet.", ColorSubtext)))
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		}
+		children = append(children, layout.Rigid(a.cardLine(
+			fmt.Sprintf("%s  ·  %d exercises  ·  %d sets  ·  %.0f vol", s.Date, s.Exercises, s.Sets, s.Volume), ColorSubtext)))
+		for _, e := range s.Entries {
+			children = append(children, layout.Rigid(a.cardLine(
+				fmt.Sprintf("%s: %.1f kg × %d × %d", e.Exercise, e.Weight, e.Reps, e.Sets), ColorText)))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) dashPRCard(gtx layout.Context) layout.Dimensions {
+	prs := a.dash.val.RecentPRs
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Recent PRs", ColorGold)),
+		}
+		if len(prs) == 0 {
+			children = append(children, layout.Rigid(a.cardLine("No new personal bests in the last 30 days.", ColorSubtext)))
+		}
+		for _, pr := range prs {
+			children = append(children, layout.Rigid(a.cardLine(
+				fmt.Sprintf("🏆  %s: %.1f kg (was %.1f)  ·  %s", pr.Exercise, pr.Weight, pr.Previous, pr.Date), ColorGold)))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) dashAttentionCard(gtx layout.Context) layout.Dimensions {
+	items := a.dash.val.NeedsAttention
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Needs Attention", ColorRed)),
+		}
+		if len(items) == 0 {
+			children = append(children, layout.Rigid(a.cardLine("All lifts are moving. Keep it up!", ColorSubtext)))
+		}
+		for _, it := range items {
+			children = append(children, layout.Rigid(a.cardLine(
+				fmt.Sprintf("%s — %s (last: %s)", it.Exercise, it.Reason, it.LastDate), ColorText)))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) cardTitle(text string, col color.NRGBA) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		t := material.Body1(a.th, text)
+		t.Color = col
+		return layout.Inset{Bottom: unit.Dp(10)}.Layout(gtx, t.Layout)
+	}
+}
+
+func (a *App) cardLine(text string, col color.NRGBA) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		t := material.Body2(a.th, text)
+		t.Color = col
+		return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, t.Layout)
+	}
+}
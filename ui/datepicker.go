@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// datePicker is a month calendar that fills in a date editor, with buttons
+// for the most common recent days. Future days cannot be picked.
+type datePicker struct {
+	open      bool
+	month     data.Date // first day of the month shown
+	toggleBtn widget.Clickable
+	prevBtn   widget.Clickable
+	nextBtn   widget.Clickable
+	todayBtn  widget.Clickable
+	yestBtn   widget.Clickable
+	twoAgoBtn widget.Clickable
+	dayBtns   [31]widget.Clickable
+}
+
+// selected is the day ed currently names, or zero.
+func (p *datePicker) selected(ed *widget.Editor) data.Date {
+	d, err := data.ResolveDate(ed.Text(), data.Today())
+	if err != nil {
+		return ""
+	}
+	return d
+}
+
+func monthOf(d data.Date) data.Date {
+	return d[:8] + "01"
+}
+
+// update handles clicks and reports whether it changed ed.
+func (p *datePicker) update(gtx layout.Context, ed *widget.Editor) bool {
+	today := data.Today()
+	if p.month.IsZero() {
+		p.month = monthOf(today)
+	}
+	if p.toggleBtn.Clicked(gtx) {
+		p.open = !p.open
+		if d := p.selected(ed); p.open && !d.IsZero() {
+			p.month = monthOf(d)
+		}
+	}
+	if p.prevBtn.Clicked(gtx) {
+		p.month = monthOf(p.month.AddDays(-1))
+	}
+	if p.nextBtn.Clicked(gtx) && p.month < monthOf(today) {
+		p.month = monthOf(p.month.AddDays(31))
+	}
+
+	pick := data.Date("")
+	switch {
+	case p.todayBtn.Clicked(gtx):
+		pick = today
+	case p.yestBtn.Clicked(gtx):
+		pick = today.AddDays(-1)
+	case p.twoAgoBtn.Clicked(gtx):
+		pick = today.AddDays(-2)
+	}
+	for i := range p.dayBtns {
+		if p.dayBtns[i].Clicked(gtx) {
+			if d := p.month.AddDays(i); d <= today {
+				pick = d
+				p.open = false
+			}
+		}
+	}
+	if pick.IsZero() {
+		return false
+	}
+	ed.SetText(pick.String())
+	p.month = monthOf(pick)
+	return true
+}
+
+func (p *datePicker) layout(gtx layout.Context, th *material.Theme, ed *widget.Editor) layout.Dimensions {
+	chip := func(btn *widget.Clickable, label string) layout.FlexChild {
+		return layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Right: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				b := material.Button(th, btn, label)
+				b.Background = ColorBorder
+				b.Color = ColorText
+				b.TextSize = unit.Sp(12)
+				b.Inset = layout.Inset{Top: unit.Dp(5), Bottom: unit.Dp(5), Left: unit.Dp(10), Right: unit.Dp(10)}
+				return b.Layout(gtx)
+			})
+		})
+	}
+	calLabel := "CALENDAR ▾"
+	if p.open {
+		calLabel = "CALENDAR ▴"
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+				chip(&p.todayBtn, "Today"),
+				chip(&p.yestBtn, "Yesterday"),
+				chip(&p.twoAgoBtn, "-2d"),
+				chip(&p.toggleBtn, calLabel),
+			)
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			if !p.open {
+				return layout.Dimensions{}
+			}
+			return layout.Inset{Top: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return p.layoutMonth(gtx, th, p.selected(ed))
+			})
+		}),
+	)
+}
+
+func (p *datePicker) layoutMonth(gtx layout.Context, th *material.Theme, selected data.Date) layout.Dimensions {
+	today := data.Today()
+	first := p.month.Time()
+	days := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	offset := (int(first.Weekday()) + 6) % 7 // Monday first
+	cell := gtx.Dp(unit.Dp(34))
+
+	// label draws s centred in a calendar cell, on bg unless it is transparent
+	label := func(s string, col, bg color.NRGBA) layout.Widget {
+		return func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints = layout.Exact(image.Pt(cell, cell))
+			if bg.A > 0 {
+				r := clip.UniformRRect(image.Rectangle{Max: image.Pt(cell, cell)}, cell/2).Push(gtx.Ops)
+				paint.ColorOp{Color: bg}.Add(gtx.Ops)
+				paint.PaintOp{}.Add(gtx.Ops)
+				r.Pop()
+			}
+			return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				l := material.Body2(th, s)
+				l.Color = col
+				l.Alignment = text.Middle
+				return l.Layout(gtx)
+			})
+		}
+	}
+	none := color.NRGBA{}
+	canNext := p.month < monthOf(today)
+	nextCol := ColorAccent
+	if !canNext {
+		nextCol = ColorBorder
+	}
+
+	rows := []layout.FlexChild{
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return p.prevBtn.Layout(gtx, label("‹", ColorAccent, none))
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					gtx.Constraints = layout.Exact(image.Pt(cell*5, cell))
+					return layout.Center.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						l := material.Body1(th, first.Format("January 2006"))
+						l.Color = ColorText
+						return l.Layout(gtx)
+					})
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return p.nextBtn.Layout(gtx, label("›", nextCol, none))
+				}),
+			)
+		}),
+	}
+	var names []layout.FlexChild
+	for _, n := range strings.Fields("Mo Tu We Th Fr Sa Su") {
+		names = append(names, layout.Rigid(label(n, ColorSubtext, none)))
+	}
+	rows = append(rows, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, names...)
+	}))
+
+	for start := -offset; start < days; start += 7 {
+		var week []layout.FlexChild
+		for i := start; i < start+7; i++ {
+			if i < 0 || i >= days {
+				week = append(week, layout.Rigid(label("", none, none)))
+				continue
+			}
+			d := p.month.AddDays(i)
+			col, bg := ColorText, none
+			switch {
+			case d == selected:
+				col, bg = color.NRGBA{A: 255}, ColorAccent
+			case d == today:
+				col, bg = ColorAccent, ColorActive
+			case d > today:
+				col = ColorBorder
+			}
+			w := label(strconv.Itoa(i+1), col, bg)
+			btn := &p.dayBtns[i]
+			week = append(week, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return btn.Layout(gtx, w)
+			}))
+		}
+		rows = append(rows, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, week...)
+		}))
+	}
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"progresstracker/data"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+)
+
+// loadEquipment reads the equipment profile into the settings card. On
+// failure the defaults are used.
+func (a *App) loadEquipment() {
+	eq, err := a.tracker.Equipment(a.ctx)
+	if err != nil {
+		a.showError(err)
+	}
+	a.equip = eq
+	a.warmScheme.Equipment = &a.equip
+	a.platesEdit.SetText(eq.PlatesString())
+	a.barsEdit.SetText(eq.BarsString())
+	a.dumbbellStepEdit.SetText(strconv.FormatFloat(eq.DumbbellStep, 'f', -1, 64))
+	a.machineStepEdit.SetText(strconv.FormatFloat(eq.MachineStep, 'f', -1, 64))
+}
+
+func (a *App) saveEquipment() {
+	eq, err := a.tracker.SetEquipment(a.ctx, a.platesEdit.Text(), a.barsEdit.Text(), a.dumbbellStepEdit.Text(), a.machineStepEdit.Text())
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.setSettingsStatus(false, "Equipment not saved: %v", err)
+	case err != nil:
+		a.showError(err)
+	default:
+		a.equip = eq
+		a.warmScheme.Equipment = &a.equip
+		a.platesEdit.SetText(eq.PlatesString())
+		a.barsEdit.SetText(eq.BarsString())
+		a.setSettingsStatus(true, "Equipment saved")
+	}
+}
+
+// plateText describes how to load exercise at the weight typed into ed:
+// the plates per side for a bar, or the nearest loadable weight when the
+// equipment cannot make it. It is empty when there is nothing to say.
+func (a *App) plateText(exercise string, ed *widget.Editor) string {
+	w := workingWeight(ed)
+	if w <= 0 {
+		return ""
+	}
+	nearest := a.equip.Round(exercise, w)
+	bar, onBar := a.equip.Bar(exercise)
+	switch {
+	case onBar && w < bar:
+		return fmt.Sprintf("Lighter than the %g kg bar", bar)
+	case onBar && w == bar:
+		return fmt.Sprintf("Just the %g kg bar", bar)
+	case onBar:
+		side, rest := a.equip.LoadPlates(w, bar)
+		parts := make([]string, len(side))
+		for i, p := range side {
+			parts[i] = strconv.FormatFloat(p, 'f', -1, 64)
+		}
+		s := fmt.Sprintf("Per side: %s on the %g kg bar", strings.Join(parts, " + "), bar)
+		if rest > 0 {
+			s += fmt.Sprintf(", %g kg short; nearest loadable is %g kg", rest, nearest)
+		}
+		return s
+	case nearest != w:
+		return fmt.Sprintf("Not loadable; nearest is %g kg", nearest)
+	}
+	return ""
+}
+
+// layoutPlates shows plateText under a weight field.
+func (a *App) layoutPlates(exercise string, ed *widget.Editor) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		s := a.plateText(exercise, ed)
+		if s == "" {
+			return layout.Dimensions{}
+		}
+		return a.cardLine(s, ColorAccent2)(gtx)
+	}
+}
+
+func (a *App) layoutEquipmentCard(gtx layout.Context) layout.Dimensions {
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardTitle("Equipment", ColorAccent)),
+			layout.Rigid(a.cardLine("Used for the plate calculator, and to keep suggested weights loadable.", ColorSubtext)),
+			layout.Rigid(a.formField("PLATES (kg, ×n for only n pairs)", &a.platesEdit, "25, 20, 15, 10, 5, 2.5, 1.25")),
+			layout.Rigid(a.formField("BARS (kg)", &a.barsEdit, "barbell=20, ez-bar=10, smith=15, sled=0")),
+			layout.Rigid(a.formField("DUMBBELL STEP (kg)", &a.dumbbellStepEdit, "2.5")),
+			layout.Rigid(a.formField("MACHINE AND CABLE STEP (kg)", &a.machineStepEdit, "5")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return a.accentButton(gtx, &a.equipSaveBtn, "SAVE")
+			}),
+		)
+	})
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// updateDeload marks the current or next week as a deload, or ends the
+// current one, from the fatigue card.
+func (a *App) updateDeload(gtx layout.Context) {
+	d := a.dash.val
+	if d == nil || d.Fatigue == nil {
+		return
+	}
+	f := d.Fatigue
+	mark := func(days int, on bool) {
+		if err := a.tracker.SetDeload(a.ctx, f.Week.AddDays(days), on); err != nil {
+			a.showError(err)
+			return
+		}
+		a.invalidateData()
+	}
+	if a.dashDeloadBtn.Clicked(gtx) {
+		mark(0, !f.Deloading)
+	}
+	if a.dashNextDeloadBtn.Clicked(gtx) {
+		mark(7, true)
+	}
+}
+
+func (a *App) dashFatigueCard(gtx layout.Context) layout.Dimensions {
+	f := a.dash.val.Fatigue
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Fatigue", ColorAccent2)),
+		}
+		if f.Deloading {
+			children = append(children,
+				layout.Rigid(a.cardLine("This week is a deload: program lifts go light, their cycles wait, and stalls are not counted.", ColorGold)),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(a.smallButton(&a.dashDeloadBtn, "END DELOAD")),
+			)
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+		}
+
+		load := "Acute:chronic workload needs two weeks of history"
+		if f.ACWR > 0 {
+			load = fmt.Sprintf("Acute:chronic workload %.2f  ·  %.0f kg in 7 days vs %.0f a week", f.ACWR, f.AcuteVolume, f.ChronicVolume)
+		}
+		children = append(children, layout.Rigid(a.cardLine(load, ColorText)))
+		if f.RPE > 0 {
+			rpe := fmt.Sprintf("Average RPE %g over two weeks", f.RPE)
+			if f.PrevRPE > 0 {
+				rpe += fmt.Sprintf(" (was %g)", f.PrevRPE)
+			}
+			children = append(children, layout.Rigid(a.cardLine(rpe, ColorText)))
+		}
+		for _, d := range f.Drops {
+			children = append(children, layout.Rigid(a.cardLine(
+				fmt.Sprintf("%s: e1RM %.1f kg, %.0f%% under its recent best", d.Exercise, d.E1RM, d.Drop()*100), ColorText)))
+		}
+		since := "No deload marked yet"
+		if f.WeeksSinceDeload >= 0 {
+			since = fmt.Sprintf("Last deload %s ago", plural(f.WeeksSinceDeload, "week"))
+		}
+		children = append(children, layout.Rigid(a.cardLine(since, ColorSubtext)))
+
+		switch {
+		case f.Deload:
+			children = append(children, layout.Rigid(a.cardLine("Deload recommended: "+strings.Join(f.Signals, "; "), ColorRed)))
+		case len(f.Signals) > 0:
+			children = append(children, layout.Rigid(a.cardLine("Keep an eye on: "+strings.Join(f.Signals, "; "), ColorGold)))
+		default:
+			children = append(children, layout.Rigid(a.cardLine("No signs of fatigue.", ColorSubtext)))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(a.smallButton(&a.dashDeloadBtn, "DELOAD THIS WEEK")),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(a.smallButton(&a.dashNextDeloadBtn, "DELOAD NEXT WEEK")),
+				)
+			}),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// plural is n and word, with an s unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
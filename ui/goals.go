@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+)
+
+func (a *App) loadGoals() {
+	today := data.Today()
+	load(a.loader, &a.goals, today.String(), func() ([]*logic.GoalProgress, error) {
+		return a.anal.Goals(a.ctx, today.Time())
+	})
+}
+
+func (a *App) updateGoals(gtx layout.Context) {
+	if a.bodyweightBtn.Clicked(gtx) {
+		a.logBodyweight()
+	}
+	if a.goalAddBtn.Clicked(gtx) {
+		a.addGoal()
+	}
+	for id, btn := range a.goalDelBtns {
+		if !btn.Clicked(gtx) {
+			continue
+		}
+		if err := a.tracker.DeleteGoal(a.ctx, id); err != nil {
+			a.showError(err)
+			continue
+		}
+		delete(a.goalDelBtns, id)
+		a.invalidateData()
+		a.setSettingsStatus(true, "Goal deleted")
+	}
+}
+
+// checkGoals notifies about goals reached by what was just logged.
+func (a *App) checkGoals() {
+	reached, err := a.tracker.CheckGoals(a.ctx)
+	if err != nil {
+		a.showError(err)
+		return
+	}
+	for _, g := range reached {
+		notify("Goal reached", logic.GoalTitle(g))
+	}
+}
+
+func (a *App) logBodyweight() {
+	a.bodyweightMsg = ""
+	b, err := a.tracker.LogBodyweight(a.ctx, a.bodyweightEdit.Text(), "")
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.bodyweightMsg = ve.Error()
+	case err != nil:
+		a.showError(err)
+	default:
+		a.bodyweightEdit.SetText("")
+		a.bodyweightMsg = fmt.Sprintf("Logged %g kg for %s", b.Weight, b.Date)
+		a.checkGoals()
+		a.invalidateData()
+	}
+}
+
+func (a *App) addGoal() {
+	metric, exercise, target, by := logic.ParseGoal(a.goalEdit.Text())
+	g, err := a.tracker.AddGoal(a.ctx, metric, exercise, target, by, data.Today())
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.setSettingsStatus(false, "Goal not set: %v", err)
+	case err != nil:
+		a.showError(err)
+	default:
+		a.goalEdit.SetText("")
+		a.invalidateData()
+		a.setSettingsStatus(true, "Goal set: %s", logic.GoalTitle(g))
+	}
+}
+
+// goalColor is green for goals reached or on track, gold for those behind
+// and grey for those without a forecast.
+func goalColor(g *logic.GoalProgress) color.NRGBA {
+	switch {
+	case g.OnTrack:
+		return ColorAccent
+	case g.Forecast != "":
+		return ColorGold
+	}
+	return ColorSubtext
+}
+
+func progressBar(frac float64, col color.NRGBA) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Inset{Top: unit.Dp(2), Bottom: unit.Dp(8)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			w, h := gtx.Constraints.Max.X, gtx.Dp(6)
+			fillRect(gtx, ColorBorder, w, h)
+			fillRect(gtx, col, int(float64(w)*frac), h)
+			return layout.Dimensions{Size: image.Pt(w, h)}
+		})
+	}
+}
+
+func (a *App) dashGoalsCard(gtx layout.Context) layout.Dimensions {
+	goals := a.dash.val.Goals
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Goals", ColorAccent)),
+		}
+		if len(goals) == 0 {
+			children = append(children, layout.Rigid(a.cardLine("No goals yet. Set one under Settings.", ColorSubtext)))
+		}
+		for _, g := range goals {
+			line := fmt.Sprintf("%s  ·  %g kg now", g.Title, g.Current)
+			if g.PerWeek != 0 {
+				line += fmt.Sprintf(", %+g kg a week", g.PerWeek)
+			}
+			children = append(children,
+				layout.Rigid(a.cardLine(line, ColorText)),
+				layout.Rigid(progressBar(g.Progress, goalColor(g))),
+				layout.Rigid(a.cardLine(g.Status, goalColor(g))),
+			)
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+			layout.Rigid(a.formField("BODYWEIGHT (kg)", &a.bodyweightEdit, "82.5")),
+			layout.Rigid(a.smallButton(&a.bodyweightBtn, "LOG BODYWEIGHT")),
+		)
+		if a.bodyweightMsg != "" {
+			children = append(children, layout.Rigid(a.cardLine(a.bodyweightMsg, ColorSubtext)))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) layoutGoalsCard(gtx layout.Context) layout.Dimensions {
+	a.loadGoals()
+	if a.goalDelBtns == nil {
+		a.goalDelBtns = map[int64]*widget.Clickable{}
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Goals", ColorAccent)),
+			layout.Rigid(a.cardLine("A goal is a weight for a lift's heaviest set or estimated 1RM, or a bodyweight, optionally by a date. The dashboard forecasts when each is reached from the trend of the last eight weeks.", ColorSubtext)),
+		}
+		for _, g := range a.goals.val {
+			btn, ok := a.goalDelBtns[g.ID]
+			if !ok {
+				btn = &widget.Clickable{}
+				a.goalDelBtns[g.ID] = btn
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, a.cardLine(fmt.Sprintf("%s: %s", g.Title, g.Status), goalColor(g))),
+					layout.Rigid(a.smallButton(btn, "DELETE")),
+				)
+			}))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+			layout.Rigid(a.formField("NEW GOAL", &a.goalEdit, "Barbell Squats 140 kg by 2027-03-01, Barbell Squats e1rm 150, or bodyweight 80")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return a.accentButton(gtx, &a.goalAddBtn, "ADD GOAL")
+			}),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+
+	"gioui.org/app"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+type loadState int
+
+const (
+	loadIdle loadState = iota
+	loadPending
+	loadReady
+	loadFailed
+)
+
+// resource is a value read from the database off the frame loop. key says
+// what it was loaded for (usually the exercise), so switching exercise
+// triggers a reload while redraws reuse the cached value.
+type resource[T any] struct {
+	key   string
+	state loadState
+	val   T
+	has   bool // val belongs to key
+	err   error
+	seq   int // bumped per request so stale results are dropped
+}
+
+// invalidate marks r out of date. The last value stays visible until the
+// reload finishes.
+func (r *resource[T]) invalidate() {
+	r.state = loadIdle
+	r.seq++
+}
+
+// loading reports whether a load is running with nothing to show yet.
+func (r *resource[T]) loading() bool {
+	return !r.has && r.state != loadFailed
+}
+
+// loader runs fetches in background goroutines and hands the results back
+// to the UI goroutine, which applies them at the start of the next frame.
+// All App state is still only touched from the UI goroutine.
+type loader struct {
+	window  *app.Window
+	results chan func()
+}
+
+func newLoader() *loader {
+	return &loader{results: make(chan func(), 16)}
+}
+
+// apply runs the results that have arrived since the last frame.
+func (l *loader) apply() {
+	for {
+		select {
+		case f := <-l.results:
+			f()
+		default:
+			return
+		}
+	}
+}
+
+// load starts fetching r for key unless it already holds, or is loading,
+// data for that key.
+func load[T any](l *loader, r *resource[T], key string, fetch func() (T, error)) {
+	if r.key == key && r.state != loadIdle {
+		return
+	}
+	if r.key != key {
+		var zero T
+		r.val, r.has = zero, false
+	}
+	r.key = key
+	r.state = loadPending
+	r.seq++
+	seq := r.seq
+	go func() {
+		v, err := fetch()
+		l.results <- func() {
+			if r.seq != seq {
+				return
+			}
+			r.err = err
+			if err != nil {
+				r.state = loadFailed
+				return
+			}
+			r.val, r.has, r.state = v, true, loadReady
+		}
+		if l.window != nil {
+			l.window.Invalidate()
+		}
+	}()
+}
+
+// loadStatus shows the state of a resource above (or instead of) its
+// content: a loading line while there is nothing to show yet, or the error
+// with a retry button. It takes no space once the data is in.
+func (a *App) loadStatus(gtx layout.Context, what string, loading bool, err error) layout.Dimensions {
+	if err != nil {
+		return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					t := material.Body2(a.th, fmt.Sprintf("Couldn't load %s: %v", what, err))
+					t.Color = ColorRed
+					return t.Layout(gtx)
+				}),
+				layout.Rigid(a.smallButton(&a.retryBtn, "RETRY")),
+			)
+		})
+	}
+	if loading {
+		t := material.Body2(a.th, "Loading "+what+"…")
+		t.Color = ColorSubtext
+		return layout.Inset{Bottom: unit.Dp(12)}.Layout(gtx, t.Layout)
+	}
+	return layout.Dimensions{}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notify shows a desktop notification with a sound, using whatever the
+// platform has on hand. It never blocks, and failures are ignored: the
+// window shows the same message.
+func notify(title, body string) {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s sound name \"Glass\"",
+			appleScriptString(body), appleScriptString(title))
+		start("osascript", "-e", script)
+	case "windows":
+		// the console beep is the one sound every Windows install can make;
+		// the balloon tip is shown by a temporary tray icon
+		script := fmt.Sprintf(`[console]::beep(880,400);
+Add-Type -AssemblyName System.Windows.Forms;
+$n = New-Object System.Windows.Forms.NotifyIcon;
+$n.Icon = [System.Drawing.SystemIcons]::Information;
+$n.Visible = $true;
+$n.ShowBalloonTip(5000, '%s', '%s', 'Info');
+Start-Sleep -Seconds 6;
+$n.Dispose()`, powerShellString(title), powerShellString(body))
+		start("powershell", "-NoProfile", "-WindowStyle", "Hidden", "-Command", script)
+	default:
+		start("notify-send", "--app-name=ProgressTracker", title, body)
+		if start("canberra-gtk-play", "--id=complete", "--description="+title) != nil {
+			start("paplay", "/usr/share/sounds/freedesktop/stereo/complete.oga")
+		}
+	}
+}
+
+// start runs a command in the background, reaping it when it exits.
+func start(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellString(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
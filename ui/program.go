@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+// loadProgram reads the saved program into the settings card.
+func (a *App) loadProgram() {
+	p, err := a.tracker.Program(a.ctx, data.Today())
+	if err != nil {
+		a.showError(err)
+		return
+	}
+	if p == nil {
+		a.progTemplateEdit.SetText(logic.Templates[0].Name)
+		a.progLiftsEdit.SetText("")
+		return
+	}
+	a.progTemplateEdit.SetText(p.Template)
+	a.progLiftsEdit.SetText(p.LiftsString())
+}
+
+// loadPrescriptions is keyed by date, as a lift's week only moves on once
+// the day it was trained is over.
+func (a *App) loadPrescriptions() {
+	today := data.Today()
+	load(a.loader, &a.prog, today.String(), func() ([]*logic.Prescription, error) {
+		return a.tracker.Prescriptions(a.ctx, today)
+	})
+}
+
+func (a *App) saveProgram() {
+	p, err := a.tracker.SetProgram(a.ctx, a.progTemplateEdit.Text(), a.progLiftsEdit.Text(), data.Today())
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.setSettingsStatus(false, "Program not saved: %v", err)
+	case err != nil:
+		a.showError(err)
+	default:
+		a.progLiftsEdit.SetText(p.LiftsString())
+		a.prog.invalidate()
+		a.setSettingsStatus(true, "%s program started", p.Title())
+	}
+}
+
+func (a *App) clearProgram() {
+	if err := a.tracker.ClearProgram(a.ctx); err != nil {
+		a.showError(err)
+		return
+	}
+	a.prog.invalidate()
+	a.setSettingsStatus(true, "Program cleared; workouts are back to the usual targets")
+}
+
+func (a *App) layoutProgramCard(gtx layout.Context) layout.Dimensions {
+	a.loadPrescriptions()
+	names := make([]string, len(logic.Templates))
+	for i, t := range logic.Templates {
+		names[i] = fmt.Sprintf("%s (%s, %d weeks)", t.Name, t.Title, len(t.Weeks))
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Program", ColorAccent)),
+			layout.Rigid(a.cardLine("Prescribes sets as percentages of a training max. A lift moves to its next week each time it is trained, and its training max goes up at the end of the cycle.", ColorSubtext)),
+			layout.Rigid(a.cardLine("Templates: "+strings.Join(names, ", "), ColorSubtext)),
+		}
+		for _, pr := range a.prog.val {
+			line := fmt.Sprintf("%s: training max %g kg, cycle %d. %s", pr.Exercise, pr.TrainingMax, pr.Cycle, pr)
+			children = append(children, layout.Rigid(a.cardLine(line, ColorText)))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+			layout.Rigid(a.formField("TEMPLATE", &a.progTemplateEdit, "linear, 531 or dup")),
+			layout.Rigid(a.formField("LIFTS (exercise=training max kg; leave the kg out to use 90% of the e1RM)", &a.progLiftsEdit, "Barbell Squats=140, Flat Bench Barbell Chest Press")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.accentButton(gtx, &a.progSaveBtn, "START")
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(8)}.Layout),
+					layout.Rigid(a.smallButton(&a.progClearBtn, "CLEAR")),
+				)
+			}),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
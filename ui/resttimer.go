@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+const restStep = 15 * time.Second
+
+// restTimer counts down the rest after a logged set, or after a round of a
+// superset or circuit. It only redraws when the displayed second changes, by
+// scheduling the next frame with an InvalidateCmd.
+type restTimer struct {
+	exercises []string
+	saved     time.Duration // the exercises' rest as stored
+	total     time.Duration // this rest, adjustments included
+	end       time.Time     // zero when no rest is running
+	over      bool          // the rest ran out and has not been dismissed
+
+	plusBtn  widget.Clickable
+	minusBtn widget.Clickable
+	skipBtn  widget.Clickable
+	saveBtn  widget.Clickable
+}
+
+func (r *restTimer) running() bool {
+	return !r.end.IsZero()
+}
+
+func (r *restTimer) name() string {
+	return strings.Join(r.exercises, " + ")
+}
+
+// startRest starts the rest after a set of exs, replacing any running one.
+// Exercises done together share the longest of their rests.
+func (a *App) startRest(exs []string, now time.Time) {
+	d, err := a.tracker.GroupRest(a.ctx, exs)
+	if err != nil {
+		a.showError(err)
+	}
+	r := &a.rest
+	r.exercises, r.saved, r.total = exs, d, d
+	r.end = now.Add(d)
+	r.over = false
+}
+
+func (a *App) updateRest(gtx layout.Context) {
+	r := &a.rest
+	if r.plusBtn.Clicked(gtx) && r.running() {
+		r.end = r.end.Add(restStep)
+		r.total += restStep
+	}
+	if r.minusBtn.Clicked(gtx) && r.running() && r.total > restStep {
+		r.end = r.end.Add(-restStep)
+		r.total -= restStep
+	}
+	if r.skipBtn.Clicked(gtx) {
+		r.end = time.Time{}
+		r.over = false
+	}
+	if r.saveBtn.Clicked(gtx) {
+		r.saved = r.total
+		for _, ex := range r.exercises {
+			if err := a.tracker.SetRestDuration(a.ctx, ex, r.total); err != nil {
+				a.showError(err)
+				break
+			}
+		}
+	}
+
+	if !r.running() {
+		return
+	}
+	left := r.end.Sub(gtx.Now)
+	if left <= 0 {
+		r.end = time.Time{}
+		r.over = true
+		notify("Rest over", r.name()+": time for the next set")
+		return
+	}
+	// redraw when the shown second ticks over
+	next := left % time.Second
+	if next == 0 {
+		next = time.Second
+	}
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(next)})
+}
+
+// formatRest shows d as m:ss, rounding part seconds up so a rest never reads
+// 0:00 while it is still running.
+func formatRest(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+func (a *App) layoutRestCard(gtx layout.Context) layout.Dimensions {
+	r := &a.rest
+	if !r.running() && !r.over {
+		return layout.Dimensions{}
+	}
+	return layout.Inset{Bottom: unit.Dp(20)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+			if r.over {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						t := material.H6(a.th, "Rest over — time for the next set")
+						t.Color = ColorAccent
+						return t.Layout(gtx)
+					}),
+					layout.Rigid(a.smallButton(&r.skipBtn, "DISMISS")),
+				)
+			}
+			left := r.end.Sub(gtx.Now)
+			buttons := []layout.FlexChild{
+				layout.Rigid(a.smallButton(&r.minusBtn, "−15s")),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+				layout.Rigid(a.smallButton(&r.plusBtn, "+15s")),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+				layout.Rigid(a.smallButton(&r.skipBtn, "SKIP")),
+			}
+			if r.total != r.saved {
+				buttons = append(buttons,
+					layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+					layout.Rigid(a.smallButton(&r.saveBtn, "SAVE "+formatRest(r.total)+" AS DEFAULT")),
+				)
+			}
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(a.cardTitle("Rest · "+r.name(), ColorAccent)),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					t := material.H3(a.th, formatRest(left))
+					t.Color = ColorText
+					return t.Layout(gtx)
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(8)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return restBar(gtx, 1-float32(left)/float32(r.total))
+				}),
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, buttons...)
+				}),
+			)
+		})
+	})
+}
+
+// restBar is a thin progress bar filled to done (0 to 1).
+func restBar(gtx layout.Context, done float32) layout.Dimensions {
+	w, h := gtx.Constraints.Max.X, gtx.Dp(unit.Dp(6))
+	done = max(0, min(done, 1))
+	track := clip.UniformRRect(image.Rectangle{Max: image.Pt(w, h)}, h/2).Push(gtx.Ops)
+	paint.ColorOp{Color: ColorBorder}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	track.Pop()
+	fill := clip.UniformRRect(image.Rectangle{Max: image.Pt(int(float32(w)*done), h)}, h/2).Push(gtx.Ops)
+	paint.ColorOp{Color: ColorAccent}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	fill.Pop()
+	return layout.Dimensions{Size: image.Pt(w, h)}
+}
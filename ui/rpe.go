@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"progresstracker/logic"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+)
+
+// suggestText suggests a load for the reps and RPE typed in, from the e1RM
+// of the last session of exercise. It is empty until both are filled in, or
+// when there is no history to go on.
+func (a *App) suggestText(exercise string, e1rm float64, repsEd, rpeEd *widget.Editor) string {
+	reps, err := strconv.Atoi(strings.TrimSpace(repsEd.Text()))
+	if err != nil || e1rm <= 0 {
+		return ""
+	}
+	rpe, err := logic.ParseEffort(rpeEd.Text())
+	if err != nil || rpe == 0 {
+		return ""
+	}
+	w, ok := logic.SuggestLoad(e1rm, reps, rpe)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("For %d reps at RPE %g: about %g kg (e1RM %.1f kg)", reps, rpe, math.Round(a.equip.Round(exercise, w)*10)/10, e1rm)
+}
+
+// layoutSuggestion shows suggestText under an RPE field.
+func (a *App) layoutSuggestion(exercise string, e1rm float64, repsEd, rpeEd *widget.Editor) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		s := a.suggestText(exercise, e1rm, repsEd, rpeEd)
+		if s == "" {
+			return layout.Dimensions{}
+		}
+		return a.cardLine(s, ColorAccent2)(gtx)
+	}
+}
@@ -0,0 +1,494 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// sessionView is the Log tab while a guided workout is in progress, and the
+// summary shown once it is finished.
+type sessionView struct {
+	s        *logic.Session
+	report   *logic.SessionReport
+	shown    *logic.SessionExercise // exercise the editors were filled in for
+	shownSet int                    // and which of its sets, for program lifts
+
+	weightEdit widget.Editor
+	repsEdit   widget.Editor
+	rpeEdit    widget.Editor
+	logBtn     widget.Clickable
+	skipBtn    widget.Clickable
+	finishBtn  widget.Clickable
+	closeBtn   widget.Clickable
+	rowBtns    []widget.Clickable
+	upBtns     []widget.Clickable
+	downBtns   []widget.Clickable
+	warmups    warmupRow
+
+	errs       map[string]string // rejected editors and why
+	confirmKey string            // setKey of an outlier awaiting a second click
+	status     string            // why the last LOG SET did not save
+}
+
+// startSession starts the guided workout for day in the current week and
+// shows it on the Log tab.
+func (a *App) startSession(gtx layout.Context, day string) {
+	s, err := a.tracker.StartSession(a.ctx, day, a.currentWeek, gtx.Now)
+	if err != nil {
+		a.showError(err)
+		return
+	}
+	n := len(s.Exercises)
+	a.sess = sessionView{
+		s:        s,
+		rowBtns:  make([]widget.Clickable, n),
+		upBtns:   make([]widget.Clickable, n),
+		downBtns: make([]widget.Clickable, n),
+	}
+	a.sess.weightEdit.SingleLine = true
+	a.sess.repsEdit.SingleLine = true
+	a.sess.rpeEdit.SingleLine = true
+	a.activeTab = TabLog
+}
+
+func (a *App) updateSession(gtx layout.Context) {
+	v := &a.sess
+	if v.closeBtn.Clicked(gtx) {
+		v.report = nil
+	}
+	s := v.s
+	if s == nil {
+		return
+	}
+	for i := range s.Exercises {
+		if v.rowBtns[i].Clicked(gtx) {
+			s.Select(i)
+		}
+		if v.upBtns[i].Clicked(gtx) {
+			s.Move(i, -1)
+		}
+		if v.downBtns[i].Clicked(gtx) {
+			s.Move(i, 1)
+		}
+	}
+	if v.skipBtn.Clicked(gtx) {
+		s.Skip()
+	}
+	clearEditedErrors(gtx, v.fields(), v.errs)
+	if v.logBtn.Clicked(gtx) {
+		a.logSessionSet(gtx)
+	}
+	if i := v.warmups.clicked(gtx); i >= 0 {
+		if _, err := a.tracker.LogWarmup(a.ctx, s, v.warmups.sets[i]); err != nil {
+			a.showError(err)
+		} else {
+			v.warmups.done[i] = true
+			a.invalidateData()
+		}
+	}
+	if v.finishBtn.Clicked(gtx) {
+		v.report = s.Finish(gtx.Now)
+		v.s = nil
+		a.rest = restTimer{}
+		return
+	}
+
+	// fill in the target when moving to another exercise; after a set the
+	// values are kept, so the next identical set is one click
+	if ex := s.CurrentExercise(); ex != v.shown {
+		v.shown = ex
+		v.shownSet = -1
+		v.warmups = warmupRow{}
+		v.errs, v.confirmKey, v.status = nil, "", ""
+		if ex != nil {
+			v.weightEdit.SetText(targetWeight(ex))
+			v.repsEdit.SetText("")
+			if ex.Target.Reps > 0 {
+				v.repsEdit.SetText(strconv.Itoa(ex.Target.Reps))
+			}
+		}
+	}
+
+	// program lifts have every set prescribed; fill each in as it comes up
+	if ex := v.shown; ex != nil && v.shownSet != len(ex.Sets) {
+		v.shownSet = len(ex.Sets)
+		if set, ok := ex.NextPrescribed(); ok {
+			v.weightEdit.SetText(strconv.FormatFloat(set.Weight, 'f', -1, 64))
+			v.repsEdit.SetText(strconv.Itoa(set.Reps))
+		}
+	}
+
+	// warm-ups lead up to the weight typed in, until the first working set
+	if ex := s.CurrentExercise(); ex != nil && len(ex.Sets) == 0 {
+		v.warmups.show(a.warmScheme.For(ex.Name, workingWeight(&v.weightEdit)))
+	} else {
+		v.warmups.show(nil)
+	}
+
+	// redraw when the elapsed minutes shown tick over
+	elapsed := gtx.Now.Sub(s.Started)
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Minute - elapsed%time.Minute)})
+}
+
+// setKey identifies the set typed into the session editors, so a second
+// click on an outlier can be told from a corrected one.
+func (v *sessionView) setKey() string {
+	name := ""
+	if ex := v.s.CurrentExercise(); ex != nil {
+		name = ex.Name
+	}
+	return strings.Join([]string{name, v.weightEdit.Text(), v.repsEdit.Text(), v.rpeEdit.Text()}, "\x00")
+}
+
+// fields are the session editors, named like the fields of a
+// data.ValidationError.
+func (v *sessionView) fields() []formField {
+	return []formField{
+		{"weight", &v.weightEdit},
+		{"reps", &v.repsEdit},
+		{"rpe", &v.rpeEdit},
+	}
+}
+
+// logSessionSet logs the set in the session editors. Like saveEntry, a set
+// far above the recent history needs a second click, and rejected values
+// are marked on their editors.
+func (a *App) logSessionSet(gtx layout.Context) {
+	v := &a.sess
+	s := v.s
+	v.errs, v.status = nil, ""
+	if ex := s.CurrentExercise(); ex != nil {
+		if key := v.setKey(); key != v.confirmKey {
+			v.confirmKey = ""
+			o, err := a.tracker.CheckEntry(a.ctx, ex.Name, v.weightEdit.Text(), v.repsEdit.Text(), "1", v.rpeEdit.Text(), "", s.Date.String())
+			if err == nil && o != nil {
+				v.confirmKey = key
+				v.status = "Is this right? The " + o.String() + ". Click LOG ANYWAY to keep it."
+				return
+			}
+			// errors are reported by LogSet below
+		}
+	}
+	v.confirmKey = ""
+	group := s.CurrentGroup()
+	_, err := a.tracker.LogSet(a.ctx, s, v.weightEdit.Text(), v.repsEdit.Text(), v.rpeEdit.Text())
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		v.showErrors(gtx, ve)
+	case err != nil:
+		a.showError(err)
+	default:
+		a.checkGoals()
+		a.invalidateData()
+		if s.RestDue {
+			a.startRest(group, gtx.Now)
+		} else {
+			// straight on to the next exercise of the round
+			a.rest = restTimer{}
+		}
+	}
+}
+
+// showErrors marks every session editor ve rejected and moves focus to the
+// first of them. Rejections of anything else, like an exercise that is
+// done, go in the status line.
+func (v *sessionView) showErrors(gtx layout.Context, ve *data.ValidationError) {
+	v.errs = map[string]string{}
+	var reasons []string
+	for _, f := range ve.Fields {
+		if _, ok := v.errs[f.Field]; !ok {
+			v.errs[f.Field] = f.Msg
+			reasons = append(reasons, f.Msg)
+		}
+	}
+	v.status = strings.Join(reasons, "; ")
+	for _, f := range v.fields() {
+		if _, ok := v.errs[f.name]; ok {
+			gtx.Execute(key.FocusCmd{Tag: f.ed})
+			break
+		}
+	}
+}
+
+func targetWeight(ex *logic.SessionExercise) string {
+	if ex.Target.Reps == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(ex.Target.Weight, 'f', -1, 64)
+}
+
+// formatMinutes shows d to the minute, as 47 min or 1 h 05 min.
+func formatMinutes(d time.Duration) string {
+	m := int(d / time.Minute)
+	if m >= 60 {
+		return fmt.Sprintf("%d h %02d min", m/60, m%60)
+	}
+	return fmt.Sprintf("%d min", m)
+}
+
+func (a *App) layoutSession(gtx layout.Context) layout.Dimensions {
+	v := &a.sess
+	if v.report != nil {
+		return a.layoutSessionReport(gtx)
+	}
+	s := v.s
+	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+					return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							t := material.H5(a.th, s.Day+" Workout")
+							t.Color = ColorText
+							return t.Layout(gtx)
+						}),
+						layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+						layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+							t := material.Body2(a.th, fmt.Sprintf("Week %d · %s in progress", s.Week, formatMinutes(gtx.Now.Sub(s.Started))))
+							t.Color = ColorSubtext
+							return t.Layout(gtx)
+						}),
+					)
+				}),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					btn := material.Button(a.th, &v.finishBtn, "FINISH WORKOUT")
+					btn.Background = ColorAccent
+					btn.Color = color.NRGBA{A: 255}
+					return btn.Layout(gtx)
+				}),
+			)
+		}),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+		layout.Rigid(a.layoutSessionSet),
+		layout.Rigid(layout.Spacer{Height: unit.Dp(20)}.Layout),
+		layout.Rigid(a.layoutRestCard),
+		layout.Rigid(a.layoutSessionPlan),
+	)
+}
+
+// layoutSessionSet is the card for logging the current exercise's next set.
+func (a *App) layoutSessionSet(gtx layout.Context) layout.Dimensions {
+	v := &a.sess
+	ex := v.s.CurrentExercise()
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		if ex == nil {
+			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(a.cardTitle("All exercises done", ColorAccent)),
+				layout.Rigid(a.cardLine("Finish the workout to see the summary, or pick an exercise below for extra sets.", ColorSubtext)),
+			)
+		}
+		last := "nothing logged yet"
+		if len(ex.Last) > 0 {
+			last = formatSets(ex.Last) + " on " + ex.Last[0].Date.String()
+		}
+		children := []layout.FlexChild{
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.H6(a.th, ex.Name)
+				t.Color = ColorText
+				return layout.Inset{Bottom: unit.Dp(4)}.Layout(gtx, t.Layout)
+			}),
+			layout.Rigid(a.cardTitle(fmt.Sprintf("Set %d of %d", len(ex.Sets)+1, ex.Target.Sets), ColorAccent)),
+			layout.Rigid(a.cardLine("Target: "+ex.Target.String(), ColorText)),
+			layout.Rigid(a.layoutPrescription(ex)),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				group := data.Block(v.s.CurrentGroup())
+				if group.Kind() == "" {
+					return layout.Dimensions{}
+				}
+				others := slices.DeleteFunc(slices.Clone(group), func(n string) bool { return n == ex.Name })
+				line := "In a " + group.Kind() + " with " + strings.Join(others, ", ") + ", resting after each round"
+				return a.cardLine(line, ColorAccent2)(gtx)
+			}),
+			layout.Rigid(a.cardLine("Last time: "+last, ColorSubtext)),
+			layout.Rigid(a.layoutWarmupRow(&v.warmups)),
+		}
+		if len(ex.Sets) > 0 {
+			children = append(children, layout.Rigid(a.cardLine("Today: "+formatSets(ex.Sets), ColorAccent2)))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.End}.Layout(gtx,
+					layout.Flexed(1, a.sessionInput("WEIGHT (kg)", "weight", &v.weightEdit)),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Flexed(1, a.sessionInput("REPS", "reps", &v.repsEdit)),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Flexed(1, a.sessionInput("RPE / RIR", "rpe", &v.rpeEdit)),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						label, bg := "LOG SET", ColorAccent
+						if v.confirmKey != "" && v.confirmKey == v.setKey() {
+							label, bg = "LOG ANYWAY", ColorGold
+						}
+						btn := material.Button(a.th, &v.logBtn, label)
+						btn.Background = bg
+						btn.Color = color.NRGBA{A: 255}
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+					layout.Rigid(a.smallButton(&v.skipBtn, "SKIP")),
+				)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if v.status == "" {
+					return layout.Dimensions{}
+				}
+				t := material.Body2(a.th, v.status)
+				t.Color = ColorRed
+				return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, t.Layout)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(6)}.Layout),
+			layout.Rigid(a.layoutPlates(ex.Name, &v.weightEdit)),
+			layout.Rigid(a.layoutSuggestion(ex.Name, ex.E1RM, &v.repsEdit, &v.rpeEdit)),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+// sessionInput lays out a labelled session editor, with a red label and
+// border while its value is rejected.
+func (a *App) sessionInput(label, name string, ed *widget.Editor) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		_, invalid := a.sess.errs[name]
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.Caption(a.th, label)
+				t.Color = ColorSubtext
+				if invalid {
+					t.Color = ColorRed
+				}
+				return t.Layout(gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if invalid {
+					return borderedEditor(gtx, a.th, ed, "", ColorRed)
+				}
+				return styledEditor(gtx, a.th, ed)
+			}),
+		)
+	}
+}
+
+// layoutPrescription shows a program lift's sets for the session, and what
+// the next one asks for.
+func (a *App) layoutPrescription(ex *logic.SessionExercise) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		pr := ex.Prescription
+		if pr == nil {
+			return layout.Dimensions{}
+		}
+		next := "all prescribed sets done"
+		if set, ok := ex.NextPrescribed(); ok {
+			next = fmt.Sprintf("next %g kg × %d", set.Weight, set.Reps)
+			if set.AMRAP {
+				next = fmt.Sprintf("next %g kg for as many reps as you can, at least %d", set.Weight, set.Reps)
+			}
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardLine(pr.String(), ColorGold)),
+			layout.Rigid(a.cardLine(fmt.Sprintf("Training max %g kg, cycle %d; %s", pr.TrainingMax, pr.Cycle, next), ColorSubtext)),
+		)
+	}
+}
+
+// formatSets lists sets as weight×reps, with a multiplier for entries of
+// several sets.
+func formatSets(entries []*data.Entry) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%g×%d", e.Weight, e.Reps)
+		if e.Sets > 1 {
+			parts[i] += fmt.Sprintf("×%d", e.Sets)
+		}
+		if e.RPE != 0 {
+			parts[i] += " " + logic.FormatEffort(e.RPE)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// layoutSessionPlan lists the session's exercises in order, for picking,
+// reordering and seeing what is left.
+func (a *App) layoutSessionPlan(gtx layout.Context) layout.Dimensions {
+	v := &a.sess
+	s := v.s
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{layout.Rigid(a.cardTitle("Plan", ColorAccent2))}
+		labels := s.Labels()
+		for i, ex := range s.Exercises {
+			mark, col := "○", ColorText
+			switch {
+			case i == s.Current:
+				mark, col = "▸", ColorAccent
+			case ex.Skipped && len(ex.Sets) == 0:
+				mark, col = "–", ColorSubtext
+			case ex.Done():
+				mark, col = "✓", ColorSubtext
+			}
+			label := fmt.Sprintf("%s  %-3s %s   %d/%d sets", mark, labels[i], ex.Name, len(ex.Sets), ex.Target.Sets)
+			if ex.Skipped && len(ex.Sets) == 0 {
+				label = fmt.Sprintf("%s  %-3s %s   skipped", mark, labels[i], ex.Name)
+			}
+			children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						return v.rowBtns[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+							t := material.Body2(a.th, label)
+							t.Color = col
+							return layout.Inset{Top: unit.Dp(6), Bottom: unit.Dp(6)}.Layout(gtx, t.Layout)
+						})
+					}),
+					layout.Rigid(a.smallButton(&v.upBtns[i], "▲")),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(4)}.Layout),
+					layout.Rigid(a.smallButton(&v.downBtns[i], "▼")),
+				)
+			}))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) layoutSessionReport(gtx layout.Context) layout.Dimensions {
+	v := &a.sess
+	r := v.report
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle(r.Day+" workout complete", ColorAccent)),
+			layout.Rigid(a.cardLine("Duration: "+formatMinutes(r.Duration), ColorText)),
+			layout.Rigid(a.cardLine(fmt.Sprintf("%d exercises · %d sets", r.Exercises, r.Sets), ColorText)),
+			layout.Rigid(a.cardLine(fmt.Sprintf("Total volume: %.0f kg", r.Volume), ColorText)),
+		}
+		if len(r.PRs) == 0 {
+			children = append(children, layout.Rigid(a.cardLine("No new PRs this time.", ColorSubtext)))
+		}
+		for _, pr := range r.PRs {
+			line := fmt.Sprintf("🏆  %s: %.1f kg (was %.1f)", pr.Exercise, pr.Weight, pr.Previous)
+			children = append(children, layout.Rigid(a.cardLine(line, ColorGold)))
+		}
+		if len(r.Skipped) > 0 {
+			children = append(children, layout.Rigid(a.cardLine("Skipped: "+strings.Join(r.Skipped, ", "), ColorSubtext)))
+		}
+		children = append(children,
+			layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+			layout.Rigid(a.smallButton(&v.closeBtn, "DONE")),
+		)
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
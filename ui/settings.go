@@ -0,0 +1,530 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+const importPreviewRows = 10
+
+func (a *App) initSettings() {
+	a.settingsScroll.Axis = layout.Vertical
+	for _, ed := range []*widget.Editor{&a.expPathEdit, &a.expExerciseEdit, &a.expFromEdit, &a.expToEdit, &a.impPathEdit, &a.impMapEdit, &a.bakPathEdit, &a.appPathEdit, &a.warmStepsEdit, &a.warmBarEdit, &a.platesEdit, &a.barsEdit, &a.dumbbellStepEdit, &a.machineStepEdit, &a.progTemplateEdit, &a.progLiftsEdit, &a.goalEdit, &a.bodyweightEdit} {
+		ed.SingleLine = true
+	}
+	a.expPathEdit.SetText("progress-export.csv")
+	a.bakPathEdit.SetText("progresstracker-backup.json")
+	a.impUnit = logic.UnitKg
+	a.appUnit = logic.UnitKg
+	a.backupSel = -1
+}
+
+func (a *App) updateSettings(gtx layout.Context) {
+	if a.progSaveBtn.Clicked(gtx) {
+		a.saveProgram()
+	}
+	if a.progClearBtn.Clicked(gtx) {
+		a.clearProgram()
+	}
+	if a.equipSaveBtn.Clicked(gtx) {
+		a.saveEquipment()
+	}
+	if a.warmSaveBtn.Clicked(gtx) {
+		a.saveWarmupScheme()
+	}
+	if a.exportBtn.Clicked(gtx) {
+		a.exportCSV()
+	}
+	if a.impUnitBtn.Clicked(gtx) {
+		if a.impUnit == logic.UnitKg {
+			a.impUnit = logic.UnitLb
+		} else {
+			a.impUnit = logic.UnitKg
+		}
+		a.impPreview = nil
+	}
+	if a.impPreviewBtn.Clicked(gtx) {
+		a.importCSV(true)
+	}
+	if a.impRunBtn.Clicked(gtx) {
+		a.importCSV(false)
+	}
+	if a.appSourceBtn.Clicked(gtx) {
+		a.appSource = (a.appSource + 1) % len(logic.AppSources)
+		a.appImport = nil
+	}
+	if a.appUnitBtn.Clicked(gtx) {
+		if a.appUnit == logic.UnitKg {
+			a.appUnit = logic.UnitLb
+		} else {
+			a.appUnit = logic.UnitKg
+		}
+		a.appImport = nil
+	}
+	if a.appAnalyzeBtn.Clicked(gtx) {
+		a.analyzeAppExport()
+	}
+	for i := range a.appChoiceBtns {
+		if a.appChoiceBtns[i].Clicked(gtx) {
+			// cycle through the suggestions, then "ignore"
+			a.appChoices[i] = (a.appChoices[i] + 1) % (len(a.appImport.Unknown[i].Suggestions) + 1)
+		}
+	}
+	if a.appImportBtn.Clicked(gtx) {
+		a.importAppExport()
+	}
+	if a.backupBtn.Clicked(gtx) {
+		a.backupJSON()
+	}
+	if a.restoreModeBtn.Clicked(gtx) {
+		a.restoreReplace = !a.restoreReplace
+		a.restoreArmed = false
+	}
+	if a.restoreBtn.Clicked(gtx) {
+		// replacing wipes the database, so it takes a second click
+		if a.restoreReplace && !a.restoreArmed {
+			a.restoreArmed = true
+			a.setSettingsStatus(false, "Replace deletes all current entries. Click again to confirm.")
+		} else {
+			a.restoreArmed = false
+			a.restoreJSON()
+		}
+	}
+}
+
+func (a *App) setSettingsStatus(ok bool, format string, args ...any) {
+	a.settingsMsg = fmt.Sprintf(format, args...)
+	a.settingsOK = ok
+}
+
+func (a *App) exportCSV() {
+	path := strings.TrimSpace(a.expPathEdit.Text())
+	if path == "" {
+		a.setSettingsStatus(false, "Error: choose a file to export to")
+		return
+	}
+	filter := logic.ExportFilter{Exercise: strings.TrimSpace(a.expExerciseEdit.Text())}
+	var err error
+	if filter.From, err = editorDate(&a.expFromEdit); err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	if filter.To, err = editorDate(&a.expToEdit); err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	defer f.Close()
+	n, err := a.tracker.ExportCSV(a.ctx, f, filter)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.setSettingsStatus(true, "Exported %d entries to %s", n, path)
+}
+
+// editorDate reads an optional date, shortcuts included, from ed.
+func editorDate(ed *widget.Editor) (data.Date, error) {
+	text := strings.TrimSpace(ed.Text())
+	if text == "" {
+		return "", nil
+	}
+	return data.ResolveDate(text, data.Today())
+}
+
+func (a *App) importCSV(dryRun bool) {
+	a.impPreview = nil
+	mapping, err := logic.ParseColumnMapping(a.impMapEdit.Text())
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	f, err := os.Open(strings.TrimSpace(a.impPathEdit.Text()))
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	defer f.Close()
+	res, err := a.tracker.ImportCSV(a.ctx, f, logic.ImportOptions{Mapping: mapping, Unit: a.impUnit, DryRun: dryRun})
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	if dryRun {
+		a.impPreview = res
+		a.setSettingsStatus(true, "Preview: %d rows, %d to import, %d duplicates, %d invalid",
+			res.Rows, len(res.Entries), res.Duplicates, len(res.Invalid))
+		return
+	}
+	a.setSettingsStatus(true, "Imported %d entries (%d duplicates, %d invalid skipped)",
+		res.Imported, res.Duplicates, len(res.Invalid))
+	a.invalidateData()
+}
+
+func (a *App) analyzeAppExport() {
+	a.appImport = nil
+	a.impPreview = nil
+	f, err := os.Open(strings.TrimSpace(a.appPathEdit.Text()))
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	defer f.Close()
+	imp, err := a.tracker.ReadAppExport(a.ctx, f, logic.AppSources[a.appSource], a.appUnit)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	res, err := a.tracker.ImportApp(a.ctx, imp, true)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.appImport = imp
+	a.appChoices = make([]int, len(imp.Unknown))
+	a.appChoiceBtns = make([]widget.Clickable, len(imp.Unknown))
+	a.impPreview = res
+	a.setSettingsStatus(true, "%d sets read, %d exercise names need mapping", res.Rows, len(imp.Unknown))
+}
+
+// appChoice returns the exercise picked for the i-th unknown name, or "" to
+// ignore it.
+func (a *App) appChoice(i int) string {
+	sugg := a.appImport.Unknown[i].Suggestions
+	if a.appChoices[i] < len(sugg) {
+		return sugg[a.appChoices[i]]
+	}
+	return ""
+}
+
+func (a *App) importAppExport() {
+	imp := a.appImport
+	if imp == nil {
+		a.setSettingsStatus(false, "Analyze an export first")
+		return
+	}
+	for i, u := range imp.Unknown {
+		if err := a.tracker.MapExercise(a.ctx, imp.Source, u.Name, a.appChoice(i)); err != nil {
+			a.setSettingsStatus(false, "Error: %v", err)
+			return
+		}
+	}
+	res, err := a.tracker.ImportApp(a.ctx, imp, false)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.appImport = nil
+	a.impPreview = nil
+	a.setSettingsStatus(true, "Imported %d entries (%d duplicates, %d ignored, %d invalid skipped)",
+		res.Imported, res.Duplicates, res.Ignored, len(res.Invalid))
+	a.invalidateData()
+}
+
+func (a *App) backupJSON() {
+	path := strings.TrimSpace(a.bakPathEdit.Text())
+	f, err := os.Create(path)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	if err := a.repo.Backup(a.ctx, f); err != nil {
+		f.Close()
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	if err := f.Close(); err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.setSettingsStatus(true, "Backup written to %s", path)
+}
+
+func (a *App) restoreJSON() {
+	a.restoreReport = nil
+	f, err := os.Open(strings.TrimSpace(a.bakPathEdit.Text()))
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	defer f.Close()
+	mode := data.RestoreMerge
+	if a.restoreReplace {
+		mode = data.RestoreReplace
+	}
+	report, err := a.repo.Restore(a.ctx, f, mode)
+	if err != nil {
+		a.setSettingsStatus(false, "Error: %v", err)
+		return
+	}
+	a.restoreReport = report
+	a.setSettingsStatus(true, "Restored: %d entries added, %d already present, %d conflicts",
+		report.EntriesAdded, report.EntriesSkipped, len(report.Conflicts))
+	a.reloadWeek()
+	a.rebuildExBtns()
+	a.invalidateData()
+}
+
+func (a *App) layoutSettings(gtx layout.Context) layout.Dimensions {
+	sections := []layout.Widget{
+		func(gtx layout.Context) layout.Dimensions {
+			t := material.H5(a.th, "Settings")
+			t.Color = ColorText
+			return t.Layout(gtx)
+		},
+		a.layoutSettingsStatus,
+		a.layoutGoalsCard,
+		a.layoutProgramCard,
+		a.layoutEquipmentCard,
+		a.layoutWarmupCard,
+		a.layoutExportCard,
+		a.layoutImportCard,
+		a.layoutAppImportCard,
+		a.layoutImportPreview,
+		a.layoutBackupCard,
+		a.layoutSnapshotsCard,
+	}
+	return layout.UniformInset(unit.Dp(28)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return a.settingsScroll.Layout(gtx, len(sections), func(gtx layout.Context, idx int) layout.Dimensions {
+			gtx.Constraints.Min.X = gtx.Constraints.Max.X
+			return layout.Inset{Bottom: unit.Dp(16)}.Layout(gtx, sections[idx])
+		})
+	})
+}
+
+func (a *App) layoutExportCard(gtx layout.Context) layout.Dimensions {
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardTitle("Export CSV", ColorAccent)),
+			layout.Rigid(a.formField("FILE", &a.expPathEdit, "progress-export.csv")),
+			layout.Rigid(a.formField("EXERCISE (blank for all)", &a.expExerciseEdit, "e.g. Barbell Squats")),
+			layout.Rigid(a.formField("FROM (YYYY-MM-DD, optional)", &a.expFromEdit, "2025-01-01")),
+			layout.Rigid(a.formField("TO (YYYY-MM-DD, optional)", &a.expToEdit, "2025-12-31")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return a.accentButton(gtx, &a.exportBtn, "EXPORT")
+			}),
+		)
+	})
+}
+
+func (a *App) layoutImportCard(gtx layout.Context) layout.Dimensions {
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardTitle("Import CSV", ColorAccent)),
+			layout.Rigid(a.formField("FILE", &a.impPathEdit, "path/to/entries.csv")),
+			layout.Rigid(a.formField("COLUMN MAPPING (optional)", &a.impMapEdit, "date=Day,weight=Load (lbs)")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.impUnitBtn, "UNIT: "+strings.ToUpper(a.impUnit))
+						btn.Background = ColorBorder
+						btn.Color = ColorText
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.impPreviewBtn, "PREVIEW")
+						btn.Background = ColorAccent2
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.accentButton(gtx, &a.impRunBtn, "IMPORT")
+					}),
+				)
+			}),
+		)
+	})
+}
+
+func (a *App) layoutSettingsStatus(gtx layout.Context) layout.Dimensions {
+	if a.settingsMsg == "" {
+		return layout.Dimensions{}
+	}
+	t := material.Body2(a.th, a.settingsMsg)
+	if a.settingsOK {
+		t.Color = ColorAccent
+	} else {
+		t.Color = ColorRed
+	}
+	return t.Layout(gtx)
+}
+
+func (a *App) layoutImportPreview(gtx layout.Context) layout.Dimensions {
+	res := a.impPreview
+	if res == nil {
+		return layout.Dimensions{}
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Import Preview", ColorAccent2)),
+		}
+		for i, e := range res.Entries {
+			if i == importPreviewRows {
+				children = append(children, layout.Rigid(a.cardLine(
+					fmt.Sprintf("… and %d more", len(res.Entries)-importPreviewRows), ColorSubtext)))
+				break
+			}
+			children = append(children, layout.Rigid(a.cardLine(
+				fmt.Sprintf("%s  %s: %.1f kg × %d × %d", e.Date, e.Exercise, e.Weight, e.Reps, e.Sets), ColorText)))
+		}
+		for _, ie := range res.Invalid {
+			children = append(children, layout.Rigid(a.cardLine("Skipped "+ie.Error(), ColorRed)))
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) layoutAppImportCard(gtx layout.Context) layout.Dimensions {
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Import From Another App", ColorAccent)),
+			layout.Rigid(a.formField("EXPORT FILE (CSV)", &a.appPathEdit, "path/to/strong_workouts.csv")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.appSourceBtn, "FROM: "+strings.ToUpper(logic.AppSources[a.appSource]))
+						btn.Background = ColorBorder
+						btn.Color = ColorText
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.appUnitBtn, "UNIT: "+strings.ToUpper(a.appUnit))
+						btn.Background = ColorBorder
+						btn.Color = ColorText
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.appAnalyzeBtn, "ANALYZE")
+						btn.Background = ColorAccent2
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.accentButton(gtx, &a.appImportBtn, "IMPORT")
+					}),
+				)
+			}),
+		}
+		if imp := a.appImport; imp != nil && len(imp.Unknown) > 0 {
+			children = append(children,
+				layout.Rigid(layout.Spacer{Height: unit.Dp(12)}.Layout),
+				layout.Rigid(a.cardLine("Map unknown exercises (click to change, remembered for next time):", ColorSubtext)),
+			)
+			for i, u := range imp.Unknown {
+				i, u := i, u
+				children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					return layout.Inset{Top: unit.Dp(6)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+						return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+							layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+								t := material.Body2(a.th, fmt.Sprintf("%s (%d sets)  →", u.Name, u.Sets))
+								t.Color = ColorText
+								return t.Layout(gtx)
+							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								choice := a.appChoice(i)
+								if choice == "" {
+									choice = "Ignore"
+								}
+								btn := material.Button(a.th, &a.appChoiceBtns[i], choice)
+								btn.Background = ColorBorder
+								btn.Color = ColorAccent
+								btn.TextSize = unit.Sp(12)
+								return btn.Layout(gtx)
+							}),
+						)
+					})
+				}))
+			}
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) layoutBackupCard(gtx layout.Context) layout.Dimensions {
+	mode := "MODE: MERGE"
+	if a.restoreReplace {
+		mode = "MODE: REPLACE"
+	}
+	restore := "RESTORE"
+	if a.restoreArmed {
+		restore = "CONFIRM REPLACE"
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		children := []layout.FlexChild{
+			layout.Rigid(a.cardTitle("Backup & Restore (JSON)", ColorAccent)),
+			layout.Rigid(a.formField("FILE", &a.bakPathEdit, "progresstracker-backup.json")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return a.accentButton(gtx, &a.backupBtn, "BACKUP")
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.restoreModeBtn, mode)
+						btn.Background = ColorBorder
+						btn.Color = ColorText
+						return btn.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(10)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &a.restoreBtn, restore)
+						btn.Background = ColorAccent2
+						if a.restoreArmed {
+							btn.Background = ColorRed
+						}
+						return btn.Layout(gtx)
+					}),
+				)
+			}),
+		}
+		if r := a.restoreReport; r != nil && len(r.Conflicts) > 0 {
+			children = append(children, layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout))
+			for _, c := range r.Conflicts {
+				children = append(children, layout.Rigid(a.cardLine(
+					fmt.Sprintf("Conflict (%s) %s: kept %q, backup has %q", c.Kind, c.Key, c.Local, c.Incoming), ColorGold)))
+			}
+		}
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx, children...)
+	})
+}
+
+func (a *App) formField(label string, ed *widget.Editor, hint string) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.Caption(a.th, label)
+				t.Color = ColorSubtext
+				return t.Layout(gtx)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(4)}.Layout),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return plainEditor(gtx, a.th, ed, hint)
+			}),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(14)}.Layout),
+		)
+	}
+}
+
+func (a *App) accentButton(gtx layout.Context, btn *widget.Clickable, label string) layout.Dimensions {
+	b := material.Button(a.th, btn, label)
+	b.Background = ColorAccent
+	b.Color = color.NRGBA{A: 255}
+	return b.Layout(gtx)
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"time"
+
+	"progresstracker/data"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+const toastDuration = 6 * time.Second
+
+// toast is an error shown over the bottom of the window for a few seconds.
+// Failures of actions that have no form of their own to report into end up
+// here, so they are never silently dropped.
+type toast struct {
+	msg      string
+	until    time.Time
+	closeBtn widget.Clickable
+}
+
+// showError reports err in the toast. Validation errors show just their
+// reasons; anything else is prefixed so it reads as a failure.
+func (a *App) showError(err error) {
+	msg := "Error: " + err.Error()
+	if errors.Is(err, data.ErrValidation) {
+		msg = err.Error()
+	}
+	a.toast.msg = msg
+	a.toast.until = time.Time{}
+}
+
+func (a *App) layoutToast(gtx layout.Context) layout.Dimensions {
+	t := &a.toast
+	if t.closeBtn.Clicked(gtx) {
+		t.msg = ""
+	}
+	if t.msg == "" {
+		return layout.Dimensions{}
+	}
+	if t.until.IsZero() {
+		t.until = gtx.Now.Add(toastDuration)
+	}
+	if !gtx.Now.Before(t.until) {
+		t.msg = ""
+		return layout.Dimensions{}
+	}
+	gtx.Execute(op.InvalidateCmd{At: t.until})
+
+	bg := color.NRGBA{R: 90, G: 24, B: 28, A: 245}
+	return layout.S.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.UniformInset(unit.Dp(20)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			gtx.Constraints.Min.X = 0
+			if max := gtx.Dp(unit.Dp(560)); gtx.Constraints.Max.X > max {
+				gtx.Constraints.Max.X = max
+			}
+			m := op.Record(gtx.Ops)
+			dims := layout.UniformInset(unit.Dp(12)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						l := material.Body2(a.th, t.msg)
+						l.Color = ColorText
+						return l.Layout(gtx)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(12)}.Layout),
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						btn := material.Button(a.th, &t.closeBtn, "✕")
+						btn.Background = color.NRGBA{A: 0}
+						btn.Color = ColorSubtext
+						btn.TextSize = unit.Sp(12)
+						btn.Inset = layout.UniformInset(unit.Dp(4))
+						return btn.Layout(gtx)
+					}),
+				)
+			})
+			call := m.Stop()
+
+			r := clip.UniformRRect(image.Rectangle{Max: dims.Size}, 8).Push(gtx.Ops)
+			paint.ColorOp{Color: bg}.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			r.Pop()
+			call.Add(gtx.Ops)
+			return dims
+		})
+	})
+}
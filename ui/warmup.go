@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"progresstracker/data"
+	"progresstracker/logic"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// warmupRow is a row of generated warm-up sets, each logged with one click.
+type warmupRow struct {
+	sets []logic.WarmupSet
+	done []bool
+	btns []widget.Clickable
+}
+
+// show sets the warm-ups on offer, forgetting which were logged if they
+// changed.
+func (w *warmupRow) show(sets []logic.WarmupSet) {
+	if slices.Equal(sets, w.sets) {
+		return
+	}
+	w.sets = sets
+	w.done = make([]bool, len(sets))
+	if len(w.btns) < len(sets) {
+		w.btns = make([]widget.Clickable, len(sets))
+	}
+}
+
+// clicked returns the index of a warm-up set clicked this frame, or -1.
+func (w *warmupRow) clicked(gtx layout.Context) int {
+	for i := range w.sets {
+		if w.btns[i].Clicked(gtx) {
+			return i
+		}
+	}
+	return -1
+}
+
+// workingWeight reads the planned working weight from an editor, or 0.
+func workingWeight(ed *widget.Editor) float64 {
+	w, err := strconv.ParseFloat(strings.TrimSpace(ed.Text()), 64)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+func (a *App) layoutWarmupRow(w *warmupRow) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		if len(w.sets) == 0 {
+			return layout.Dimensions{}
+		}
+		children := []layout.FlexChild{
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				t := material.Caption(a.th, "WARM-UP")
+				t.Color = ColorSubtext
+				return layout.Inset{Right: unit.Dp(10)}.Layout(gtx, t.Layout)
+			}),
+		}
+		for i, s := range w.sets {
+			label := fmt.Sprintf("%g×%d", s.Weight, s.Reps)
+			if w.done[i] {
+				label = "✓ " + label
+			}
+			children = append(children,
+				layout.Rigid(a.smallButton(&w.btns[i], label)),
+				layout.Rigid(layout.Spacer{Width: unit.Dp(6)}.Layout),
+			)
+		}
+		return layout.Inset{Top: unit.Dp(10)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx, children...)
+		})
+	}
+}
+
+// loadWarmupScheme reads the warm-up scheme into the settings card. On
+// failure the default scheme is used.
+func (a *App) loadWarmupScheme() {
+	s, err := a.tracker.WarmupScheme(a.ctx)
+	if err != nil {
+		a.showError(err)
+	}
+	a.warmScheme = s
+	a.warmStepsEdit.SetText(s.String())
+	a.warmBarEdit.SetText(strconv.FormatFloat(s.Bar, 'f', -1, 64))
+}
+
+// updateLogWarmups logs warm-up sets clicked on the log form, and works out
+// the ones to offer from the weight typed in.
+func (a *App) updateLogWarmups(gtx layout.Context) {
+	w := &a.logWarmups
+	ex := a.currentExercise()
+	if i := w.clicked(gtx); i >= 0 {
+		s := w.sets[i]
+		if _, err := a.tracker.AddWarmup(a.ctx, ex, s, a.dateEdit.Text()); err != nil {
+			a.showError(err)
+		} else {
+			w.done[i] = true
+			a.statusMsg = fmt.Sprintf("Warm-up %g×%d logged", s.Weight, s.Reps)
+			a.statusOK = true
+			a.invalidateData()
+		}
+	}
+	w.show(a.warmScheme.For(ex, workingWeight(&a.weightEdit)))
+}
+
+func (a *App) saveWarmupScheme() {
+	s, err := a.tracker.SetWarmupScheme(a.ctx, a.warmStepsEdit.Text(), a.warmBarEdit.Text())
+	var ve *data.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		a.setSettingsStatus(false, "Warm-up scheme not saved: %v", err)
+	case err != nil:
+		a.showError(err)
+	default:
+		a.warmScheme = s
+		a.warmStepsEdit.SetText(s.String())
+		a.setSettingsStatus(true, "Warm-up scheme saved")
+	}
+}
+
+func (a *App) layoutWarmupCard(gtx layout.Context) layout.Dimensions {
+	example := "none, the bar is as heavy as 100 kg"
+	if sets := a.warmScheme.Sets(100); len(sets) > 0 {
+		parts := make([]string, len(sets))
+		for i, s := range sets {
+			parts[i] = fmt.Sprintf("%g×%d", s.Weight, s.Reps)
+		}
+		example = strings.Join(parts, ", ")
+	}
+	return drawCard(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(a.cardTitle("Warm-up Sets", ColorAccent)),
+			layout.Rigid(a.cardLine("Offered before "+strings.Join(data.WarmupExercises, ", ")+".", ColorSubtext)),
+			layout.Rigid(a.cardLine("Working at 100 kg: "+example, ColorSubtext)),
+			layout.Rigid(layout.Spacer{Height: unit.Dp(10)}.Layout),
+			layout.Rigid(a.formField("STEPS (% of working weight × reps)", &a.warmStepsEdit, "bar×10, 40%×5, 60%×3, 80%×2")),
+			layout.Rigid(a.formField("BAR (kg)", &a.warmBarEdit, "20")),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return a.accentButton(gtx, &a.warmSaveBtn, "SAVE")
+			}),
+		)
+	})
+}